@@ -0,0 +1,133 @@
+// Package activity streams the activity of the accounts, their new entries and balance changes, to the clients
+// following it. Every server has a Broker, fed by the notifications of Postgres so it streams the activity
+// written by every replica, or by its own store on the databases without notifications.
+package activity
+
+import (
+	"encoding/json"
+	"sync"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+const (
+	EventEntry = "entry"
+	EventBalance = "balance"
+)
+
+// Event is something that happened to an account. Data is the entry of EventEntry and the Balance of EventBalance.
+type Event struct {
+	Type string `json:"type"`
+	AccountID int64 `json:"account_id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Balance is the data of EventBalance
+type Balance struct {
+	AccountID int64 `json:"account_id"`
+	Balance int64 `json:"balance"`
+	Currency string `json:"currency"`
+}
+
+func newEvent(eventType string, accountID int64, data interface{}) Event {
+	// entries and balances always marshal
+	payload, _ := json.Marshal(data)
+	return Event{Type: eventType, AccountID: accountID, Data: payload}
+}
+
+func NewEntryEvent(entry db.Entry) Event {
+	return newEvent(EventEntry, entry.AccountID, entry)
+}
+
+func NewBalanceEvent(account db.Account) Event {
+	return newEvent(EventBalance, account.ID, Balance{
+		AccountID: account.ID,
+		Balance: account.Balance,
+		Currency: account.Currency,
+	})
+}
+
+// subscriptionBuffer is how many events a subscriber may fall behind before it is dropped
+const subscriptionBuffer = 64
+
+// Broker hands the events it is published to the subscribers of their account
+type Broker struct {
+	mu sync.Mutex
+	subscriptions map[int64]map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscriptions: map[int64]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events of an account until it is closed
+type Subscription struct {
+	broker *Broker
+	accountID int64
+	events chan Event
+}
+
+// Subscribe starts receiving the events of the account published from now on
+func (broker *Broker) Subscribe(accountID int64) *Subscription {
+	subscription := &Subscription{broker: broker, accountID: accountID, events: make(chan Event, subscriptionBuffer)}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.subscriptions[accountID] == nil {
+		broker.subscriptions[accountID] = map[*Subscription]struct{}{}
+	}
+	broker.subscriptions[accountID][subscription] = struct{}{}
+	return subscription
+}
+
+// Publish hands event to the subscribers of its account without blocking.
+// Subscribers too far behind to take it are dropped, so they do not miss events unknowingly.
+func (broker *Broker) Publish(event Event) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for subscription := range broker.subscriptions[event.AccountID] {
+		select {
+		case subscription.events <- event:
+		default:
+			broker.remove(subscription)
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions
+func (broker *Broker) Subscribers() int {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	n := 0
+	for _, subscriptions := range broker.subscriptions {
+		n += len(subscriptions)
+	}
+	return n
+}
+
+// remove closes subscription, the broker lock must be held
+func (broker *Broker) remove(subscription *Subscription) {
+	subscriptions, ok := broker.subscriptions[subscription.accountID]
+	if _, subscribed := subscriptions[subscription]; !ok || !subscribed {
+		return
+	}
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(broker.subscriptions, subscription.accountID)
+	}
+	close(subscription.events)
+}
+
+// Events receives the events of the account, it is closed once the subscription is closed or dropped
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+// Close stops receiving events, closing it twice is a no-op
+func (subscription *Subscription) Close() {
+	subscription.broker.mu.Lock()
+	defer subscription.broker.mu.Unlock()
+	subscription.broker.remove(subscription)
+}
@@ -0,0 +1,55 @@
+package activity
+
+import (
+	"testing"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker()
+	subscription1 := broker.Subscribe(1)
+	subscription2 := broker.Subscribe(1)
+	other := broker.Subscribe(2)
+	require.Equal(t, 3, broker.Subscribers())
+
+	event := NewBalanceEvent(db.Account{ID: 1, Balance: 100, Currency: "EUR"})
+	broker.Publish(event)
+
+	require.Equal(t, event, <-subscription1.Events())
+	require.Equal(t, event, <-subscription2.Events())
+	require.Empty(t, other.Events())
+	require.JSONEq(t, `{"account_id": 1, "balance": 100, "currency": "EUR"}`, string(event.Data))
+
+	// a closed subscription receives no more events
+	subscription1.Close()
+	subscription1.Close()
+	_, ok := <-subscription1.Events()
+	require.False(t, ok)
+	require.Equal(t, 2, broker.Subscribers())
+
+	broker.Publish(event)
+	require.Equal(t, event, <-subscription2.Events())
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker()
+	slow := broker.Subscribe(1)
+
+	for i := 0; i < subscriptionBuffer + 1; i++ {
+		broker.Publish(NewEntryEvent(db.Entry{ID: int64(i), AccountID: 1}))
+	}
+	require.Zero(t, broker.Subscribers())
+
+	// the events buffered before it fell behind are received, then the channel is closed
+	for i := 0; i < subscriptionBuffer; i++ {
+		event, ok := <-slow.Events()
+		require.True(t, ok)
+		require.Equal(t, EventEntry, event.Type)
+	}
+	_, ok := <-slow.Events()
+	require.False(t, ok)
+
+	slow.Close()
+}
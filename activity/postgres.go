@@ -0,0 +1,60 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresChannel is notified of the account activity by the triggers of the entries and accounts tables
+const postgresChannel = "account_activity"
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// pingInterval checks the connection when no notification arrives for a while, so it is reopened if lost
+	pingInterval = 90 * time.Second
+)
+
+// Listen publishes to broker the account activity Postgres at dataSource is notified of, until ctx is done.
+// The listening connection is reopened whenever it is lost; the activity notified meanwhile is lost with it.
+func Listen(ctx context.Context, dataSource string, broker *Broker) error {
+	listener := pq.NewListener(dataSource, minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("account activity listener connection failed", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(postgresChannel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// a failed ping makes the listener reconnect
+			listener.Ping()
+		case notification := <-listener.Notify:
+			if notification == nil {
+				slog.Warn("account activity listener reconnected, activity may have been missed")
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				slog.Error("invalid account activity notification", "payload", notification.Extra, "error", err)
+				continue
+			}
+			broker.Publish(event)
+		}
+	}
+}
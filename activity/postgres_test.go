@@ -0,0 +1,77 @@
+package activity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/db/migration"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("needs Postgres")
+	}
+
+	config, err := util.LoadConfig("..")
+	require.NoError(t, err)
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, migration.Up(context.Background(), conn, config.DBDriver))
+
+	store := db.NewStore(conn)
+	account1 := createAccount(t, store, 100)
+	account2 := createAccount(t, store, 0)
+
+	broker := NewBroker()
+	subscription := broker.Subscribe(account2.ID)
+	defer subscription.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Listen(ctx, config.DBSource, broker)
+	}()
+	// notifications sent before the listener is listening are not received
+	time.Sleep(500 * time.Millisecond)
+
+	result, err := store.CreateTransferTx(context.Background(), db.CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 30,
+	})
+	require.NoError(t, err)
+
+	next := func() Event {
+		select {
+		case event := <-subscription.Events():
+			return event
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "event not received")
+			return Event{}
+		}
+	}
+
+	event := next()
+	require.Equal(t, EventEntry, event.Type)
+	require.Equal(t, account2.ID, event.AccountID)
+	var entry db.Entry
+	require.NoError(t, json.Unmarshal(event.Data, &entry))
+	require.Equal(t, result.ToEntry.ID, entry.ID)
+	require.Equal(t, int64(30), entry.Amount)
+
+	event = next()
+	require.Equal(t, EventBalance, event.Type)
+	var balance Balance
+	require.NoError(t, json.Unmarshal(event.Data, &balance))
+	require.Equal(t, Balance{AccountID: account2.ID, Balance: 30, Currency: util.EUR}, balance)
+
+	cancel()
+	require.NoError(t, <-done)
+}
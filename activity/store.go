@@ -0,0 +1,70 @@
+package activity
+
+import (
+	"context"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+// PublishingStore publishes the activity of the transactions of a store to a broker once they commit.
+// It stands in for the Postgres notifications on the databases without them, where the server writing
+// through it is the only writer.
+type PublishingStore struct {
+	db.Store
+	broker *Broker
+}
+
+func NewPublishingStore(store db.Store, broker *Broker) *PublishingStore {
+	return &PublishingStore{Store: store, broker: broker}
+}
+
+// publishTransfer publishes in the order the transfer transaction writes: the entries, then the balances
+func (store *PublishingStore) publishTransfer(result db.CreateTransferTxResult) {
+	store.broker.Publish(NewEntryEvent(result.FromEntry))
+	store.broker.Publish(NewEntryEvent(result.ToEntry))
+	store.broker.Publish(NewBalanceEvent(result.FromAccount))
+	store.broker.Publish(NewBalanceEvent(result.ToAccount))
+}
+
+func (store *PublishingStore) CreateTransferTx(ctx context.Context, arg db.CreateTransferTxParams) (db.CreateTransferTxResult, error) {
+	result, err := store.Store.CreateTransferTx(ctx, arg)
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return result, err
+}
+
+func (store *PublishingStore) ReverseTransferTx(ctx context.Context, transferID int64) (db.CreateTransferTxResult, error) {
+	result, err := store.Store.ReverseTransferTx(ctx, transferID)
+	if err == nil {
+		store.publishTransfer(result)
+	}
+	return result, err
+}
+
+func (store *PublishingStore) PostInterestTx(ctx context.Context, arg db.PostInterestTxParams) (db.PostInterestTxResult, error) {
+	result, err := store.Store.PostInterestTx(ctx, arg)
+	if err == nil {
+		store.publishTransfer(result.Transfer)
+	}
+	return result, err
+}
+
+// UpdateAccountStatusTx publishes the balances of the accounts a closed account is swept to.
+// The sweep transaction does not return its entries, they are not published.
+func (store *PublishingStore) UpdateAccountStatusTx(ctx context.Context, arg db.UpdateAccountStatusTxParams) (db.UpdateAccountStatusTxResult, error) {
+	result, err := store.Store.UpdateAccountStatusTx(ctx, arg)
+	if err != nil || result.SweepTransfer == nil {
+		return result, err
+	}
+
+	for _, id := range []int64{result.SweepTransfer.FromAccountID, result.SweepTransfer.ToAccountID} {
+		account, err := store.Store.GetAccount(ctx, id)
+		if err != nil {
+			// the sweep committed already, only its activity is missed
+			continue
+		}
+		store.broker.Publish(NewBalanceEvent(account))
+	}
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccount(t *testing.T, store db.Store, balance int64) db.Account {
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner: user.Username,
+		Balance: balance,
+		Currency: util.EUR,
+		AccountType: db.AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func receive(t *testing.T, subscription *Subscription) Event {
+	select {
+	case event := <-subscription.Events():
+		return event
+	default:
+		require.FailNow(t, "event not published")
+		return Event{}
+	}
+}
+
+func TestPublishingStoreTransfer(t *testing.T) {
+	broker := NewBroker()
+	store := NewPublishingStore(db.NewMemoryStore(), broker)
+	account1 := createAccount(t, store, 100)
+	account2 := createAccount(t, store, 0)
+
+	subscription := broker.Subscribe(account2.ID)
+	defer subscription.Close()
+
+	result, err := store.CreateTransferTx(context.Background(), db.CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 30,
+	})
+	require.NoError(t, err)
+
+	event := receive(t, subscription)
+	require.Equal(t, EventEntry, event.Type)
+	require.Equal(t, account2.ID, event.AccountID)
+	var entry db.Entry
+	require.NoError(t, json.Unmarshal(event.Data, &entry))
+	require.Equal(t, result.ToEntry, entry)
+
+	event = receive(t, subscription)
+	require.Equal(t, EventBalance, event.Type)
+	var balance Balance
+	require.NoError(t, json.Unmarshal(event.Data, &balance))
+	require.Equal(t, Balance{AccountID: account2.ID, Balance: 30, Currency: util.EUR}, balance)
+	require.Empty(t, subscription.Events())
+
+	// failed transfers publish nothing
+	_, err = store.CreateTransferTx(context.Background(), db.CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 1000,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+	require.Empty(t, subscription.Events())
+
+	_, err = store.ReverseTransferTx(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, EventEntry, receive(t, subscription).Type)
+	event = receive(t, subscription)
+	require.NoError(t, json.Unmarshal(event.Data, &balance))
+	require.Zero(t, balance.Balance)
+}
+
+func TestPublishingStoreSweep(t *testing.T) {
+	broker := NewBroker()
+	store := NewPublishingStore(db.NewMemoryStore(), broker)
+	account := createAccount(t, store, 50)
+	sweepAccount := createAccount(t, store, 0)
+
+	subscription := broker.Subscribe(sweepAccount.ID)
+	defer subscription.Close()
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), db.UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status: db.AccountStatusClosed,
+		SweepAccountID: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+
+	event := receive(t, subscription)
+	require.Equal(t, EventBalance, event.Type)
+	var balance Balance
+	require.NoError(t, json.Unmarshal(event.Data, &balance))
+	require.Equal(t, int64(50), balance.Balance)
+	require.Empty(t, subscription.Events())
+}
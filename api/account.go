@@ -1,44 +1,45 @@
 package api
 
 import (
-	"database/sql"
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/gorkaio/simplebank/db/sqlc"
-	"github.com/lib/pq"
+	"github.com/gorkaio/simplebank/util"
 )
 
 type createAccountRequest struct {
 	Owner string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
+	AccountType string `json:"account_type" binding:"omitempty,oneof=checking savings"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 
-	arg := db.CreateAccountParams{
+	if req.AccountType == "" {
+		req.AccountType = db.AccountTypeChecking
+	}
+
+	arg := db.CreateAccountTxParams{
 		Owner: req.Owner,
 		Currency: req.Currency,
+		AccountType: req.AccountType,
 		Balance: 0,
+		IBANCountryCode: server.config.IBANCountryCode,
+		IBANBankCode: server.config.IBANBankCode,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-				case "foreign_key_violation", "unique_validation":
-					ctx.JSON(http.StatusForbidden, errorResponse(err))
-					return
-			}
-			log.Println(pqErr.Code.Name())
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, storeError(err, constraintErrors{
+			"owner_currency_key": ErrDuplicateAccount,
+			"accounts_owner_fkey": ErrUnknownUser,
+		}))
 		return
 	}
 
@@ -52,21 +53,37 @@ type getAccountRequest struct {
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 	
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	accountResponse(ctx, account)
+}
+
+type getAccountByNumberRequest struct {
+	Number string `uri:"number" binding:"required,iban"`
+}
+
+func (server *Server) getAccountByNumber(ctx *gin.Context) {
+	var req getAccountByNumberRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	account, err := server.store.GetAccountByNumber(ctx, util.NormalizeIBAN(req.Number))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	accountResponse(ctx, account)
 }
 
 type listAccountsRequest struct {
@@ -77,7 +94,7 @@ type listAccountsRequest struct {
 func (server *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 	
@@ -87,9 +104,49 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	})
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, accounts)
-}
\ No newline at end of file
+}
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	result, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: uri.ID,
+		Status: req.Status,
+		SweepAccountID: req.SweepAccountID,
+		Version: version,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("ETag", accountETag(result.Account))
+	ctx.JSON(http.StatusOK, result)
+}
+
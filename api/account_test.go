@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +16,7 @@ import (
 	mockdb "github.com/gorkaio/simplebank/db/mock"
 	db "github.com/gorkaio/simplebank/db/sqlc"
 	"github.com/gorkaio/simplebank/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,7 +37,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{Owner: account.Owner, Currency: account.Currency})).
+					CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountTxParams{Owner: account.Owner, Currency: account.Currency, AccountType: db.AccountTypeChecking, IBANCountryCode: "ES", IBANBankCode: "9999"})).
 					Times(1).
 					Return(account, nil)
 			},
@@ -44,6 +46,39 @@ func TestCreateAccountAPI(t *testing.T) {
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name: "OKSavings",
+			body: gin.H{
+				"owner": account.Owner,
+				"currency": account.Currency,
+				"account_type": db.AccountTypeSavings,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountTxParams{Owner: account.Owner, Currency: account.Currency, AccountType: db.AccountTypeSavings, IBANCountryCode: "ES", IBANBankCode: "9999"})).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusOK)
+			},
+		},
+		{
+			name: "BadRequestWithInvalidAccountType",
+			body: gin.H{
+				"owner": account.Owner,
+				"currency": account.Currency,
+				"account_type": "investment",
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusBadRequest)
+			},
+		},
 		{
 			name: "InternalServerError",
 			body: gin.H{
@@ -52,7 +87,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Account{}, sql.ErrConnDone)
 			},
@@ -60,6 +95,55 @@ func TestCreateAccountAPI(t *testing.T) {
 				require.Equal(t, recorder.Code, http.StatusInternalServerError)
 			},
 		},
+		{
+			name: "DuplicateAccount",
+			body: gin.H{
+				"owner": account.Owner,
+				"currency": account.Currency,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23505", Constraint: "owner_currency_key"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusForbidden)
+			},
+		},
+		{
+			name: "UnknownOwner",
+			body: gin.H{
+				"owner": account.Owner,
+				"currency": account.Currency,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23503", Constraint: "accounts_owner_fkey"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusForbidden)
+			},
+		},
+		{
+			name: "AccountNumberTaken",
+			body: gin.H{
+				"owner": account.Owner,
+				"currency": account.Currency,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23505", Constraint: "account_number_key"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// not a duplicate account, the client can try again and get another number
+				require.Equal(t, recorder.Code, http.StatusConflict)
+			},
+		},
 		{
 			name: "BadRequestWithoutOwner",
 			body: gin.H{
@@ -67,7 +151,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -81,7 +165,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -96,7 +180,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -115,7 +199,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 		
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			body, err := json.Marshal(tc.body)
@@ -216,7 +300,7 @@ func TestListAccountsAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 		
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 		
 			url := tc.url
@@ -235,6 +319,7 @@ func TestGetAccountAPI(t *testing.T) {
 	testCases := []struct{
 		name string
 		accountID int64
+		ifNoneMatch string
 		buildStubs func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
@@ -249,6 +334,38 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, recorder.Code, http.StatusOK)
+				require.Equal(t, fmt.Sprintf(`"%d"`, account.Version), recorder.Header().Get("ETag"))
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "NotModified",
+			accountID: account.ID,
+			ifNoneMatch: fmt.Sprintf(`"%d"`, account.Version),
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, account.Version), recorder.Header().Get("ETag"))
+				require.Empty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "OKModified",
+			accountID: account.ID,
+			ifNoneMatch: fmt.Sprintf(`W/"%d", "%d"`, account.Version+1, account.Version+2),
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
@@ -301,12 +418,15 @@ func TestGetAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 		
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 		
 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			if tc.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
 		
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
@@ -336,4 +456,384 @@ func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Ac
 	err = json.Unmarshal(data, &gotAccounts)
 	require.NoError(t, err)
 	require.Equal(t, accounts, gotAccounts)
-}
\ No newline at end of file
+}
+
+func TestGetAccountByNumberAPI(t *testing.T) {
+	account := randomAccount()
+
+	testCases := []struct{
+		name string
+		number string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			number: account.AccountNumber,
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "OKLowercase",
+			number: strings.ToLower(account.AccountNumber),
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			number: account.AccountNumber,
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestWithInvalidChecksum",
+			number: "GB83WEST12345698765432",
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/by-number/%s", tc.number)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateAccountStatusAPI(t *testing.T) {
+	account := randomAccount()
+	etag := fmt.Sprintf(`"%d"`, account.Version)
+
+	testCases := []struct{
+		name string
+		accountID int64
+		ifMatch string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				frozen.Version++
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{AccountID: account.ID, Status: db.AccountStatusFrozen, Version: account.Version})).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: frozen}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprintf(`"%d"`, account.Version+1), recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name: "OKAnyVersion",
+			accountID: account.ID,
+			ifMatch: "*",
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{AccountID: account.ID, Status: db.AccountStatusFrozen})).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PreconditionFailed",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountVersionMismatch)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "precondition_failed")
+			},
+		},
+		{
+			name: "PreconditionFailedWithWeakETag",
+			accountID: account.ID,
+			ifMatch: "W/" + etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{AccountID: account.ID, Status: db.AccountStatusFrozen, Version: -1})).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountVersionMismatch)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "PreconditionRequired",
+			accountID: account.ID,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "precondition_required")
+			},
+		},
+		{
+			name: "BadRequestWithSeveralETags",
+			accountID: account.ID,
+			ifMatch: etag + `, "1"`,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OKCloseWithSweep",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusClosed,
+				"sweep_account_id": account.ID + 1,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateAccountStatusTxParams{AccountID: account.ID, Status: db.AccountStatusClosed, SweepAccountID: account.ID + 1, Version: account.Version})).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidTransition",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusActive,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "invalid_status_transition")
+			},
+		},
+		{
+			name: "NonZeroBalance",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusClosed,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrNonZeroBalance)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "non_zero_balance")
+			},
+		},
+		{
+			name: "SweepAccountClosed",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusClosed,
+				"sweep_account_id": account.ID + 1,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "account_closed")
+			},
+		},
+		{
+			name: "InternalError",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestWithInvalidStatus",
+			accountID: account.ID,
+			ifMatch: etag,
+			body: gin.H{
+				"status": "deleted",
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestWithInvalidID",
+			accountID: 0,
+			ifMatch: etag,
+			body: gin.H{
+				"status": db.AccountStatusFrozen,
+			},
+			buildStubs: func (store *mockdb.MockStore)  {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/status", tc.accountID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchErrorCode(t *testing.T, body *bytes.Buffer, code string) {
+	var got struct {
+		Code string `json:"code"`
+	}
+	err := json.Unmarshal(body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, code, got.Code)
+}
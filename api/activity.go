@@ -0,0 +1,112 @@
+package api
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/activity"
+	"golang.org/x/net/websocket"
+)
+
+// streamHeartbeat is how often an idle event stream sends a comment, so proxies do not close it
+const streamHeartbeat = 15 * time.Second
+
+type streamAccountEventsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// streamAccountEvents streams the new entries and balance changes of an account to its owner, over WebSocket
+// if the request asks for an upgrade and as Server-Sent Events otherwise. The balance is sent first, so
+// clients need not fetch the account, and the stream ends when the server shuts down or the client falls behind.
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req streamAccountEventsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	// subscribed before reading the balance, so no change is missed in between
+	subscription := server.broker.Subscribe(req.ID)
+	defer subscription.Close()
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	if account.Owner != payload.Username {
+		errorResponse(ctx, errAccountNotOwned)
+		return
+	}
+	balance := activity.NewBalanceEvent(account)
+
+	if ctx.IsWebsocket() {
+		websocket.Server{Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			// clients send nothing, reading only tells when they close the connection
+			closed := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, conn)
+				close(closed)
+			}()
+
+			server.stream(subscription, balance, closed, func(event activity.Event) error {
+				return websocket.JSON.Send(conn, event)
+			}, nil)
+		}}.ServeHTTP(ctx.Writer, ctx.Request)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	// keeps nginx from buffering the stream
+	ctx.Header("X-Accel-Buffering", "no")
+	server.stream(subscription, balance, ctx.Request.Context().Done(), func(event activity.Event) error {
+		ctx.SSEvent(event.Type, event.Data)
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	}, func() error {
+		_, err := io.WriteString(ctx.Writer, ": heartbeat\n\n")
+		ctx.Writer.Flush()
+		return err
+	})
+}
+
+// stream sends the first event and then those of subscription, with a heartbeat if given,
+// until the client is gone, sending fails, the subscription is dropped or the server shuts down
+func (server *Server) stream(subscription *activity.Subscription, first activity.Event, gone <-chan struct{}, send func(activity.Event) error, heartbeat func() error) {
+	if err := send(first); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case <-server.streams.Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if heartbeat != nil {
+				if err := heartbeat(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/activity"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// activityTest serves a server backed by a memory store, with an account of a user holding an access token
+type activityTest struct {
+	store db.Store
+	server *Server
+	http *httptest.Server
+	account db.Account
+	accessToken string
+}
+
+func createActivityUser(t *testing.T, store db.Store) db.User {
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func newActivityTest(t *testing.T) *activityTest {
+	store := db.NewMemoryStore()
+	user := createActivityUser(t, store)
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner: user.Username,
+		Balance: 100,
+		Currency: util.EUR,
+		AccountType: db.AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+
+	server := newTestServer(t, store)
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.router)
+	t.Cleanup(httpServer.Close)
+	return &activityTest{store: store, server: server, http: httpServer, account: account, accessToken: accessToken}
+}
+
+func (test *activityTest) get(t *testing.T, accountID int64, accessToken string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/%d/events", test.http.URL, accountID), nil)
+	require.NoError(t, err)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer " + accessToken)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+// waitForSubscribers waits until the broker has n subscribers
+func (test *activityTest) waitForSubscribers(t *testing.T, n int) {
+	require.Eventually(t, func() bool {
+		return test.server.broker.Subscribers() == n
+	}, time.Second, 10*time.Millisecond)
+}
+
+// readSSEvent reads the next event of a Server-Sent Events stream, skipping comments
+func readSSEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+func TestStreamAccountEventsSSE(t *testing.T) {
+	test := newActivityTest(t)
+
+	res := test.get(t, test.account.ID, test.accessToken)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	reader := bufio.NewReader(res.Body)
+
+	// the balance comes first
+	name, data := readSSEvent(t, reader)
+	require.Equal(t, activity.EventBalance, name)
+	require.JSONEq(t, fmt.Sprintf(`{"account_id": %d, "balance": 100, "currency": "EUR"}`, test.account.ID), data)
+
+	entry := db.Entry{ID: 1, AccountID: test.account.ID, Amount: 10}
+	test.server.broker.Publish(activity.NewEntryEvent(entry))
+	name, data = readSSEvent(t, reader)
+	require.Equal(t, activity.EventEntry, name)
+	var got db.Entry
+	require.NoError(t, json.Unmarshal([]byte(data), &got))
+	require.Equal(t, entry, got)
+
+	// shutting down ends the stream
+	require.NoError(t, test.server.Shutdown(context.Background()))
+	_, err := reader.ReadString('\n')
+	require.Error(t, err)
+	test.waitForSubscribers(t, 0)
+}
+
+func TestStreamAccountEventsWebSocket(t *testing.T) {
+	test := newActivityTest(t)
+
+	url := fmt.Sprintf("ws%s/accounts/%d/events?access_token=%s", strings.TrimPrefix(test.http.URL, "http"), test.account.ID, test.accessToken)
+	conn, err := websocket.Dial(url, "", test.http.URL)
+	require.NoError(t, err)
+
+	var event activity.Event
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	require.Equal(t, activity.EventBalance, event.Type)
+	require.Equal(t, test.account.ID, event.AccountID)
+
+	published := activity.NewEntryEvent(db.Entry{ID: 1, AccountID: test.account.ID, Amount: -10})
+	test.server.broker.Publish(published)
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	require.Equal(t, published.Type, event.Type)
+	require.JSONEq(t, string(published.Data), string(event.Data))
+
+	// closing the connection ends the subscription
+	require.NoError(t, conn.Close())
+	test.waitForSubscribers(t, 0)
+}
+
+func TestStreamAccountEventsAuthorization(t *testing.T) {
+	test := newActivityTest(t)
+
+	otherToken, err := test.server.tokenMaker.CreateToken(createActivityUser(t, test.store).Username, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	unknownUserToken, err := test.server.tokenMaker.CreateToken(util.RandomOwner(), token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	expiredToken, err := test.server.tokenMaker.CreateToken(test.account.Owner, token.TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		accountID int64
+		accessToken string
+		status int
+	}{
+		{"NoAccessToken", test.account.ID, "", http.StatusUnauthorized},
+		{"InvalidAccessToken", test.account.ID, "invalid", http.StatusUnauthorized},
+		{"ExpiredAccessToken", test.account.ID, expiredToken, http.StatusUnauthorized},
+		{"UnknownUser", test.account.ID, unknownUserToken, http.StatusUnauthorized},
+		{"OtherUser", test.account.ID, otherToken, http.StatusForbidden},
+		{"AccountNotFound", test.account.ID + 1000, test.accessToken, http.StatusNotFound},
+		{"InvalidID", 0, test.accessToken, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := test.get(t, tc.accountID, tc.accessToken)
+			require.Equal(t, tc.status, res.StatusCode)
+		})
+	}
+	test.waitForSubscribers(t, 0)
+}
+
+func TestStreamAccountEventsRevokedToken(t *testing.T) {
+	test := newActivityTest(t)
+
+	// changing the password revokes the access tokens issued before
+	_, err := test.store.UpdateUserPassword(context.Background(), db.UpdateUserPasswordParams{
+		HashedPassword: "new hashed",
+		Username: test.account.Owner,
+	})
+	require.NoError(t, err)
+
+	res := test.get(t, test.account.ID, test.accessToken)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	test.waitForSubscribers(t, 0)
+}
+
+func TestStreamAccountEventsDisabledUser(t *testing.T) {
+	test := newActivityTest(t)
+
+	// disabling the user revokes the access tokens issued before
+	_, err := test.store.DisableUser(context.Background(), test.account.Owner)
+	require.NoError(t, err)
+
+	res := test.get(t, test.account.ID, test.accessToken)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	test.waitForSubscribers(t, 0)
+}
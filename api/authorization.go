@@ -0,0 +1,64 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/token"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	authorizationBearer = "bearer"
+	// accessTokenParam carries the access token of the clients unable to set headers,
+	// such as the EventSource and WebSocket browser APIs
+	accessTokenParam = "access_token"
+)
+
+// authorizeUser verifies the bearer access token of the request, or its access_token query parameter,
+// answering 401 if it is missing, invalid, revoked by a password change or its user has been disabled
+func (server *Server) authorizeUser(ctx *gin.Context) (*token.Payload, bool) {
+	accessToken := requestAccessToken(ctx)
+	if accessToken == "" {
+		errorResponse(ctx, ErrUnauthorized)
+		return nil, false
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(accessToken, token.TokenTypeAccess)
+	if err != nil {
+		errorResponse(ctx, ErrUnauthorized)
+		return nil, false
+	}
+
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		errorResponse(ctx, ErrUnauthorized)
+		return nil, false
+	}
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, false
+	}
+	if user.IsDisabled || payload.IssuedAt.Before(user.PasswordChangedAt) {
+		errorResponse(ctx, ErrUnauthorized)
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// requestAccessToken returns the bearer access token of the request, or its access_token query parameter,
+// empty if it has none or its Authorization header is malformed
+func requestAccessToken(ctx *gin.Context) string {
+	accessToken := ctx.Query(accessTokenParam)
+	if header := ctx.GetHeader(authorizationHeader); header != "" {
+		fields := strings.Fields(header)
+		if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationBearer {
+			return ""
+		}
+		accessToken = fields[1]
+	}
+	return accessToken
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+)
+
+type beneficiaryOwnerRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type beneficiaryRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// The target account can be given either by ID or by account number (IBAN)
+type createBeneficiaryRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=64"`
+	AccountID int64 `json:"account_id" binding:"required_without=AccountNumber,excluded_with=AccountNumber,omitempty,min=1"`
+	AccountNumber string `json:"account_number" binding:"omitempty,iban"`
+}
+
+func (server *Server) createBeneficiary(ctx *gin.Context) {
+	var uri beneficiaryOwnerRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req createBeneficiaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var account db.Account
+	var err error
+	if req.AccountID != 0 {
+		account, err = server.store.GetAccount(ctx, req.AccountID)
+	} else {
+		account, err = server.store.GetAccountByNumber(ctx, util.NormalizeIBAN(req.AccountNumber))
+	}
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	beneficiary, err := server.store.CreateBeneficiary(ctx, db.CreateBeneficiaryParams{
+		Owner: uri.Username,
+		Nickname: req.Nickname,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		errorResponse(ctx, storeError(err, constraintErrors{
+			"owner_nickname_key": ErrDuplicateBeneficiary,
+			"owner_account_key": ErrDuplicateBeneficiary,
+			"beneficiaries_owner_fkey": ErrUnknownUser,
+		}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+func (server *Server) getBeneficiary(ctx *gin.Context) {
+	var req beneficiaryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	beneficiary, ok := server.ownedBeneficiary(ctx, req.Username, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+type listBeneficiariesRequest struct {
+	PageID int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listBeneficiaries(ctx *gin.Context) {
+	var uri beneficiaryOwnerRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req listBeneficiariesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	beneficiaries, err := server.store.ListBeneficiaries(ctx, db.ListBeneficiariesParams{
+		Owner: uri.Username,
+		Limit: req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beneficiaries)
+}
+
+type updateBeneficiaryRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=64"`
+}
+
+func (server *Server) updateBeneficiary(ctx *gin.Context) {
+	var uri beneficiaryRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req updateBeneficiaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.ownedBeneficiary(ctx, uri.Username, uri.ID); !ok {
+		return
+	}
+
+	beneficiary, err := server.store.UpdateBeneficiaryNickname(ctx, db.UpdateBeneficiaryNicknameParams{
+		ID: uri.ID,
+		Nickname: req.Nickname,
+	})
+	if err != nil {
+		errorResponse(ctx, storeError(err, constraintErrors{"owner_nickname_key": ErrDuplicateBeneficiary}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+// verifyBeneficiary marks a beneficiary as verified, which starts its cooling-off period for large transfers
+func (server *Server) verifyBeneficiary(ctx *gin.Context) {
+	var req beneficiaryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.ownedBeneficiary(ctx, req.Username, req.ID); !ok {
+		return
+	}
+
+	beneficiary, err := server.store.VerifyBeneficiary(ctx, req.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+func (server *Server) deleteBeneficiary(ctx *gin.Context) {
+	var req beneficiaryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.ownedBeneficiary(ctx, req.Username, req.ID); !ok {
+		return
+	}
+
+	err := server.store.DeleteBeneficiary(ctx, req.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ownedBeneficiary fetches a beneficiary of the given user.
+// Beneficiaries of other users are reported as not found.
+func (server *Server) ownedBeneficiary(ctx *gin.Context, username string, id int64) (db.Beneficiary, bool) {
+	beneficiary, err := server.store.GetBeneficiary(ctx, id)
+	if err != nil {
+		errorResponse(ctx, err)
+		return beneficiary, false
+	}
+
+	if beneficiary.Owner != username {
+		errorResponse(ctx, ErrNotFound)
+		return beneficiary, false
+	}
+
+	return beneficiary, true
+}
+
+// validBeneficiary fetches the beneficiary of a transfer from the given account and returns its target account ID.
+// Transfers of at least BeneficiaryLargeTransferAmount require the beneficiary to have been verified
+// at least BeneficiaryCoolingOff ago.
+func (server *Server) validBeneficiary(ctx *gin.Context, beneficiaryID int64, fromAccount db.Account, amount int64) (int64, bool) {
+	beneficiary, err := server.store.GetBeneficiary(ctx, beneficiaryID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return 0, false
+	}
+
+	if beneficiary.Owner != fromAccount.Owner {
+		errorResponse(ctx, errBeneficiaryOwnerMismatch)
+		return 0, false
+	}
+
+	if err := server.checkLargeTransfer(beneficiary, amount); err != nil {
+		errorResponse(ctx, err)
+		return 0, false
+	}
+
+	return beneficiary.AccountID, true
+}
+
+// validTargetAccount checks the target account of a transfer not made to a beneficiary.
+// Transfers of at least BeneficiaryLargeTransferAmount must be made to a beneficiary of the owner
+// of the source account, however the target account is given.
+func (server *Server) validTargetAccount(ctx *gin.Context, fromAccount db.Account, toAccountID int64, amount int64) bool {
+	if !server.isLargeTransfer(amount) {
+		return true
+	}
+
+	beneficiary, err := server.store.GetBeneficiaryByOwnerAndAccount(ctx, db.GetBeneficiaryByOwnerAndAccountParams{
+		Owner: fromAccount.Owner,
+		AccountID: toAccountID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errBeneficiaryRequired
+	}
+	if err == nil {
+		err = server.checkLargeTransfer(beneficiary, amount)
+	}
+	if err != nil {
+		errorResponse(ctx, err)
+		return false
+	}
+	return true
+}
+
+func (server *Server) isLargeTransfer(amount int64) bool {
+	threshold := server.config.BeneficiaryLargeTransferAmount
+	return threshold > 0 && amount >= threshold
+}
+
+// checkLargeTransfer checks the beneficiary of a large transfer was verified at least BeneficiaryCoolingOff ago
+func (server *Server) checkLargeTransfer(beneficiary db.Beneficiary, amount int64) error {
+	if !server.isLargeTransfer(amount) {
+		return nil
+	}
+
+	if !beneficiary.IsVerified {
+		return errBeneficiaryNotVerified
+	}
+
+	coolingOffEnd := beneficiary.VerifiedAt.Add(server.config.BeneficiaryCoolingOff)
+	if time.Now().Before(coolingOffEnd) {
+		return fmt.Errorf("%w until %s", errBeneficiaryCoolingOff, coolingOffEnd.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,566 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBeneficiaryAPI(t *testing.T) {
+	account := randomAccount()
+	beneficiary := randomBeneficiary(util.RandomOwner(), account.ID)
+
+	testCases := []struct {
+		name string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"nickname": beneficiary.Nickname,
+				"account_id": account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiary(gomock.Any(), gomock.Eq(db.CreateBeneficiaryParams{
+						Owner: beneficiary.Owner,
+						Nickname: beneficiary.Nickname,
+						AccountID: account.ID,
+					})).
+					Times(1).
+					Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBeneficiary(t, recorder.Body, beneficiary)
+			},
+		},
+		{
+			name: "OKWithAccountNumber",
+			body: gin.H{
+				"nickname": beneficiary.Nickname,
+				"account_number": account.AccountNumber,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), account.AccountNumber).Times(1).Return(account, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{
+				"nickname": beneficiary.Nickname,
+				"account_id": account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateBeneficiary",
+			body: gin.H{
+				"nickname": beneficiary.Nickname,
+				"account_id": account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(1).Return(db.Beneficiary{}, &pq.Error{Code: "23505", Constraint: "owner_nickname_key"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestWithAccountIDAndNumber",
+			body: gin.H{
+				"nickname": beneficiary.Nickname,
+				"account_id": account.ID,
+				"account_number": account.AccountNumber,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestWithoutNickname",
+			body: gin.H{
+				"account_id": account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/users/%s/beneficiaries", beneficiary.Owner)
+			recorder := serveBeneficiaryRequest(t, tc.buildStubs, http.MethodPost, url, tc.body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetBeneficiaryAPI(t *testing.T) {
+	beneficiary := randomBeneficiary(util.RandomOwner(), util.RandomInt(1, 1000))
+
+	testCases := []struct {
+		name string
+		username string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: beneficiary.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBeneficiary(t, recorder.Body, beneficiary)
+			},
+		},
+		{
+			name: "NotFound",
+			username: beneficiary.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(db.Beneficiary{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "OtherOwner",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/users/%s/beneficiaries/%d", tc.username, beneficiary.ID)
+			recorder := serveBeneficiaryRequest(t, tc.buildStubs, http.MethodGet, url, nil)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListBeneficiariesAPI(t *testing.T) {
+	owner := util.RandomOwner()
+	beneficiaries := []db.Beneficiary{}
+	for i := 0; i < 5; i++ {
+		beneficiaries = append(beneficiaries, randomBeneficiary(owner, util.RandomInt(1, 1000)))
+	}
+
+	buildStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			ListBeneficiaries(gomock.Any(), gomock.Eq(db.ListBeneficiariesParams{Owner: owner, Limit: 5, Offset: 5})).
+			Times(1).
+			Return(beneficiaries, nil)
+	}
+
+	url := fmt.Sprintf("/users/%s/beneficiaries?page_id=2&page_size=5", owner)
+	recorder := serveBeneficiaryRequest(t, buildStubs, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []db.Beneficiary
+	err := json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Len(t, got, len(beneficiaries))
+}
+
+func TestUpdateBeneficiaryAPI(t *testing.T) {
+	beneficiary := randomBeneficiary(util.RandomOwner(), util.RandomInt(1, 1000))
+	updated := beneficiary
+	updated.Nickname = util.RandomOwner()
+
+	buildStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+		store.EXPECT().
+			UpdateBeneficiaryNickname(gomock.Any(), gomock.Eq(db.UpdateBeneficiaryNicknameParams{ID: beneficiary.ID, Nickname: updated.Nickname})).
+			Times(1).
+			Return(updated, nil)
+	}
+
+	url := fmt.Sprintf("/users/%s/beneficiaries/%d", beneficiary.Owner, beneficiary.ID)
+	recorder := serveBeneficiaryRequest(t, buildStubs, http.MethodPatch, url, gin.H{"nickname": updated.Nickname})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchBeneficiary(t, recorder.Body, updated)
+}
+
+func TestVerifyBeneficiaryAPI(t *testing.T) {
+	beneficiary := randomBeneficiary(util.RandomOwner(), util.RandomInt(1, 1000))
+	verified := beneficiary
+	verified.IsVerified = true
+	verified.VerifiedAt = time.Now().UTC().Truncate(time.Second)
+
+	buildStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+		store.EXPECT().VerifyBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(verified, nil)
+	}
+
+	url := fmt.Sprintf("/users/%s/beneficiaries/%d/verify", beneficiary.Owner, beneficiary.ID)
+	recorder := serveBeneficiaryRequest(t, buildStubs, http.MethodPost, url, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchBeneficiary(t, recorder.Body, verified)
+}
+
+func TestDeleteBeneficiaryAPI(t *testing.T) {
+	beneficiary := randomBeneficiary(util.RandomOwner(), util.RandomInt(1, 1000))
+
+	testCases := []struct {
+		name string
+		username string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: beneficiary.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "OtherOwner",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiary(gomock.Any(), beneficiary.ID).Times(1).Return(beneficiary, nil)
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/users/%s/beneficiaries/%d", tc.username, beneficiary.ID)
+			recorder := serveBeneficiaryRequest(t, tc.buildStubs, http.MethodDelete, url, nil)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateTransferToBeneficiaryAPI(t *testing.T) {
+	currency := util.EUR
+	accountFrom := randomAccountWithCurrency(currency)
+	accountTo := randomAccountWithCurrency(currency)
+	transfer, _, _ := randomTransferForAccounts(accountFrom.ID, accountTo.ID)
+
+	unverified := randomBeneficiary(accountFrom.Owner, accountTo.ID)
+	coolingOff := unverified
+	coolingOff.IsVerified = true
+	coolingOff.VerifiedAt = time.Now().Add(-time.Hour)
+	verified := unverified
+	verified.IsVerified = true
+	verified.VerifiedAt = time.Now().Add(-48 * time.Hour)
+	otherOwner := randomBeneficiary(util.RandomOwner(), accountTo.ID)
+
+	expectTransfer := func(store *mockdb.MockStore, amount int64) {
+		store.EXPECT().GetAccount(gomock.Any(), accountTo.ID).Times(1).Return(accountTo, nil)
+		store.EXPECT().
+			CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{
+				FromAccountID: accountFrom.ID,
+				ToAccountID: accountTo.ID,
+				Amount: amount,
+			})).
+			Times(1).
+			Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+	}
+
+	testCases := []struct {
+		name string
+		beneficiary db.Beneficiary
+		amount int64
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OKSmallTransferToUnverified",
+			beneficiary: unverified,
+			amount: 1000,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectTransfer(store, 1000)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OKLargeTransferAfterCoolingOff",
+			beneficiary: verified,
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectTransfer(store, 200000)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "LargeTransferToUnverified",
+			beneficiary: unverified,
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_not_verified")
+			},
+		},
+		{
+			name: "LargeTransferDuringCoolingOff",
+			beneficiary: coolingOff,
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_cooling_off")
+			},
+		},
+		{
+			name: "BeneficiaryOfOtherOwner",
+			beneficiary: otherOwner,
+			amount: 1000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_owner_mismatch")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buildStubs := func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), accountFrom.ID).Times(1).Return(accountFrom, nil)
+				store.EXPECT().GetBeneficiary(gomock.Any(), tc.beneficiary.ID).Times(1).Return(tc.beneficiary, nil)
+				tc.buildStubs(store)
+			}
+
+			body := gin.H{
+				"from_account_id": accountFrom.ID,
+				"beneficiary_id": tc.beneficiary.ID,
+				"currency": currency,
+				"amount": tc.amount,
+			}
+			recorder := serveBeneficiaryRequest(t, buildStubs, http.MethodPost, "/transfers", body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateLargeTransferToAccountAPI(t *testing.T) {
+	currency := util.EUR
+	accountFrom := randomAccountWithCurrency(currency)
+	accountTo := randomAccountWithCurrency(currency)
+	accountTo.ID = accountFrom.ID + 1
+	transfer, _, _ := randomTransferForAccounts(accountFrom.ID, accountTo.ID)
+
+	unverified := randomBeneficiary(accountFrom.Owner, accountTo.ID)
+	coolingOff := unverified
+	coolingOff.IsVerified = true
+	coolingOff.VerifiedAt = time.Now().Add(-time.Hour)
+	verified := unverified
+	verified.IsVerified = true
+	verified.VerifiedAt = time.Now().Add(-48 * time.Hour)
+	beneficiaryArg := db.GetBeneficiaryByOwnerAndAccountParams{Owner: accountFrom.Owner, AccountID: accountTo.ID}
+
+	testCases := []struct {
+		name string
+		amount int64
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OKSmallTransfer",
+			amount: 1000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiaryByOwnerAndAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OKAfterCoolingOff",
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiaryByOwnerAndAccount(gomock.Any(), gomock.Eq(beneficiaryArg)).Times(1).Return(verified, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{
+						FromAccountID: accountFrom.ID,
+						ToAccountID: accountTo.ID,
+						Amount: 200000,
+					})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotABeneficiary",
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiaryByOwnerAndAccount(gomock.Any(), gomock.Eq(beneficiaryArg)).Times(1).Return(db.Beneficiary{}, sql.ErrNoRows)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_required")
+			},
+		},
+		{
+			name: "Unverified",
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiaryByOwnerAndAccount(gomock.Any(), gomock.Eq(beneficiaryArg)).Times(1).Return(unverified, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_not_verified")
+			},
+		},
+		{
+			name: "DuringCoolingOff",
+			amount: 200000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeneficiaryByOwnerAndAccount(gomock.Any(), gomock.Eq(beneficiaryArg)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "beneficiary_cooling_off")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buildStubs := func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), accountFrom.ID).Times(1).Return(accountFrom, nil)
+				store.EXPECT().GetAccount(gomock.Any(), accountTo.ID).Times(1).Return(accountTo, nil)
+				tc.buildStubs(store)
+			}
+
+			body := gin.H{
+				"from_account_id": accountFrom.ID,
+				"to_account_id": accountTo.ID,
+				"currency": currency,
+				"amount": tc.amount,
+			}
+			recorder := serveBeneficiaryRequest(t, buildStubs, http.MethodPost, "/transfers", body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func serveBeneficiaryRequest(t *testing.T, buildStubs func(store *mockdb.MockStore), method string, url string, body gin.H) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	buildStubs(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func randomBeneficiary(owner string, accountID int64) db.Beneficiary {
+	return db.Beneficiary{
+		ID: util.RandomInt(1, 1000),
+		Owner: owner,
+		Nickname: util.RandomOwner(),
+		AccountID: accountID,
+	}
+}
+
+func requireBodyMatchBeneficiary(t *testing.T, body *bytes.Buffer, beneficiary db.Beneficiary) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var got db.Beneficiary
+	err = json.Unmarshal(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, beneficiary.ID, got.ID)
+	require.Equal(t, beneficiary.Owner, got.Owner)
+	require.Equal(t, beneficiary.Nickname, got.Nickname)
+	require.Equal(t, beneficiary.AccountID, got.AccountID)
+	require.Equal(t, beneficiary.IsVerified, got.IsVerified)
+	require.WithinDuration(t, beneficiary.VerifiedAt, got.VerifiedAt, time.Second)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Routes serving the documentation itself and operational endpoints are not part of the API
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs": true,
+	"GET /docs/swagger-ui.css": true,
+	"GET /docs/swagger-ui-bundle.js": true,
+	"GET /metrics": true,
+	"GET /healthz": true,
+	"GET /readyz": true,
+}
+
+var ginPathParam = regexp.MustCompile(`:([^/]+)`)
+
+type openAPIDocument struct {
+	OpenAPI string `json:"openapi"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func parseOpenAPISpec(t *testing.T) openAPIDocument {
+	var doc openAPIDocument
+	err := json.Unmarshal(openAPISpec, &doc)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+	return doc
+}
+
+func TestOpenAPIDocumentsAllRoutes(t *testing.T) {
+	doc := parseOpenAPISpec(t)
+	server := newTestServer(t, nil)
+
+	registered := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		if undocumentedRoutes[route.Method+" "+route.Path] {
+			continue
+		}
+
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		_, ok := doc.Paths[path][method]
+		require.True(t, ok, "route %s %s is missing from the OpenAPI document", route.Method, path)
+	}
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			require.True(t, registered[method+" "+path], "documented route %s %s is not registered", strings.ToUpper(method), path)
+		}
+	}
+}
+
+func TestGetOpenAPISpec(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+	require.True(t, json.Valid(recorder.Body.Bytes()))
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/docs", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "/openapi.json")
+	require.NotContains(t, recorder.Body.String(), "https://")
+
+	// the assets of the page are served by the server itself
+	for _, asset := range []string{"/docs/swagger-ui.css", "/docs/swagger-ui-bundle.js"} {
+		require.Contains(t, recorder.Body.String(), asset)
+
+		assetRecorder := httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, asset, nil)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(assetRecorder, request)
+		require.Equal(t, http.StatusOK, assetRecorder.Code, asset)
+		require.NotEmpty(t, assetRecorder.Body.Bytes(), asset)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound = errors.New("resource not found")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrDuplicateUser = errors.New("username or email already in use")
+	ErrDuplicateAccount = errors.New("owner already has an account in this currency")
+	ErrDuplicateBeneficiary = errors.New("beneficiary nickname or account already in use")
+	ErrUnknownUser = errors.New("user does not exist")
+	ErrAlreadyExists = errors.New("resource already exists")
+	ErrInvalidDocument = errors.New("invalid document")
+	ErrTooManyRequests = errors.New("too many requests, retry later")
+	ErrUnauthorized = errors.New("missing or invalid access token")
+	ErrPreconditionRequired = errors.New("If-Match header with the ETag of the resource is required")
+)
+
+var (
+	errBeneficiaryNotVerified = errors.New("beneficiary must be verified for large transfers")
+	errBeneficiaryCoolingOff = errors.New("beneficiary is in its cooling-off period for large transfers")
+	errBeneficiaryRequired = errors.New("target account must be a beneficiary of the owner of the source account for large transfers")
+	errBeneficiaryOwnerMismatch = errors.New("beneficiary does not belong to the owner of the source account")
+	errAccountNotOwned = errors.New("account does not belong to the authenticated user")
+	errEmailNotVerified = errors.New("owner of the source account must verify their email first")
+	errWrongPassword = errors.New("current password is wrong")
+	errUserDisabled = errors.New("user is disabled")
+	errStepUpRequired = errors.New("totp_code of the owner of the source account is required")
+)
+
+// constraintErrors are the domain errors of the constraint violations a request can cause, by constraint name
+type constraintErrors map[string]error
+
+// storeError replaces constraint violations reported by the store with the domain errors of their constraints,
+// so the database error text never reaches the client. Other unique violations are ErrAlreadyExists.
+func storeError(err error, errs constraintErrors) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	if domainErr, ok := errs[pqErr.Constraint]; ok {
+		return domainErr
+	}
+	if pqErr.Code.Name() == "unique_violation" {
+		return ErrAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+// accountETag is the entity tag of an account, changing with its version
+func accountETag(account db.Account) string {
+	return fmt.Sprintf(`"%d"`, account.Version)
+}
+
+// parseETags splits an If-Match or If-None-Match header into its entity tags
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ifMatchVersion reads the account version required by the If-Match header of the request, zero for any version.
+// Tags that are weak or not an account version can never match, so they require a version no account has.
+func ifMatchVersion(ctx *gin.Context) (int64, error) {
+	tags := parseETags(ctx.GetHeader("If-Match"))
+	if len(tags) == 0 {
+		return 0, ErrPreconditionRequired
+	}
+	if len(tags) > 1 {
+		return 0, &ValidationError{Detail: "If-Match must be a single entity tag or *"}
+	}
+	if tags[0] == "*" {
+		return 0, nil
+	}
+
+	var version int64
+	if _, err := fmt.Sscanf(tags[0], `"%d"`, &version); err != nil || version < 1 || accountETag(db.Account{Version: version}) != tags[0] {
+		return -1, nil
+	}
+	return version, nil
+}
+
+// accountResponse answers with the account and its ETag, or with 304 when the If-None-Match header of the
+// request shows the client has this version already
+func accountResponse(ctx *gin.Context, account db.Account) {
+	etag := accountETag(account)
+	ctx.Header("ETag", etag)
+	for _, tag := range parseETags(ctx.GetHeader("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
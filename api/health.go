@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/db/migration"
+	"github.com/gorkaio/simplebank/logger"
+)
+
+// readinessTimeout bounds the checks run by readyz, so a hung database fails the probe instead of blocking it
+const readinessTimeout = 2 * time.Second
+
+const (
+	checkOK = "ok"
+	checkFailed = "failed"
+)
+
+type readinessResponse struct {
+	Status string `json:"status"`
+	Checks map[string]string `json:"checks"`
+	// SchemaVersion is the migration the database is at, if it could be read
+	SchemaVersion *int64 `json:"schema_version,omitempty"`
+}
+
+// healthz tells the server process is up and serving requests
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": checkOK})
+}
+
+// readyz tells the server can handle traffic: the database is reachable and its schema is clean and at least
+// at the latest migration embedded in the server. A newer schema is accepted so old instances keep serving
+// during a rolling upgrade. Failures are logged, not returned, as the endpoint is public.
+func (server *Server) readyz(ctx *gin.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	rsp := readinessResponse{
+		Status: checkOK,
+		Checks: map[string]string{"database": checkOK, "migrations": checkOK},
+	}
+	fail := func(check string, err error) {
+		logger.FromContext(ctx).WarnContext(ctx, "readiness check failed", "check", check, "error", err)
+		rsp.Status = checkFailed
+		rsp.Checks[check] = checkFailed
+	}
+
+	if err := server.store.Ping(checkCtx); err != nil {
+		fail("database", err)
+		rsp.Checks["migrations"] = checkFailed
+	} else if version, err := server.store.GetSchemaVersion(checkCtx); err != nil {
+		fail("migrations", err)
+	} else {
+		rsp.SchemaVersion = &version.Version
+		if version.Dirty {
+			fail("migrations", fmt.Errorf("schema version %d is dirty", version.Version))
+		} else if latest := migration.LatestVersion(); version.Version < latest {
+			fail("migrations", fmt.Errorf("schema version %d is behind version %d", version.Version, latest))
+		}
+	}
+
+	status := http.StatusOK
+	if rsp.Status != checkOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, rsp)
+}
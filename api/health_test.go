@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorkaio/simplebank/db/migration"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthzAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestReadyzAPI(t *testing.T) {
+	testCases := []struct {
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: migration.LatestVersion()}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				rsp := requireReadiness(t, recorder, http.StatusOK, checkOK, checkOK)
+				require.Equal(t, migration.LatestVersion(), *rsp.SchemaVersion)
+			},
+		},
+		{
+			name: "SchemaAhead",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: migration.LatestVersion() + 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				rsp := requireReadiness(t, recorder, http.StatusOK, checkOK, checkOK)
+				require.Equal(t, migration.LatestVersion()+1, *rsp.SchemaVersion)
+			},
+		},
+		{
+			name: "SchemaBehind",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: migration.LatestVersion() - 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				rsp := requireReadiness(t, recorder, http.StatusServiceUnavailable, checkOK, checkFailed)
+				require.Equal(t, migration.LatestVersion()-1, *rsp.SchemaVersion)
+			},
+		},
+		{
+			name: "DatabaseUnreachable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				rsp := requireReadiness(t, recorder, http.StatusServiceUnavailable, checkFailed, checkFailed)
+				require.Nil(t, rsp.SchemaVersion)
+				require.NotContains(t, recorder.Body.String(), "connection refused")
+			},
+		},
+		{
+			name: "NotMigrated",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireReadiness(t, recorder, http.StatusServiceUnavailable, checkOK, checkFailed)
+			},
+		},
+		{
+			name: "DirtySchema",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: 6, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireReadiness(t, recorder, http.StatusServiceUnavailable, checkOK, checkFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireReadiness(t *testing.T, recorder *httptest.ResponseRecorder, status int, database string, migrations string) readinessResponse {
+	require.Equal(t, status, recorder.Code)
+
+	var rsp readinessResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+
+	expectedStatus := checkOK
+	if status != http.StatusOK {
+		expectedStatus = checkFailed
+	}
+	require.Equal(t, expectedStatus, rsp.Status)
+	require.Equal(t, database, rsp.Checks["database"])
+	require.Equal(t, migrations, rsp.Checks["migrations"])
+	return rsp
+}
+
+func TestServerShutdown(t *testing.T) {
+	account := randomAccount()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the request stays in flight until release is closed
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), account.ID).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			close(inFlight)
+			<-release
+			return account, nil
+		})
+
+	server := newTestServer(t, store)
+	address := freeAddress(t)
+
+	started := make(chan error, 1)
+	go func() {
+		started <- server.Start(address)
+	}()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		url := fmt.Sprintf("http://%s/accounts/%d", address, account.ID)
+		for {
+			rsp, err := http.Get(url)
+			if err == nil {
+				responses <- rsp
+				return
+			}
+			// the listener may not be up yet
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	<-inFlight
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown did not wait for the in-flight request")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	rsp := <-responses
+	defer rsp.Body.Close()
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-started)
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
@@ -3,12 +3,33 @@ package api
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/activity"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/mailer"
+	"github.com/gorkaio/simplebank/metrics"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+		BeneficiaryLargeTransferAmount: 100000,
+		BeneficiaryCoolingOff: 24 * time.Hour,
+		TokenSymmetricKey: util.RandomString(32),
+	}
+
+	server, err := NewServer(config, store, metrics.New(), nil, activity.NewBroker(), mailer.NewMemoryMailer())
+	require.NoError(t, err)
+	return server
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 
 	os.Exit(m.Run())
-}
\ No newline at end of file
+}
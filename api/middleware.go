@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/token"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	tracerName = "github.com/gorkaio/simplebank/api"
+)
+
+// requestIDMiddleware takes the request ID from the X-Request-ID header, or generates one,
+// and carries it in the request context so it reaches the store and the logs
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := logger.RequestIDOrNew(ctx.GetHeader(requestIDHeader))
+		ctx.Request = ctx.Request.WithContext(logger.WithRequestID(ctx.Request.Context(), requestID))
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+// tracingMiddleware starts a server span for every request, continuing the trace of the caller
+// if it sent a traceparent header. The span is named after the route pattern, not the actual path.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		spanCtx, span := otel.Tracer(tracerName).Start(parent, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", ctx.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("request_id", logger.RequestID(ctx.Request.Context())),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// loggerMiddleware logs every request once it has been served.
+// Query strings are left out as they may carry sensitive values.
+func loggerMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger.FromContext(ctx.Request.Context()).Log(ctx.Request.Context(), level, "http request",
+			"method", ctx.Request.Method,
+			"route", ctx.FullPath(),
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"size", ctx.Writer.Size(),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
+
+// metricsMiddleware records every request by route pattern. Requests not matching any route
+// are grouped together so they cannot grow the number of series.
+func (server *Server) metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		server.metrics.ObserveHTTPRequest(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+// recoveryMiddleware answers panics with an internal error problem instead of crashing the server
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, recovered any) {
+		errorResponse(ctx, fmt.Errorf("panic: %v", recovered))
+	})
+}
+
+// rateLimitMiddleware answers with a rate limited problem and a Retry-After header once the client
+// runs out of requests for the route. Requests with a valid access token are limited by its username,
+// so users behind the same address do not share their limit, and the others by client IP. The handlers
+// still authorize the request, the token only picks the bucket here. Requests are let through if the
+// limiter store fails, limits are not worth an outage.
+func (server *Server) rateLimitMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		client := ratelimit.IPClient(ctx.ClientIP())
+		if payload, err := server.tokenMaker.VerifyToken(requestAccessToken(ctx), token.TokenTypeAccess); err == nil {
+			client = ratelimit.UserClient(payload.Username)
+		}
+
+		allowed, retryAfter, err := server.limiter.Allow(ctx, route, client)
+		if err != nil {
+			logger.FromContext(ctx.Request.Context()).Error("rate limit failed", "error", err)
+			ctx.Next()
+			return
+		}
+
+		if !allowed {
+			server.metrics.RateLimited(route)
+			ctx.Header("Retry-After", strconv.FormatInt(ratelimit.RetryAfterSeconds(retryAfter), 10))
+			errorResponse(ctx, ErrTooManyRequests)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,412 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/tracing"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	account := randomAccount()
+
+	testCases := []struct {
+		name string
+		requestID string
+		checkRequestID func(t *testing.T, requestID string)
+	}{
+		{
+			name: "Provided",
+			requestID: "client-request-1",
+			checkRequestID: func(t *testing.T, requestID string) {
+				require.Equal(t, "client-request-1", requestID)
+			},
+		},
+		{
+			name: "Generated",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Invalid",
+			requestID: "client request\n1",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var storeRequestID string
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetAccount(gomock.Any(), account.ID).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+					storeRequestID = logger.RequestID(ctx)
+					return account, nil
+				})
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			requestID := recorder.Header().Get(requestIDHeader)
+			tc.checkRequestID(t, requestID)
+			require.Equal(t, requestID, storeRequestID)
+		})
+	}
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger, err := logger.New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(testLogger)
+	defer slog.SetDefault(defaultLogger)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body, err := json.Marshal(gin.H{"username": "johndoe", "password": "supersecret", "email": "john@example.com"})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users?token=abc", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeader, "client-request-1")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "http request", entry["msg"])
+	require.Equal(t, "client-request-1", entry["request_id"])
+	require.Equal(t, "/users", entry["route"])
+	require.Equal(t, "/users", entry["path"])
+	require.Equal(t, float64(http.StatusBadRequest), entry["status"])
+
+	require.NotContains(t, buf.String(), "supersecret")
+	require.NotContains(t, buf.String(), "john@example.com")
+	require.NotContains(t, buf.String(), "token=abc")
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			panic("boom")
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	problem := requireProblem(t, recorder, http.StatusInternalServerError, "internal_error")
+	require.NotContains(t, problem.Detail, "boom")
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	account := randomAccount()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+
+	for _, url := range []string{fmt.Sprintf("/accounts/%d", account.ID), "/unknown/1", "/unknown/2"} {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		server.router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `simplebank_http_requests_total{method="GET",route="/accounts/:id",status="200"} 1`)
+	require.Contains(t, recorder.Body.String(), `simplebank_http_requests_total{method="GET",route="unmatched",status="404"} 2`)
+	require.Contains(t, recorder.Body.String(), `simplebank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="200"} 1`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	account := randomAccount()
+
+	spans := tracetest.NewSpanRecorder()
+	defaultProvider := otel.GetTracerProvider()
+	defaultPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tracing.NewProvider(spans))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(defaultProvider)
+	defer otel.SetTextMapPropagator(defaultPropagator)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var storeSpan trace.SpanContext
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), account.ID).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			storeSpan = trace.SpanContextFromContext(ctx)
+			return db.Account{}, errors.New("connection lost")
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	request.Header.Set(requestIDHeader, "client-request-1")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	require.Equal(t, "GET /accounts/:id", span.Name())
+	require.Equal(t, trace.SpanKindServer, span.SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	require.Equal(t, codes.Error, span.Status().Code)
+	require.Subset(t, span.Attributes(), []attribute.KeyValue{
+		attribute.String("http.method", http.MethodGet),
+		attribute.String("http.route", "/accounts/:id"),
+		attribute.Int("http.status_code", http.StatusInternalServerError),
+		attribute.String("request_id", "client-request-1"),
+	})
+
+	// the store runs within the request span
+	require.Equal(t, span.SpanContext().SpanID(), storeSpan.SpanID())
+}
+
+type failingLimiterStore struct{}
+
+func (failingLimiterStore) Take(context.Context, string, float64, float64) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	account := randomAccount()
+	limits := map[string]util.RateLimit{"GET /accounts/:id": {Requests: 2, Period: time.Minute}}
+
+	testCases := []struct {
+		name string
+		limiterStore ratelimit.Store
+		buildStubs func(store *mockdb.MockStore)
+		checkResponses func(t *testing.T, recorders []*httptest.ResponseRecorder)
+	}{
+		{
+			name: "Limited",
+			limiterStore: ratelimit.NewMemoryStore(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(2).Return(account, nil)
+			},
+			checkResponses: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorders[0].Code)
+				require.Equal(t, http.StatusOK, recorders[1].Code)
+
+				requireProblem(t, recorders[2], http.StatusTooManyRequests, "rate_limited")
+				require.Equal(t, "30", recorders[2].Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "StoreError",
+			limiterStore: failingLimiterStore{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(3).Return(account, nil)
+			},
+			checkResponses: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			// other routes are not limited
+			store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(3).Return(db.Transfer{}, nil)
+
+			limiter, err := ratelimit.NewLimiter(tc.limiterStore, limits)
+			require.NoError(t, err)
+			server := newTestServer(t, store)
+			server.limiter = limiter
+
+			recorders := make([]*httptest.ResponseRecorder, 3)
+			for i := range recorders {
+				recorders[i] = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+				require.NoError(t, err)
+				request.RemoteAddr = "10.0.0.1:1234"
+				server.router.ServeHTTP(recorders[i], request)
+
+				request, err = http.NewRequest(http.MethodGet, "/transfers/1", nil)
+				require.NoError(t, err)
+				request.RemoteAddr = "10.0.0.1:1234"
+				recorder := httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+				require.Equal(t, http.StatusOK, recorder.Code)
+			}
+			tc.checkResponses(t, recorders)
+		})
+	}
+}
+
+func TestRateLimitMiddlewareForwardedFor(t *testing.T) {
+	account := randomAccount()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(2).Return(account, nil)
+
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]util.RateLimit{
+		"GET /accounts/:id": {Requests: 1, Period: time.Minute},
+	})
+	require.NoError(t, err)
+	server := newTestServer(t, store)
+	server.limiter = limiter
+
+	// X-Forwarded-For is ignored without trusted proxies, so it cannot be used to get a new bucket
+	// or to take the bucket of another client
+	requests := []struct {
+		remoteAddr string
+		status int
+	}{
+		{"10.0.0.1:1234", http.StatusOK},
+		{"10.0.0.1:1234", http.StatusTooManyRequests},
+		{"10.0.0.2:1234", http.StatusOK},
+	}
+	for _, r := range requests {
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+		require.NoError(t, err)
+		request.RemoteAddr = r.remoteAddr
+		request.Header.Set("X-Forwarded-For", "10.0.0.3")
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, r.status, recorder.Code)
+	}
+}
+
+func TestRateLimitMiddlewareByUser(t *testing.T) {
+	account := randomAccount()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(3).Return(account, nil)
+
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]util.RateLimit{
+		"GET /accounts/:id": {Requests: 1, Period: time.Minute},
+	})
+	require.NoError(t, err)
+	server := newTestServer(t, store)
+	server.limiter = limiter
+
+	user1Token, err := server.tokenMaker.CreateToken(util.RandomOwner(), token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	user2Token, err := server.tokenMaker.CreateToken(util.RandomOwner(), token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	expiredToken, err := server.tokenMaker.CreateToken(util.RandomOwner(), token.TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+
+	// users behind the same address have their own limits, requests without a valid token share the address limit
+	requests := []struct {
+		accessToken string
+		status int
+	}{
+		{user1Token, http.StatusOK},
+		{user1Token, http.StatusTooManyRequests},
+		{user2Token, http.StatusOK},
+		{"", http.StatusOK},
+		{expiredToken, http.StatusTooManyRequests},
+	}
+	for _, r := range requests {
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:1234"
+		if r.accessToken != "" {
+			request.Header.Set("Authorization", "Bearer " + r.accessToken)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, r.status, recorder.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorkaio/simplebank/iso20022"
+)
+
+const maxPain001Size = 10 << 20
+
+// importPain001 executes a pain.001 batch and answers with a pain.002 status report.
+// The document can be sent either as the request body or as a multipart "file" field.
+func (server *Server) importPain001(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPain001Size)
+
+	var body io.Reader = ctx.Request.Body
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			bindingErrorResponse(ctx, err)
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			bindingErrorResponse(ctx, err)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	doc, err := iso20022.ParsePain001(body)
+	if err != nil {
+		errorResponse(ctx, fmt.Errorf("%w: %v", ErrInvalidDocument, err))
+		return
+	}
+
+	report, err := iso20022.NewProcessor(server.store, server.config.RequireVerifiedEmail, server.config.StepUpTransferAmount).Process(ctx, doc)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	data, err := report.Marshal()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
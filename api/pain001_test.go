@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/iso20022"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+const testPain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-1</MsgId><NbOfTxs>1</NbOfTxs><CtrlSum>%s</CtrlSum></GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestImportPain001API(t *testing.T) {
+	currency := util.EUR
+	accountFrom := randomAccountWithCurrency(currency)
+	accountTo := randomAccountWithCurrency(currency)
+	transfer, _, _ := randomTransferForAccounts(accountFrom.ID, accountTo.ID)
+	transfer.Amount = util.RandomInt(1, 1000)
+	amount := fmt.Sprintf("%d.%02d", transfer.Amount/100, transfer.Amount%100)
+	document := fmt.Sprintf(testPain001, amount, accountFrom.ID, currency, amount, accountTo.ID)
+
+	testCases := []struct {
+		name string
+		buildRequest func(t *testing.T) *http.Request
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/transfers/pain001", bytes.NewBufferString(document))
+				require.NoError(t, err)
+				request.Header.Set("Content-Type", "application/xml")
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), accountFrom.ID).Times(1).Return(accountFrom, nil)
+				store.EXPECT().GetAccount(gomock.Any(), accountTo.ID).Times(1).Return(accountTo, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{
+						FromAccountID: accountFrom.ID,
+						ToAccountID: accountTo.ID,
+						Amount: transfer.Amount,
+					})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchGroupStatus(t, recorder.Body, iso20022.StatusAcceptedSettlementCompleted)
+			},
+		},
+		{
+			name: "OKMultipart",
+			buildRequest: func(t *testing.T) *http.Request {
+				body := &bytes.Buffer{}
+				writer := multipart.NewWriter(body)
+				part, err := writer.CreateFormFile("file", "batch.xml")
+				require.NoError(t, err)
+				_, err = part.Write([]byte(document))
+				require.NoError(t, err)
+				require.NoError(t, writer.Close())
+
+				request, err := http.NewRequest(http.MethodPost, "/transfers/pain001", body)
+				require.NoError(t, err)
+				request.Header.Set("Content-Type", writer.FormDataContentType())
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), accountFrom.ID).Times(1).Return(accountFrom, nil)
+				store.EXPECT().GetAccount(gomock.Any(), accountTo.ID).Times(1).Return(accountTo, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchGroupStatus(t, recorder.Body, iso20022.StatusAcceptedSettlementCompleted)
+			},
+		},
+		{
+			name: "BadRequest",
+			buildRequest: func(t *testing.T) *http.Request {
+				request, err := http.NewRequest(http.MethodPost, "/transfers/pain001", bytes.NewBufferString("<Document>"))
+				require.NoError(t, err)
+				request.Header.Set("Content-Type", "application/xml")
+				return request
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			server.router.ServeHTTP(recorder, tc.buildRequest(t))
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchGroupStatus(t *testing.T, body *bytes.Buffer, status string) {
+	var report iso20022.StatusReport
+	err := xml.Unmarshal(body.Bytes(), &report)
+	require.NoError(t, err)
+	require.Equal(t, status, report.CustomerPaymentStatusReport.OriginalGroupInformation.GroupStatus)
+}
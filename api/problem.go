@@ -0,0 +1,220 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/webhook"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an error response following RFC 7807.
+// Code is stable and meant to be used by clients, while Detail is only informative.
+type Problem struct {
+	Type string `json:"type"`
+	Title string `json:"title"`
+	Status int `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code string `json:"code"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is a request parameter that failed validation
+type InvalidParam struct {
+	Name string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError is a request that could not be bound or failed validation
+type ValidationError struct {
+	Detail string
+	InvalidParams []InvalidParam
+}
+
+func (e *ValidationError) Error() string {
+	return e.Detail
+}
+
+type problemType struct {
+	err error
+	status int
+	code string
+	title string
+}
+
+// problemTypes maps domain errors to their problem type. Codes must never change once published.
+var problemTypes = []problemType{
+	{ErrNotFound, http.StatusNotFound, "not_found", "Resource not found"},
+	{ErrCurrencyMismatch, http.StatusBadRequest, "currency_mismatch", "Currency mismatch"},
+	{db.ErrInsufficientFunds, http.StatusUnprocessableEntity, "insufficient_funds", "Insufficient funds"},
+	{ErrDuplicateUser, http.StatusForbidden, "duplicate_user", "Duplicate user"},
+	{ErrDuplicateAccount, http.StatusForbidden, "duplicate_account", "Duplicate account"},
+	{ErrDuplicateBeneficiary, http.StatusForbidden, "duplicate_beneficiary", "Duplicate beneficiary"},
+	{ErrUnknownUser, http.StatusForbidden, "unknown_user", "Unknown user"},
+	{ErrAlreadyExists, http.StatusConflict, "already_exists", "Resource already exists"},
+	{ErrInvalidDocument, http.StatusBadRequest, "invalid_document", "Invalid document"},
+	{ErrTooManyRequests, http.StatusTooManyRequests, "rate_limited", "Too many requests"},
+	{ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Unauthorized"},
+	{ErrPreconditionRequired, http.StatusPreconditionRequired, "precondition_required", "Precondition required"},
+	{db.ErrAccountVersionMismatch, http.StatusPreconditionFailed, "precondition_failed", "Precondition failed"},
+	{errAccountNotOwned, http.StatusForbidden, "account_not_owned", "Account not owned"},
+	{webhook.ErrForbiddenAddress, http.StatusBadRequest, "forbidden_webhook_address", "Forbidden webhook address"},
+	{db.ErrInvalidVerifyEmail, http.StatusBadRequest, "invalid_verify_email", "Invalid email verification"},
+	{errEmailNotVerified, http.StatusForbidden, "email_not_verified", "Email not verified"},
+	{errWrongPassword, http.StatusUnauthorized, "wrong_password", "Wrong password"},
+	{errUserDisabled, http.StatusForbidden, "user_disabled", "User disabled"},
+	{db.ErrInvalidPasswordReset, http.StatusBadRequest, "invalid_password_reset", "Invalid password reset"},
+	{db.ErrAccountFrozen, http.StatusForbidden, "account_frozen", "Account frozen"},
+	{db.ErrAccountClosed, http.StatusForbidden, "account_closed", "Account closed"},
+	{db.ErrInvalidStatusTransition, http.StatusConflict, "invalid_status_transition", "Invalid status transition"},
+	{db.ErrNonZeroBalance, http.StatusConflict, "non_zero_balance", "Non-zero balance"},
+	{db.ErrInvalidSweepAccount, http.StatusBadRequest, "invalid_sweep_account", "Invalid sweep account"},
+	{errBeneficiaryOwnerMismatch, http.StatusForbidden, "beneficiary_owner_mismatch", "Beneficiary owner mismatch"},
+	{errBeneficiaryRequired, http.StatusForbidden, "beneficiary_required", "Beneficiary required"},
+	{errBeneficiaryNotVerified, http.StatusForbidden, "beneficiary_not_verified", "Beneficiary not verified"},
+	{errBeneficiaryCoolingOff, http.StatusForbidden, "beneficiary_cooling_off", "Beneficiary in cooling-off period"},
+	{errStepUpRequired, http.StatusForbidden, "step_up_required", "Two-factor code required"},
+	{db.ErrTOTPNotEnrolled, http.StatusForbidden, "totp_not_enrolled", "Two-factor authentication not enrolled"},
+	{db.ErrInvalidTOTPCode, http.StatusForbidden, "invalid_totp_code", "Invalid two-factor code"},
+}
+
+// newProblem maps an error to its problem details.
+// Unknown errors are reported as internal errors without any detail, so SQL or driver messages are never exposed.
+func newProblem(err error) Problem {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return Problem{
+			Type: "/problems/validation_failed",
+			Title: "Validation failed",
+			Status: http.StatusBadRequest,
+			Detail: validationErr.Detail,
+			Code: "validation_failed",
+			InvalidParams: validationErr.InvalidParams,
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrNotFound
+	}
+
+	for _, problemType := range problemTypes {
+		if errors.Is(err, problemType.err) {
+			return Problem{
+				Type: "/problems/" + problemType.code,
+				Title: problemType.title,
+				Status: problemType.status,
+				Detail: err.Error(),
+				Code: problemType.code,
+			}
+		}
+	}
+
+	return Problem{
+		Type: "/problems/internal_error",
+		Title: "Internal server error",
+		Status: http.StatusInternalServerError,
+		Code: "internal_error",
+	}
+}
+
+// errorResponse answers the request with the problem details of err
+func errorResponse(ctx *gin.Context, err error) {
+	problem := newProblem(err)
+	problem.Instance = ctx.Request.URL.Path
+	if problem.Status == http.StatusInternalServerError {
+		logger.FromContext(ctx.Request.Context()).Error("internal error", "error", err)
+	}
+
+	ctx.Header("Content-Type", problemContentType)
+	ctx.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// bindingErrorResponse answers a request that could not be bound with a validation problem
+func bindingErrorResponse(ctx *gin.Context, err error) {
+	errorResponse(ctx, newValidationError(err))
+}
+
+func newValidationError(err error) *ValidationError {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var numErr *strconv.NumError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		params := make([]InvalidParam, len(validationErrs))
+		for i, fieldErr := range validationErrs {
+			params[i] = InvalidParam{Name: fieldErr.Field(), Reason: validationReason(fieldErr)}
+		}
+		return &ValidationError{Detail: "request has invalid parameters", InvalidParams: params}
+	case errors.As(err, &typeErr):
+		return &ValidationError{
+			Detail: "request has invalid parameters",
+			InvalidParams: []InvalidParam{{Name: typeErr.Field, Reason: "must be a " + typeErr.Type.Kind().String()}},
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return &ValidationError{Detail: "request body is not valid JSON"}
+	case errors.As(err, &numErr):
+		return &ValidationError{Detail: fmt.Sprintf("invalid number %q", numErr.Num)}
+	default:
+		return &ValidationError{Detail: "request could not be read"}
+	}
+}
+
+func validationReason(fieldErr validator.FieldError) string {
+	unit := ""
+	if fieldErr.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_without", "required_without_all":
+		return "is required"
+	case "excluded_with":
+		return "cannot be combined with an alternative parameter"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", fieldErr.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", fieldErr.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "email":
+		return "must be a valid email address"
+	case "currency":
+		return "must be a supported currency"
+	case "iban":
+		return "must be a valid IBAN"
+	}
+	return "is invalid"
+}
+
+// paramName names validation errors after the parameter in the request instead of the struct field
+func paramName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
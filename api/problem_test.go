@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationProblem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body, err := json.Marshal(gin.H{"currency": "XYZ", "account_type": "premium"})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	problem := requireProblem(t, recorder, http.StatusBadRequest, "validation_failed")
+	require.Equal(t, "/accounts", problem.Instance)
+	require.ElementsMatch(t, []InvalidParam{
+		{Name: "owner", Reason: "is required"},
+		{Name: "currency", Reason: "must be a supported currency"},
+		{Name: "account_type", Reason: "must be one of: checking, savings"},
+	}, problem.InvalidParams)
+}
+
+func TestValidationProblemMalformedBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	testCases := []struct {
+		name string
+		body string
+		invalidParams []InvalidParam
+	}{
+		{name: "Syntax", body: `{"owner": `},
+		{name: "Type", body: `{"owner": 1, "currency": "EUR"}`, invalidParams: []InvalidParam{{Name: "owner", Reason: "must be a string"}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			problem := requireProblem(t, recorder, http.StatusBadRequest, "validation_failed")
+			require.Equal(t, tc.invalidParams, problem.InvalidParams)
+		})
+	}
+}
+
+func TestInternalErrorProblemHidesDetail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), int64(1)).
+		Times(1).
+		Return(db.Account{}, &pq.Error{Code: "42P01", Message: `relation "accounts" does not exist`})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	problem := requireProblem(t, recorder, http.StatusInternalServerError, "internal_error")
+	require.Empty(t, problem.Detail)
+	require.NotContains(t, recorder.Body.String(), "relation")
+}
+
+func TestDuplicateUserProblem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateUserTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_pkey"`, Constraint: "users_pkey"})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body, err := json.Marshal(gin.H{
+		"username": "johndoe",
+		"password": "secret",
+		"full_name": "John Doe",
+		"email": "john@example.com",
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	problem := requireProblem(t, recorder, http.StatusForbidden, "duplicate_user")
+	require.NotContains(t, problem.Detail, "users_pkey")
+}
+
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, status int, code string) Problem {
+	require.Equal(t, status, recorder.Code)
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+
+	var problem Problem
+	err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Equal(t, status, problem.Status)
+	require.Equal(t, code, problem.Code)
+	require.Equal(t, "/problems/"+code, problem.Type)
+	require.NotEmpty(t, problem.Title)
+	return problem
+}
@@ -1,43 +1,138 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/gorkaio/simplebank/activity"
 	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/mailer"
+	"github.com/gorkaio/simplebank/metrics"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
 )
 
 type Server struct {
+	config util.Config
 	store db.Store
+	metrics *metrics.Metrics
+	limiter *ratelimit.Limiter
+	tokenMaker token.Maker
+	broker *activity.Broker
+	mailer mailer.Mailer
+	// streams is done once the server shuts down, ending the event streams
+	streams context.Context
+	endStreams context.CancelFunc
+	// background tracks the work left running once its request has been answered
+	background sync.WaitGroup
 	router *gin.Engine
+	httpServer *http.Server
 }
 
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+// NewServer creates the HTTP server, streaming the account activity published to broker.
+// A nil limiter disables rate limiting, and a nil mailer sending the password reset emails.
+func NewServer(config util.Config, store db.Store, metrics *metrics.Metrics, limiter *ratelimit.Limiter, broker *activity.Broker, mailer mailer.Mailer) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{config: config, store: store, metrics: metrics, limiter: limiter, tokenMaker: tokenMaker, broker: broker, mailer: mailer}
+	server.streams, server.endStreams = context.WithCancel(context.Background())
+	router := gin.New()
+	// the client IP is only taken from X-Forwarded-For when set by a trusted proxy, so it cannot be spoofed
+	// to get around the rate limits
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		slog.Error("invalid trusted proxies, trusting none", "error", err)
+		router.SetTrustedProxies(nil)
+	}
+	// handlers pass the gin context to the store, which needs the values carried by the request context
+	router.ContextWithFallback = true
+	router.Use(requestIDMiddleware(), tracingMiddleware(), loggerMiddleware(), server.metricsMiddleware(), recoveryMiddleware(),
+		server.rateLimitMiddleware())
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("iban", validIBAN)
+		v.RegisterTagNameFunc(paramName)
 	}
 	
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+	router.GET("/openapi.json", server.getOpenAPISpec)
+	router.GET("/docs", server.getDocs)
+	router.GET("/docs/swagger-ui.css", server.getSwaggerUICSS)
+	router.GET("/docs/swagger-ui-bundle.js", server.getSwaggerUIBundle)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	router.POST("/accounts", server.createAccount)
 	router.GET("/accounts/:id", server.getAccount)
+	router.GET("/accounts/by-number/:number", server.getAccountByNumber)
 	router.GET("/accounts", server.listAccounts)
+	router.PATCH("/accounts/:id/status", server.updateAccountStatus)
+	router.GET("/accounts/:id/events", server.streamAccountEvents)
+	router.POST("/accounts/:id/webhooks", server.createWebhook)
+	router.GET("/accounts/:id/webhooks", server.listWebhooks)
+	router.GET("/accounts/:id/webhooks/:webhook_id", server.getWebhook)
+	router.DELETE("/accounts/:id/webhooks/:webhook_id", server.deleteWebhook)
+	router.GET("/accounts/:id/webhooks/:webhook_id/deliveries", server.listWebhookDeliveries)
+	router.POST("/accounts/:id/webhooks/:webhook_id/deliveries/:delivery_id/replay", server.replayWebhookDelivery)
 
 	router.POST("/transfers", server.createTransfer)
 	router.GET("/transfers/:id", server.getTransfer)
 	router.GET("/transfers", server.listTransfers)
+	router.POST("/transfers/pain001", server.importPain001)
 
 	router.POST("/users", server.createUser)
+	router.GET("/verify_email", server.verifyEmail)
+	router.POST("/forgot_password", server.forgotPassword)
+	router.POST("/reset_password", server.resetPassword)
+	router.PATCH("/users/:username/password", server.changePassword)
+	router.POST("/users/:username/beneficiaries", server.createBeneficiary)
+	router.GET("/users/:username/beneficiaries", server.listBeneficiaries)
+	router.GET("/users/:username/beneficiaries/:id", server.getBeneficiary)
+	router.PATCH("/users/:username/beneficiaries/:id", server.updateBeneficiary)
+	router.DELETE("/users/:username/beneficiaries/:id", server.deleteBeneficiary)
+	router.POST("/users/:username/beneficiaries/:id/verify", server.verifyBeneficiary)
 
 	server.router = router
-	return server
+	server.httpServer = &http.Server{Handler: router}
+	return server, nil
 }
 
+// Start serves HTTP requests on address until the server is shut down
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	server.httpServer.Addr = address
+	err := server.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
-}
\ No newline at end of file
+// Shutdown stops accepting connections, ends the event streams and waits for in-flight requests
+// and the background work they left to finish, until ctx is done
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.endStreams()
+	err := server.httpServer.Shutdown(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		server.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
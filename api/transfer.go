@@ -1,41 +1,73 @@
 package api
 
 import (
-	"database/sql"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
 )
 
+// Accounts can be given either by ID or by account number (IBAN).
+// The target account can also be given as a beneficiary of the owner of the source account.
 type createTransferRequest struct {
-	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	FromAccountID int64 `json:"from_account_id" binding:"required_without=FromAccountNumber,excluded_with=FromAccountNumber,omitempty,min=1"`
+	FromAccountNumber string `json:"from_account_number" binding:"omitempty,iban"`
+	ToAccountID int64 `json:"to_account_id" binding:"required_without_all=ToAccountNumber BeneficiaryID,excluded_with=ToAccountNumber BeneficiaryID,omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"excluded_with=BeneficiaryID,omitempty,iban"`
+	BeneficiaryID int64 `json:"beneficiary_id" binding:"omitempty,min=1"`
 	Amount int64 `json:"amount" binding:"required,gt=0"`
 	Currency string `json:"currency" binding:"required,currency"`
+	// TOTPCode is the second factor of the owner of the source account, required for transfers of at least StepUpTransferAmount
+	TOTPCode string `json:"totp_code"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req createTransferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil  {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 
-	if !server.validAccount(ctx, req.FromAccountID, req.Currency) || !server.validAccount(ctx, req.ToAccountID, req.Currency) {
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.FromAccountNumber, req.Currency)
+	if !valid {
+		return
+	}
+	if !server.verifiedOwner(ctx, fromAccount) {
+		return
+	}
+
+	if req.BeneficiaryID != 0 {
+		req.ToAccountID, valid = server.validBeneficiary(ctx, req.BeneficiaryID, fromAccount, req.Amount)
+		if !valid {
+			return
+		}
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.ToAccountNumber, req.Currency)
+	if !valid {
+		return
+	}
+	if req.BeneficiaryID == 0 && !server.validTargetAccount(ctx, fromAccount, toAccount.ID, req.Amount) {
+		return
+	}
+
+	stepUp, valid := server.stepUp(ctx, fromAccount, req.Amount, req.TOTPCode)
+	if !valid {
 		return
 	}
 
 	arg := db.CreateTransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID: req.ToAccountID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID: toAccount.ID,
 		Amount: req.Amount,
+		StepUp: stepUp,
 	}
 
 	result, err := server.store.CreateTransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -49,17 +81,13 @@ type getTransferRequest struct {
 func (server *Server) getTransfer(ctx *gin.Context) {
 	var req getTransferRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 
 	transfer, err := server.store.GetTransfer(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -76,10 +104,9 @@ type listTransfersRequest struct {
 func (server *Server) listTransfers(ctx *gin.Context) {
 	var req listTransfersRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
-	fmt.Println(req.FromAccountID, req.ToAccountID)
 	transfers, err := server.store.ListTranfers(ctx, db.ListTranfersParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID: req.ToAccountID,
@@ -88,30 +115,71 @@ func (server *Server) listTransfers(ctx *gin.Context) {
 	})
 	
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, transfers)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) bool {
-	account, err := server.store.GetAccount(ctx, accountID)
+// validAccount looks up an account by ID or, if no ID is given, by account number
+// and checks it can take part in a transfer in the given currency
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, accountNumber string, currency string) (db.Account, bool) {
+	var account db.Account
+	var err error
+	if accountID != 0 {
+		account, err = server.store.GetAccount(ctx, accountID)
+	} else {
+		account, err = server.store.GetAccountByNumber(ctx, util.NormalizeIBAN(accountNumber))
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
-		}
+		errorResponse(ctx, err)
+		return account, false
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+	if err := db.CheckAccountActive(account); err != nil {
+		errorResponse(ctx, fmt.Errorf("account [%d]: %w", account.ID, err))
+		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		err := fmt.Errorf("account [%d]: %w: %s vs %s", account.ID, ErrCurrencyMismatch, account.Currency, currency)
+		errorResponse(ctx, err)
+		return account, false
+	}
+
+	return account, true
+}
+
+// verifiedOwner checks the owner of the source account verified their email, when the server requires it
+func (server *Server) verifiedOwner(ctx *gin.Context, account db.Account) bool {
+	if !server.config.RequireVerifiedEmail {
+		return true
 	}
 
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		errorResponse(ctx, err)
+		return false
+	}
+	if !owner.IsEmailVerified {
+		errorResponse(ctx, errEmailNotVerified)
+		return false
+	}
 	return true
-}
\ No newline at end of file
+}
+
+// stepUp returns the second factor of the owner of the source account which transfers of at least
+// StepUpTransferAmount are made with, nil for the others. CreateTransferTx checks the code along with the transfer.
+func (server *Server) stepUp(ctx *gin.Context, account db.Account, amount int64, code string) (*db.VerifyTOTPTxParams, bool) {
+	threshold := server.config.StepUpTransferAmount
+	if threshold <= 0 || amount < threshold {
+		return nil, true
+	}
+
+	if code == "" {
+		errorResponse(ctx, fmt.Errorf("%w: transfers of at least %d", errStepUpRequired, threshold))
+		return nil, false
+	}
+	return &db.VerifyTOTPTxParams{Username: account.Owner, Code: code}, true
+}
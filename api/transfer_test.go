@@ -67,6 +67,97 @@ func TestCreateTransferAPI(t *testing.T) {
 				requireBodyMatchTransfer(t, recorder.Body, transfer)
 			},
 		},
+		{
+			name: "OKWithAccountNumbers",
+			body: gin.H{
+				"from_account_number": account_from.AccountNumber,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), account_from.AccountNumber).
+					Times(1).
+					Return(account_from, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_to.ID).
+					Times(1).
+					Return(account_to, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{
+						FromAccountID: transfer.FromAccountID,
+						ToAccountID:   transfer.ToAccountID,
+						Amount:        transfer.Amount,
+					})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusOK)
+				requireBodyMatchTransfer(t, recorder.Body, transfer)
+			},
+		},
+		{
+			name: "AccountNumberNotFound",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_number": account_to.AccountNumber,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_from.ID).
+					Times(1).
+					Return(account_from, nil)
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), account_to.AccountNumber).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusNotFound)
+			},
+		},
+		{
+			name: "BadRequestWithAccountIDAndNumber",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"from_account_number": account_from.AccountNumber,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name: "BadRequestWithInvalidAccountNumber",
+			body: gin.H{
+				"from_account_number": "ES0000000000000000000000",
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusBadRequest)
+			},
+		},
 		{
 			name: "BadRequest",
 			body: gin.H{
@@ -85,6 +176,104 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, recorder.Code, http.StatusBadRequest)
 			},
 		},
+		{
+			name: "FrozenAccount",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := account_from
+				frozen.Status = db.AccountStatusFrozen
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_from.ID).
+					Times(1).
+					Return(frozen, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusForbidden)
+				requireBodyMatchErrorCode(t, recorder.Body, "account_frozen")
+			},
+		},
+		{
+			name: "ClosedAccountOnTx",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_from.ID).
+					Times(1).
+					Return(account_from, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_to.ID).
+					Times(1).
+					Return(account_to, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateTransferTxResult{}, db.ErrAccountClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusForbidden)
+				requireBodyMatchErrorCode(t, recorder.Body, "account_closed")
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": util.USD,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_from.ID).
+					Times(1).
+					Return(account_from, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, "currency_mismatch")
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_from.ID).
+					Times(1).
+					Return(account_from, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), account_to.ID).
+					Times(1).
+					Return(account_to, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, "insufficient_funds")
+			},
+		},
 		{
 			name: "InternalErrorOnValidation",
 			body: gin.H{
@@ -145,7 +334,7 @@ func TestCreateTransferAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			body, err := json.Marshal(tc.body)
@@ -160,6 +349,194 @@ func TestCreateTransferAPI(t *testing.T) {
 	}
 }
 
+func TestCreateTransferAPIRequiresVerifiedEmail(t *testing.T) {
+	currency := util.EUR
+	account_from := randomAccountWithCurrency(currency)
+	account_to := randomAccountWithCurrency(currency)
+	transfer, _, _ := randomTransferForAccounts(account_from.ID, account_to.ID)
+	owner := db.User{Username: account_from.Owner}
+
+	testCases := []struct {
+		name string
+		isEmailVerified bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Verified",
+			isEmailVerified: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTransfer(t, recorder.Body, transfer)
+			},
+		},
+		{
+			name: "NotVerified",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "email_not_verified")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			owner.IsEmailVerified = tc.isEmailVerified
+			transfers := 0
+			if tc.isEmailVerified {
+				transfers = 1
+			}
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), account_from.ID).Times(1).Return(account_from, nil)
+			store.EXPECT().GetUser(gomock.Any(), account_from.Owner).Times(1).Return(owner, nil)
+			store.EXPECT().GetAccount(gomock.Any(), account_to.ID).Times(transfers).Return(account_to, nil)
+			store.EXPECT().
+				CreateTransferTx(gomock.Any(), gomock.Any()).
+				Times(transfers).
+				Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+
+			server := newTestServer(t, store)
+			server.config.RequireVerifiedEmail = true
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": transfer.Amount,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateTransferAPIStepUp(t *testing.T) {
+	currency := util.EUR
+	account_from := randomAccountWithCurrency(currency)
+	account_to := randomAccountWithCurrency(currency)
+	account_to.ID = account_from.ID + 1
+	transfer, _, _ := randomTransferForAccounts(account_from.ID, account_to.ID)
+	stepUp := db.CreateTransferTxParams{
+		FromAccountID: account_from.ID,
+		ToAccountID: account_to.ID,
+		Amount: 1000,
+		StepUp: &db.VerifyTOTPTxParams{Username: account_from.Owner, Code: "123456"},
+	}
+
+	testCases := []struct {
+		name string
+		amount int64
+		totpCode string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTransfer(t, recorder.Body, transfer)
+			},
+		},
+		{
+			name: "BelowThreshold",
+			amount: 999,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: account_from.ID, ToAccountID: account_to.ID, Amount: 999})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MissingCode",
+			amount: 1000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "step_up_required")
+			},
+		},
+		{
+			name: "InvalidCode",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{}, db.ErrInvalidTOTPCode)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "invalid_totp_code")
+			},
+		},
+		{
+			name: "TOTPNotEnrolled",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{}, db.ErrTOTPNotEnrolled)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "totp_not_enrolled")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), account_from.ID).Times(1).Return(account_from, nil)
+			store.EXPECT().GetAccount(gomock.Any(), account_to.ID).Times(1).Return(account_to, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.StepUpTransferAmount = 1000
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(gin.H{
+				"from_account_id": account_from.ID,
+				"to_account_id": account_to.ID,
+				"currency": currency,
+				"amount": tc.amount,
+				"totp_code": tc.totpCode,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func TestListTransfers(t *testing.T) {
 	transfers := []db.Transfer{}
 	for i := 0; i < 10; i++ {
@@ -289,7 +666,7 @@ func TestListTransfers(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := tc.url
@@ -374,7 +751,7 @@ func TestGetTransferAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/transfers/%d", tc.transferID)
@@ -420,6 +797,11 @@ func randomAccountWithCurrency(currency string) db.Account {
 		Owner:    util.RandomOwner(),
 		Balance:  util.RandomMoney(),
 		Currency: currency,
+		Status:   db.AccountStatusActive,
+		AccountType: db.AccountTypeChecking,
+		AccruedInterest: "0",
+		AccountNumber: util.RandomAccountNumber(),
+		Version: util.RandomInt(1, 100),
 	}
 }
 
@@ -1,14 +1,17 @@
 package api
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/mailer"
 	"github.com/gorkaio/simplebank/util"
-	"github.com/lib/pq"
 )
 
 type createUserRequest struct {
@@ -22,19 +25,20 @@ type createUserResponse struct {
 	Username string `json:"username"`
 	FullName string `json:"full_name"`
 	Email string `json:"email"`
+	IsEmailVerified bool `json:"is_email_verified"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		bindingErrorResponse(ctx, err)
 		return
 	}
 
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -45,17 +49,12 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Email: req.Email,
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-				case "unique_validation":
-					ctx.JSON(http.StatusForbidden, errorResponse(err))
-					return
-			}
-			log.Println(pqErr.Code.Name())
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		errorResponse(ctx, storeError(err, constraintErrors{
+			"users_pkey": ErrDuplicateUser,
+			"users_email_key": ErrDuplicateUser,
+		}))
 		return
 	}
 
@@ -63,7 +62,183 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Username: user.Username,
 		FullName: user.FullName,
 		Email: user.Email,
+		IsEmailVerified: user.IsEmailVerified,
 		CreatedAt: user.CreatedAt,
 	}
 	ctx.JSON(http.StatusOK, resp)
-}
\ No newline at end of file
+}
+
+// The verification link sent to new users carries the id and secret code of their verification email
+type verifyEmailRequest struct {
+	EmailID int64 `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required"`
+}
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID: req.EmailID,
+		SecretCode: req.SecretCode,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verifyEmailResponse{IsVerified: result.User.IsEmailVerified})
+}
+
+type changePasswordURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// changePassword replaces the password of the user, given the current one.
+// The access tokens issued before are revoked.
+func (server *Server) changePassword(ctx *gin.Context) {
+	var uri changePasswordURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, uri.Username)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	if err := util.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+		errorResponse(ctx, errWrongPassword)
+		return
+	}
+	// checked after the password so disabled users cannot be told apart without it
+	if user.IsDisabled {
+		errorResponse(ctx, errUserDisabled)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	_, err = server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		HashedPassword: hashedPassword,
+		Username: user.Username,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// passwordResetTimeout bounds the time spent creating and emailing a password reset once it has been requested
+const passwordResetTimeout = time.Minute
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword emails a password reset link to the user with the given email. The user is looked up,
+// and the reset created and emailed, once the request has been answered, so neither the status nor the time
+// it takes tell whether the email belongs to a user. Failures are only logged.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	// detached from the request, which ends with the response, keeping its logger and trace
+	resetCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Request.Context()), passwordResetTimeout)
+	server.background.Add(1)
+	go func() {
+		defer server.background.Done()
+		defer cancel()
+		server.sendPasswordReset(resetCtx, req.Email)
+	}()
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// sendPasswordReset creates a password reset for the user with the given email and emails its link,
+// unless the email belongs to no user or to a disabled one
+func (server *Server) sendPasswordReset(ctx context.Context, email string) {
+	log := logger.FromContext(ctx)
+	user, err := server.store.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "password reset not created", "error", err)
+		return
+	}
+	if user.IsDisabled {
+		return
+	}
+
+	reset, err := server.store.CreatePasswordResetTx(ctx, db.CreatePasswordResetTxParams{Username: user.Username})
+	if err != nil {
+		log.ErrorContext(ctx, "password reset not created", "username", user.Username, "error", err)
+		return
+	}
+
+	if server.mailer == nil {
+		log.WarnContext(ctx, "password reset email not sent, no mailer configured", "username", user.Username)
+	} else if err := server.mailer.Send(ctx, mailer.PasswordResetMessage(user, server.config.PasswordResetURL, reset)); err != nil {
+		log.WarnContext(ctx, "password reset email not sent", "username", user.Username, "error", err)
+	}
+}
+
+type resetPasswordRequest struct {
+	Token string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// resetPassword replaces the password of the user the reset token was sent to.
+// The access tokens issued before are revoked.
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	_, err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		Token: req.Token,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,11 +11,13 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/gorkaio/simplebank/db/mock"
 	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/mailer"
 	"github.com/gorkaio/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
@@ -72,7 +75,7 @@ func TestCreateUserAPI(t *testing.T) {
 					Email: user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(args, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(args, password)).
 					Times(1).
 					Return(user, nil)
 			},
@@ -91,7 +94,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
 			},
@@ -108,7 +111,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -124,7 +127,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -140,7 +143,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -156,7 +159,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -173,7 +176,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func (store *mockdb.MockStore)  {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(gomock.Any())).
+					CreateAccountTx(gomock.Any(), gomock.Eq(gomock.Any())).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -192,7 +195,7 @@ func TestCreateUserAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 		
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			body, err := json.Marshal(tc.body)
@@ -207,6 +210,401 @@ func TestCreateUserAPI(t *testing.T) {
 	}
 }
 
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	verifyEmail := db.VerifyEmail{
+		ID: util.RandomInt(1, 1000),
+		Username: user.Username,
+		Email: user.Email,
+		SecretCode: util.RandomString(32),
+	}
+
+	testCases := []struct {
+		name string
+		query string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: fmt.Sprintf("email_id=%d&secret_code=%s", verifyEmail.ID, verifyEmail.SecretCode),
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(db.VerifyEmailTxParams{
+						EmailID: verifyEmail.ID,
+						SecretCode: verifyEmail.SecretCode,
+					})).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: verified, VerifyEmail: verifyEmail}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"is_verified": true}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "InvalidCode",
+			query: fmt.Sprintf("email_id=%d&secret_code=wrong", verifyEmail.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrInvalidVerifyEmail)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "invalid_verify_email")
+			},
+		},
+		{
+			name: "BadRequestWithoutSecretCode",
+			query: fmt.Sprintf("email_id=%d", verifyEmail.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestInvalidEmailID",
+			query: fmt.Sprintf("email_id=0&secret_code=%s", verifyEmail.SecretCode),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/verify_email?"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestChangePasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+	disabled := user
+	disabled.IsDisabled = true
+
+	testCases := []struct {
+		name string
+		username string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: user.Username,
+			body: gin.H{"current_password": password, "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserPasswordParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.CheckPassword("new secret", arg.HashedPassword))
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "WrongPassword",
+			username: user.Username,
+			body: gin.H{"current_password": "wrong", "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "wrong_password")
+			},
+		},
+		{
+			name: "UserDisabled",
+			username: user.Username,
+			body: gin.H{"current_password": password, "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(disabled, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "user_disabled")
+			},
+		},
+		{
+			name: "UserNotFound",
+			username: user.Username,
+			body: gin.H{"current_password": password, "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequestNewPasswordTooShort",
+			username: user.Username,
+			body: gin.H{"current_password": password, "new_password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s/password", tc.username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	disabled := user
+	disabled.IsDisabled = true
+	reset := db.CreatePasswordResetTxResult{
+		PasswordReset: db.PasswordReset{ID: 1, Username: user.Username, ExpiredAt: time.Now().Add(time.Hour)},
+		Token: util.RandomString(32),
+	}
+
+	testCases := []struct {
+		name string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		emails int
+		status int
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Eq(db.CreatePasswordResetTxParams{Username: user.Username})).
+					Times(1).
+					Return(reset, nil)
+			},
+			emails: 1,
+			status: http.StatusAccepted,
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePasswordResetTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "UserDisabled",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(disabled, nil)
+				store.EXPECT().CreatePasswordResetTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreatePasswordResetTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "ResetError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreatePasswordResetTxResult{}, sql.ErrConnDone)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "BadRequestInvalidEmail",
+			body: gin.H{"email": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/forgot_password", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+
+			// the reset is sent once the request is answered
+			require.NoError(t, server.Shutdown(context.Background()))
+
+			messages := server.mailer.(*mailer.MemoryMailer).Messages()
+			require.Len(t, messages, tc.emails)
+			for _, msg := range messages {
+				require.Equal(t, user.Email, msg.To)
+				require.Contains(t, msg.Body, reset.Token)
+			}
+		})
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	token := util.RandomString(32)
+
+	testCases := []struct {
+		name string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": token, "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, token, arg.Token)
+						require.NoError(t, util.CheckPassword("new secret", arg.HashedPassword))
+						return db.ResetPasswordTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": token, "new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrInvalidPasswordReset)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "invalid_password_reset")
+			},
+		},
+		{
+			name: "BadRequestWithoutToken",
+			body: gin.H{"new_password": "new secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/reset_password", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
 	password = util.RandomString(6)
 	hashedPassword, err := util.HashPassword(password)
@@ -10,4 +10,11 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 		return util.IsCurrencySupported(currency)
 	}
 	return false
-}
\ No newline at end of file
+}
+
+var validIBAN validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if iban, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.ValidateIBAN(util.NormalizeIBAN(iban)) == nil
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/webhook"
+)
+
+type webhookAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type webhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+	WebhookID int64 `uri:"webhook_id" binding:"required,min=1"`
+}
+
+type webhookDeliveryRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+	WebhookID int64 `uri:"webhook_id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// webhookResponse leaves the secret out, it is only shown once when the webhook is created
+type webhookResponse struct {
+	ID int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	Url string `json:"url"`
+	EventTypes []string `json:"event_types"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(webhook db.Webhook) webhookResponse {
+	return webhookResponse{
+		ID: webhook.ID,
+		AccountID: webhook.AccountID,
+		Url: webhook.Url,
+		EventTypes: webhook.EventTypes,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+type createWebhookRequest struct {
+	Url string `json:"url" binding:"required,url,startswith=http"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,unique,dive,oneof=transfer.received transfer.sent"`
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	// Secret signs the deliveries of the webhook
+	Secret string `json:"secret"`
+}
+
+// generateWebhookSecret returns a random secret for signing the deliveries of a webhook
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cannot generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createWebhook registers a webhook of an account of the authenticated user. Its URL must not reach
+// the network the server runs in, the deliverer refuses to post to such addresses anyway.
+func (server *Server) createWebhook(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var uri webhookAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.ownedAccount(ctx, payload, uri.ID); !ok {
+		return
+	}
+	if err := webhook.CheckURL(ctx, req.Url); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			// the address the host resolves to stays in the logs, the response must not reveal internal addresses
+			logger.FromContext(ctx.Request.Context()).Warn("webhook address refused", "url", req.Url, "error", err)
+			err = webhook.ErrForbiddenAddress
+		}
+		errorResponse(ctx, err)
+		return
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	webhook, err := server.store.CreateWebhook(ctx, db.CreateWebhookParams{
+		AccountID: uri.ID,
+		Url: req.Url,
+		Secret: secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{newWebhookResponse(webhook), webhook.Secret})
+}
+
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var uri webhookAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.ownedAccount(ctx, payload, uri.ID); !ok {
+		return
+	}
+
+	webhooks, err := server.store.ListWebhooks(ctx, uri.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	res := make([]webhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		res[i] = newWebhookResponse(webhook)
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (server *Server) getWebhook(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	webhook, ok := server.accountWebhook(ctx, payload, req.ID, req.WebhookID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+// deleteWebhook deletes the webhook with its delivery log, pending deliveries are not delivered
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.accountWebhook(ctx, payload, req.ID, req.WebhookID); !ok {
+		return
+	}
+
+	err := server.store.DeleteWebhook(ctx, req.WebhookID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var uri webhookRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.accountWebhook(ctx, payload, uri.ID, uri.WebhookID); !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: uri.WebhookID,
+		Limit: req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// replayWebhookDelivery delivers a delivery again, whether it succeeded or failed, with every attempt available
+func (server *Server) replayWebhookDelivery(ctx *gin.Context) {
+	payload, ok := server.authorizeUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req webhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		bindingErrorResponse(ctx, err)
+		return
+	}
+
+	if _, ok := server.accountWebhook(ctx, payload, req.ID, req.WebhookID); !ok {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	if delivery.WebhookID != req.WebhookID {
+		errorResponse(ctx, ErrNotFound)
+		return
+	}
+
+	delivery, err = server.store.ReplayWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// ownedAccount fetches an account of the authenticated user, answering 403 if it belongs to another user
+func (server *Server) ownedAccount(ctx *gin.Context, payload *token.Payload, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return account, false
+	}
+
+	if account.Owner != payload.Username {
+		errorResponse(ctx, errAccountNotOwned)
+		return account, false
+	}
+
+	return account, true
+}
+
+// accountWebhook fetches a webhook of the given account, which must belong to the authenticated user.
+// Webhooks of other accounts are reported as not found.
+func (server *Server) accountWebhook(ctx *gin.Context, payload *token.Payload, accountID int64, id int64) (db.Webhook, bool) {
+	if _, ok := server.ownedAccount(ctx, payload, accountID); !ok {
+		return db.Webhook{}, false
+	}
+
+	webhook, err := server.store.GetWebhook(ctx, id)
+	if err != nil {
+		errorResponse(ctx, err)
+		return webhook, false
+	}
+
+	if webhook.AccountID != accountID {
+		errorResponse(ctx, ErrNotFound)
+		return webhook, false
+	}
+
+	return webhook, true
+}
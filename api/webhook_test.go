@@ -0,0 +1,507 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+
+	testCases := []struct {
+		name string
+		username string
+		body gin.H
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: account.Owner,
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, webhook.Url, arg.Url)
+						require.Equal(t, webhook.EventTypes, arg.EventTypes)
+						require.Len(t, arg.Secret, 64)
+						webhook.Secret = arg.Secret
+						return webhook, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got createWebhookResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, webhook.ID, got.ID)
+				require.Equal(t, webhook.EventTypes, got.EventTypes)
+				// the secret is shown once, when created
+				require.Equal(t, webhook.Secret, got.Secret)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			username: account.Owner,
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUser",
+			username: util.RandomOwner(),
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "account_not_owned")
+			},
+		},
+		{
+			name: "UnknownEventType",
+			username: account.Owner,
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": []string{"account.created"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoEventTypes",
+			username: account.Owner,
+			body: gin.H{
+				"url": webhook.Url,
+				"event_types": []string{},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			username: account.Owner,
+			body: gin.H{
+				"url": "ftp://example.com/hook",
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ForbiddenAddress",
+			username: account.Owner,
+			body: gin.H{
+				"url": "http://169.254.169.254/latest/meta-data",
+				"event_types": webhook.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchErrorCode(t, recorder.Body, "forbidden_webhook_address")
+				require.NotContains(t, recorder.Body.String(), "169.254.169.254")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/accounts/%d/webhooks", account.ID)
+			recorder := serveWebhookRequest(t, tc.buildStubs, tc.username, http.MethodPost, url, tc.body)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListWebhooksAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+
+	recorder := serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().ListWebhooks(gomock.Any(), account.ID).Times(1).Return([]db.Webhook{webhook}, nil)
+	}, account.Owner, http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks", account.ID), nil)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), webhook.Secret)
+
+	var got []webhookResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	require.Equal(t, webhook.ID, got[0].ID)
+	require.Equal(t, webhook.Url, got[0].Url)
+
+	// the webhooks of the accounts of other users are not listed
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().ListWebhooks(gomock.Any(), gomock.Any()).Times(0)
+	}, util.RandomOwner(), http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks", account.ID), nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().ListWebhooks(gomock.Any(), gomock.Any()).Times(0)
+	}, "", http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks", account.ID), nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestGetWebhookAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+	otherAccount := account
+	otherAccount.ID = account.ID + 1
+
+	testCases := []struct {
+		name string
+		username string
+		accountID int64
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: account.Owner,
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), webhook.Secret)
+			},
+		},
+		{
+			name: "OtherAccount",
+			username: account.Owner,
+			accountID: otherAccount.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), otherAccount.ID).Times(1).Return(otherAccount, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUser",
+			username: util.RandomOwner(),
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			username: account.Owner,
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(db.Webhook{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/accounts/%d/webhooks/%d", tc.accountID, webhook.ID)
+			recorder := serveWebhookRequest(t, tc.buildStubs, tc.username, http.MethodGet, url, nil)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteWebhookAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+	otherAccount := account
+	otherAccount.ID = account.ID + 1
+
+	recorder := serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+		store.EXPECT().DeleteWebhook(gomock.Any(), webhook.ID).Times(1).Return(nil)
+	}, account.Owner, http.MethodDelete, fmt.Sprintf("/accounts/%d/webhooks/%d", account.ID, webhook.ID), nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), otherAccount.ID).Times(1).Return(otherAccount, nil)
+		store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+		store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Any()).Times(0)
+	}, account.Owner, http.MethodDelete, fmt.Sprintf("/accounts/%d/webhooks/%d", otherAccount.ID, webhook.ID), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Any()).Times(0)
+	}, util.RandomOwner(), http.MethodDelete, fmt.Sprintf("/accounts/%d/webhooks/%d", account.ID, webhook.ID), nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+	delivery := randomWebhookDelivery(webhook.ID)
+
+	recorder := serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+		store.EXPECT().
+			ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+				WebhookID: webhook.ID,
+				Limit: 5,
+				Offset: 5,
+			})).
+			Times(1).
+			Return([]db.WebhookDelivery{delivery}, nil)
+	}, account.Owner, http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks/%d/deliveries?page_id=2&page_size=5", account.ID, webhook.ID), nil)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	data, err := ioutil.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	var got []db.WebhookDelivery
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Len(t, got, 1)
+	require.Equal(t, delivery.ID, got[0].ID)
+	require.JSONEq(t, string(delivery.Payload), string(got[0].Payload))
+
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+	}, account.Owner, http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks/%d/deliveries?page_id=1&page_size=50", account.ID, webhook.ID), nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWebhookRequest(t, func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+		store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+	}, util.RandomOwner(), http.MethodGet, fmt.Sprintf("/accounts/%d/webhooks/%d/deliveries?page_id=1&page_size=5", account.ID, webhook.ID), nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestReplayWebhookDeliveryAPI(t *testing.T) {
+	account := randomAccount()
+	webhook := randomWebhook(account.ID)
+	delivery := randomWebhookDelivery(webhook.ID)
+	delivery.Status = db.WebhookDeliveryFailed
+
+	testCases := []struct {
+		name string
+		username string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				replayed := delivery
+				replayed.Status = db.WebhookDeliveryPending
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), delivery.ID).Times(1).Return(delivery, nil)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), delivery.ID).Times(1).Return(replayed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.WebhookDelivery
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.WebhookDeliveryPending, got.Status)
+			},
+		},
+		{
+			name: "OtherUser",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "DeliveryOfOtherWebhook",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := delivery
+				other.WebhookID = webhook.ID + 1
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), delivery.ID).Times(1).Return(other, nil)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "DeliveryNotFound",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetWebhook(gomock.Any(), webhook.ID).Times(1).Return(webhook, nil)
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), delivery.ID).Times(1).Return(db.WebhookDelivery{}, sql.ErrNoRows)
+				store.EXPECT().ReplayWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/accounts/%d/webhooks/%d/deliveries/%d/replay", account.ID, webhook.ID, delivery.ID)
+			recorder := serveWebhookRequest(t, tc.buildStubs, tc.username, http.MethodPost, url, nil)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// serveWebhookRequest serves a request with an access token of username, or with none if it is empty
+func serveWebhookRequest(t *testing.T, buildStubs func(store *mockdb.MockStore), username string, method string, url string, body gin.H) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), username).AnyTimes().Return(db.User{Username: username}, nil)
+	buildStubs(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	if username != "" {
+		accessToken, err := server.tokenMaker.CreateToken(username, token.TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+		request.Header.Set(authorizationHeader, "Bearer " + accessToken)
+	}
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func randomWebhook(accountID int64) db.Webhook {
+	return db.Webhook{
+		ID: util.RandomInt(1, 1000),
+		AccountID: accountID,
+		Url: "https://example.com/" + util.RandomString(8),
+		Secret: util.RandomString(64),
+		EventTypes: []string{db.WebhookEventTransferReceived},
+	}
+}
+
+func randomWebhookDelivery(webhookID int64) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID: util.RandomInt(1, 1000),
+		WebhookID: webhookID,
+		EventID: util.RandomInt(1, 1000),
+		EventType: db.WebhookEventTransferReceived,
+		Payload: json.RawMessage(`{"amount":10}`),
+		Status: db.WebhookDeliveryPending,
+	}
+}
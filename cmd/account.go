@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/spf13/cobra"
+)
+
+func printAccount(w io.Writer, account db.Account) {
+	printFields(w,
+		"id", account.ID,
+		"number", account.AccountNumber,
+		"owner", account.Owner,
+		"type", account.AccountType,
+		"status", account.Status,
+		"balance", fmt.Sprintf("%d %s", account.Balance, account.Currency),
+		"created at", account.CreatedAt.Format(time.RFC3339),
+	)
+}
+
+func parseID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid id: %s", arg)
+	}
+	return id, nil
+}
+
+func newAccountCommand(app *app) *cobra.Command {
+	account := &cobra.Command{
+		Use: "account",
+		Short: "Manage accounts",
+	}
+	account.AddCommand(
+		newAccountCreateCommand(app),
+		newAccountStatusCommand(app, "freeze", db.AccountStatusFrozen, "Freeze an account so it cannot take part in transfers"),
+		newAccountStatusCommand(app, "close", db.AccountStatusClosed, "Close an account, sweeping its balance to another one"),
+	)
+	return account
+}
+
+func newAccountCreateCommand(app *app) *cobra.Command {
+	var owner, currency, accountType string
+
+	create := &cobra.Command{
+		Use: "create",
+		Short: "Create an account with a new account number and no balance",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !util.IsCurrencySupported(currency) {
+				return fmt.Errorf("unsupported currency: %s", currency)
+			}
+			if accountType != db.AccountTypeChecking && accountType != db.AccountTypeSavings {
+				return fmt.Errorf("invalid account type: %s", accountType)
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			account, err := store.CreateAccountTx(cmd.Context(), db.CreateAccountTxParams{
+				Owner: owner,
+				Currency: currency,
+				AccountType: accountType,
+				Balance: 0,
+				IBANCountryCode: app.config.IBANCountryCode,
+				IBANBankCode: app.config.IBANBankCode,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot create account: %w", err)
+			}
+
+			return app.print(cmd, account, func(w io.Writer) { printAccount(w, account) })
+		},
+	}
+
+	create.Flags().StringVar(&owner, "owner", "", "username of the owner (required)")
+	create.Flags().StringVar(&currency, "currency", "", "currency, one of USD, EUR or CAD (required)")
+	create.Flags().StringVar(&accountType, "type", db.AccountTypeChecking, "checking or savings")
+	create.MarkFlagRequired("owner")
+	create.MarkFlagRequired("currency")
+	return create
+}
+
+// newAccountStatusCommand creates a command moving an account to status
+func newAccountStatusCommand(app *app, use string, status string, short string) *cobra.Command {
+	var sweepTo int64
+
+	command := &cobra.Command{
+		Use: use + " <id>",
+		Short: short,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			result, err := store.UpdateAccountStatusTx(cmd.Context(), db.UpdateAccountStatusTxParams{
+				AccountID: id,
+				Status: status,
+				SweepAccountID: sweepTo,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot %s account: %w", use, err)
+			}
+
+			return app.print(cmd, result, func(w io.Writer) {
+				printAccount(w, result.Account)
+				if result.SweepTransfer != nil {
+					printFields(w, "sweep transfer", result.SweepTransfer.ID)
+				}
+			})
+		},
+	}
+
+	if status == db.AccountStatusClosed {
+		command.Flags().Int64Var(&sweepTo, "sweep-to", 0, "id of the account receiving the remaining balance")
+	}
+	return command
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/interest"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+func newAccrueInterestCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "accrue-interest [YYYY-MM-DD]",
+		Short: "Accrue the interest of the given date, yesterday by default",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			date := time.Now().UTC().AddDate(0, 0, -1)
+			if len(args) == 1 {
+				var err error
+				date, err = time.Parse(dateLayout, args[0])
+				if err != nil {
+					return fmt.Errorf("invalid date: %w", err)
+				}
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return interest.NewAccruer(store, app.config.InterestExpenseOwner).AccrueDay(cmd.Context(), date)
+		},
+	}
+}
+
+func newPostInterestCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "post-interest [YYYY-MM]",
+		Short: "Post the whole cents of the interest accrued by every account for the given month, last month by default",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			period := db.InterestPeriod(time.Now().UTC()).AddDate(0, -1, 0)
+			if len(args) == 1 {
+				var err error
+				period, err = time.Parse(monthLayout, args[0])
+				if err != nil {
+					return fmt.Errorf("invalid month: %w", err)
+				}
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return interest.NewAccruer(store, app.config.InterestExpenseOwner).PostInterest(cmd.Context(), period)
+		},
+	}
+}
+
+func newSetInterestRateCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "set-interest-rate <checking|savings> <annual rate> <YYYY-MM-DD>",
+		Short: "Set the annual interest rate of an account type from the given date",
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountType := args[0]
+			if accountType != db.AccountTypeChecking && accountType != db.AccountTypeSavings {
+				return fmt.Errorf("invalid account type: %s", accountType)
+			}
+
+			rate, err := decimal.NewFromString(args[1])
+			if err != nil || rate.IsNegative() {
+				return fmt.Errorf("invalid annual rate: %s", args[1])
+			}
+
+			date, err := time.Parse(dateLayout, args[2])
+			if err != nil {
+				return fmt.Errorf("invalid date: %w", err)
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			interestRate, err := store.CreateInterestRate(cmd.Context(), db.CreateInterestRateParams{
+				AccountType: accountType,
+				AnnualRate: rate.String(),
+				EffectiveFrom: date,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot create interest rate: %w", err)
+			}
+
+			return app.print(cmd, interestRate, func(w io.Writer) {
+				printFields(w,
+					"id", interestRate.ID,
+					"account type", interestRate.AccountType,
+					"annual rate", interestRate.AnnualRate,
+					"effective from", interestRate.EffectiveFrom.Format(dateLayout),
+				)
+			})
+		},
+	}
+}
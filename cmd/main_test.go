@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// executeCommand runs the command line given by args against store, feeding it stdin,
+// and returns what it wrote to stdout
+func executeCommand(t *testing.T, store *mockdb.MockStore, stdin string, args ...string) (string, error) {
+	app := &app{store: store}
+	root := newRootCommand(app)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(io.Discard)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"--config", ".."}, args...))
+
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestInvalidOutput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := executeCommand(t, store, "", "transfer", "show", "1", "--output", "yaml")
+	require.ErrorContains(t, err, "invalid output")
+}
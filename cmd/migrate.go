@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gorkaio/simplebank/db/migration"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand(app *app) *cobra.Command {
+	migrate := &cobra.Command{
+		Use: "migrate",
+		Short: "Migrate the database schema with the embedded migrations",
+	}
+
+	migrate.AddCommand(
+		&cobra.Command{
+			Use: "up",
+			Short: "Apply every pending migration",
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				conn, err := app.openDB(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return migration.Up(cmd.Context(), conn, app.config.DBDriver)
+			},
+		},
+		&cobra.Command{
+			Use: "down",
+			Short: "Revert the last migration",
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				conn, err := app.openDB(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return migration.Down(cmd.Context(), conn, app.config.DBDriver)
+			},
+		},
+		&cobra.Command{
+			Use: "goto <version>",
+			Short: "Apply or revert migrations until the schema is at version, 0 reverts them all",
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid version: %s", args[0])
+				}
+
+				conn, err := app.openDB(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return migration.Migrate(cmd.Context(), conn, app.config.DBDriver, version)
+			},
+		},
+	)
+	return migrate
+}
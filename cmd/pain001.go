@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gorkaio/simplebank/iso20022"
+	"github.com/spf13/cobra"
+)
+
+func newImportPain001Command(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "import-pain001 <file>",
+		Short: "Execute the pain.001 batch in file and write the pain.002 status report to stdout",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("cannot open pain.001 file: %w", err)
+			}
+			defer file.Close()
+
+			doc, err := iso20022.ParsePain001(file)
+			if err != nil {
+				return err
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			report, err := iso20022.NewProcessor(store, app.config.RequireVerifiedEmail, app.config.StepUpTransferAmount).Process(cmd.Context(), doc)
+			if err != nil {
+				return fmt.Errorf("cannot process pain.001 file: %w", err)
+			}
+
+			data, err := report.Marshal()
+			if err != nil {
+				return fmt.Errorf("cannot encode pain.002 report: %w", err)
+			}
+
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func newReconcileCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "reconcile",
+		Short: "Check every account balance matches the sum of its entries",
+		Long: "Check every account balance matches the sum of its entries.\n" +
+			"Mismatching accounts are listed and the command fails if there is any.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			accounts, err := store.ListUnbalancedAccounts(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("cannot reconcile accounts: %w", err)
+			}
+
+			err = app.print(cmd, accounts, func(w io.Writer) {
+				if len(accounts) == 0 {
+					fmt.Fprintln(w, "every account balance matches its entries")
+					return
+				}
+				fmt.Fprintf(w, "%-10s %-8s %16s %16s\n", "ACCOUNT", "CURRENCY", "BALANCE", "ENTRIES")
+				for _, account := range accounts {
+					fmt.Fprintf(w, "%-10d %-8s %16d %16d\n", account.ID, account.Currency, account.Balance, account.EntriesTotal)
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(accounts) > 0 {
+				return fmt.Errorf("%d accounts do not match their entries", len(accounts))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,161 @@
+// Package cmd implements the simplebank command line: the server itself and the tasks operations staff
+// would otherwise run by hand against the database.
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/util"
+	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// app is the state shared by every command: the configuration, and the database once a command opens it
+type app struct {
+	configPath string
+	output string
+	config util.Config
+	conn *sql.DB
+	store db.Store
+}
+
+// Execute runs the command line with the process arguments
+func Execute() error {
+	app := &app{}
+	defer app.close()
+
+	return newRootCommand(app).ExecuteContext(context.Background())
+}
+
+func newRootCommand(app *app) *cobra.Command {
+	root := &cobra.Command{
+		Use: "simplebank",
+		Short: "Simple bank server and administration tool",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if app.output != outputText && app.output != outputJSON {
+				return fmt.Errorf("invalid output %q, must be %s or %s", app.output, outputText, outputJSON)
+			}
+			return app.loadConfig(cmd.ErrOrStderr())
+		},
+	}
+
+	root.PersistentFlags().StringVar(&app.configPath, "config", ".", "directory holding app.yml")
+	root.PersistentFlags().StringVarP(&app.output, "output", "o", outputText, "output format, text or json")
+
+	root.AddCommand(
+		newServeCommand(app),
+		newMigrateCommand(app),
+		newUserCommand(app),
+		newAccountCommand(app),
+		newTransferCommand(app),
+		newReconcileCommand(app),
+		newSeedCommand(app),
+		newImportPain001Command(app),
+		newAccrueInterestCommand(app),
+		newPostInterestCommand(app),
+		newSetInterestRateCommand(app),
+	)
+	return root
+}
+
+// loadConfig reads the configuration and sets up the default logger. Commands log to w so their output
+// can be piped; serve logs to stdout instead.
+func (app *app) loadConfig(w io.Writer) error {
+	config, err := util.LoadConfig(app.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot load configuration: %w", err)
+	}
+	app.config = config
+
+	return app.setLogger(w)
+}
+
+func (app *app) setLogger(w io.Writer) error {
+	appLogger, err := logger.New(w, app.config.LogLevel, app.config.LogFormat)
+	if err != nil {
+		return fmt.Errorf("cannot create logger: %w", err)
+	}
+	slog.SetDefault(appLogger)
+	return nil
+}
+
+// openDB connects to the database, failing if it cannot be reached
+func (app *app) openDB(ctx context.Context) (*sql.DB, error) {
+	if app.conn != nil {
+		return app.conn, nil
+	}
+
+	conn, err := sql.Open(app.config.DBDriver, app.config.DBSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+	// sql.Open does not connect, fail fast if the database cannot be reached
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+
+	app.conn = conn
+	return conn, nil
+}
+
+// openStore returns the store of the commands working with the bank data
+func (app *app) openStore(ctx context.Context) (db.Store, error) {
+	if app.store != nil {
+		return app.store, nil
+	}
+
+	conn, err := app.openDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if app.config.DBDriver == db.SQLiteDriver {
+		app.store = db.NewSQLiteStore(conn)
+	} else {
+		app.store = db.NewStore(conn)
+	}
+	return app.store, nil
+}
+
+// close closes the database, if a command opened it
+func (app *app) close() {
+	if app.conn == nil {
+		return
+	}
+	if err := app.conn.Close(); err != nil {
+		slog.Error("cannot close db", "error", err)
+	}
+	app.conn = nil
+}
+
+// print writes v as JSON when asked to, or as text otherwise
+func (app *app) print(cmd *cobra.Command, v any, text func(w io.Writer)) error {
+	if app.output == outputJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	text(cmd.OutOrStdout())
+	return nil
+}
+
+// printFields writes name and value pairs as aligned text lines
+func printFields(w io.Writer, fields ...any) {
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(w, "%-16s %v\n", fmt.Sprint(fields[i])+":", fields[i+1])
+	}
+}
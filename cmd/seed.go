@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/spf13/cobra"
+)
+
+// seedUsers are the demo customers created by seed, along with the bank user paying interest
+var seedUsers = []db.CreateUserParams{
+	{Username: "alice", FullName: "Alice Example", Email: "alice@example.com"},
+	{Username: "bob", FullName: "Bob Example", Email: "bob@example.com"},
+}
+
+var seedCurrencies = []string{util.EUR, util.USD}
+
+type seedResult struct {
+	Users []userView `json:"users"`
+	Accounts []db.Account `json:"accounts"`
+}
+
+func newSeedCommand(app *app) *cobra.Command {
+	var password string
+	var balance int64
+
+	seed := &cobra.Command{
+		Use: "seed",
+		Short: "Create demo users and funded accounts for development",
+		Long: "Create demo users and funded accounts for development.\n" +
+			"Users and accounts that exist already are left untouched, so seeding twice is harmless.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(password) < minPasswordLength {
+				return fmt.Errorf("password must have at least %d characters", minPasswordLength)
+			}
+			if balance < 0 {
+				return fmt.Errorf("balance cannot be negative")
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			hashedPassword, err := util.HashPassword(password)
+			if err != nil {
+				return err
+			}
+
+			users := append([]db.CreateUserParams{{
+				Username: app.config.InterestExpenseOwner,
+				FullName: "Simple Bank",
+				Email: app.config.InterestExpenseOwner + "@example.com",
+			}}, seedUsers...)
+
+			var result seedResult
+			for _, arg := range users {
+				arg.HashedPassword = hashedPassword
+				user, created, err := seedUser(cmd.Context(), store, arg)
+				if err != nil {
+					return err
+				}
+				if created {
+					result.Users = append(result.Users, newUserView(user))
+				}
+
+				for _, currency := range seedCurrencies {
+					// the bank account pays interest, it starts empty
+					openingBalance := balance
+					if user.Username == app.config.InterestExpenseOwner {
+						openingBalance = 0
+					}
+
+					account, created, err := app.seedAccount(cmd.Context(), store, user.Username, currency, openingBalance)
+					if err != nil {
+						return err
+					}
+					if created {
+						result.Accounts = append(result.Accounts, account)
+					}
+				}
+			}
+
+			return app.print(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "created %d users and %d accounts\n", len(result.Users), len(result.Accounts))
+				for _, user := range result.Users {
+					fmt.Fprintf(w, "user %s\n", user.Username)
+				}
+				for _, account := range result.Accounts {
+					fmt.Fprintf(w, "account %d %s %s %d\n", account.ID, account.Owner, account.Currency, account.Balance)
+				}
+			})
+		},
+	}
+
+	seed.Flags().StringVar(&password, "password", "secret", "password of the created users")
+	seed.Flags().Int64Var(&balance, "balance", 100000, "opening balance of the customer accounts, in cents")
+	return seed
+}
+
+// seedUser creates the user unless it exists already
+func seedUser(ctx context.Context, store db.Store, arg db.CreateUserParams) (db.User, bool, error) {
+	user, err := store.GetUser(ctx, arg.Username)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.User{}, false, fmt.Errorf("cannot get user %s: %w", arg.Username, err)
+	}
+
+	user, err = store.CreateUserTx(ctx, arg)
+	if err != nil {
+		return db.User{}, false, fmt.Errorf("cannot create user %s: %w", arg.Username, err)
+	}
+	return user, true, nil
+}
+
+// seedAccount creates the owner account in currency unless it exists already.
+// Its opening balance is recorded as an entry so the account reconciles.
+func (app *app) seedAccount(ctx context.Context, store db.Store, owner string, currency string, balance int64) (db.Account, bool, error) {
+	account, err := store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner: owner,
+		Currency: currency,
+	})
+	if err == nil {
+		return account, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return db.Account{}, false, fmt.Errorf("cannot get %s account of %s: %w", currency, owner, err)
+	}
+
+	account, err = store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+		Owner: owner,
+		Balance: balance,
+		Currency: currency,
+		AccountType: db.AccountTypeChecking,
+		IBANCountryCode: app.config.IBANCountryCode,
+		IBANBankCode: app.config.IBANBankCode,
+	})
+	if err != nil {
+		return db.Account{}, false, fmt.Errorf("cannot create %s account of %s: %w", currency, owner, err)
+	}
+
+	if balance != 0 {
+		_, err = store.CreateEntry(ctx, db.CreateEntryParams{AccountID: account.ID, Amount: balance})
+		if err != nil {
+			return db.Account{}, false, fmt.Errorf("cannot record opening balance of account %d: %w", account.ID, err)
+		}
+	}
+	return account, true, nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(3).Return(db.User{}, sql.ErrNoRows)
+	store.EXPECT().
+		CreateUserTx(gomock.Any(), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+			return db.User{Username: arg.Username, FullName: arg.FullName, Email: arg.Email}, nil
+		})
+	// alice owns a EUR account already
+	store.EXPECT().
+		GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+		Times(6).
+		DoAndReturn(func(ctx context.Context, arg db.GetAccountByOwnerAndCurrencyParams) (db.Account, error) {
+			if arg.Owner == "alice" && arg.Currency == "EUR" {
+				return db.Account{ID: 1, Owner: arg.Owner, Currency: arg.Currency}, nil
+			}
+			return db.Account{}, sql.ErrNoRows
+		})
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Any()).
+		Times(5).
+		DoAndReturn(func(ctx context.Context, arg db.CreateAccountTxParams) (db.Account, error) {
+			require.NotEmpty(t, arg.IBANCountryCode)
+			return db.Account{ID: 2, Owner: arg.Owner, Currency: arg.Currency, Balance: arg.Balance}, nil
+		})
+	// only customer accounts are funded
+	store.EXPECT().
+		CreateEntry(gomock.Any(), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+			require.Equal(t, int64(500), arg.Amount)
+			return db.Entry{AccountID: arg.AccountID, Amount: arg.Amount}, nil
+		})
+
+	output, err := executeCommand(t, store, "", "seed", "--balance", "500", "-o", "json")
+	require.NoError(t, err)
+
+	var result seedResult
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	require.Len(t, result.Users, 3)
+	require.Len(t, result.Accounts, 5)
+}
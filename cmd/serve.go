@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorkaio/simplebank/activity"
+	"github.com/gorkaio/simplebank/api"
+	"github.com/gorkaio/simplebank/db/migration"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/gapi"
+	"github.com/gorkaio/simplebank/interest"
+	"github.com/gorkaio/simplebank/mailer"
+	"github.com/gorkaio/simplebank/metrics"
+	"github.com/gorkaio/simplebank/outbox"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/tracing"
+	"github.com/gorkaio/simplebank/webhook"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// webhookTimeout bounds the requests delivering the outbox events and the account webhooks
+const webhookTimeout = 10 * time.Second
+
+func newServeCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "serve",
+		Short: "Serve the HTTP and gRPC APIs and run the interest accruer, the outbox relay, the webhook deliverer and the verification email sender",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.serve(cmd.Context())
+		},
+	}
+}
+
+// serve runs the servers until SIGINT or SIGTERM is received, then gives in-flight requests
+// until the shutdown timeout to finish
+func (app *app) serve(ctx context.Context) error {
+	// the server logs to stdout, as expected by container runtimes
+	if err := app.setLogger(os.Stdout); err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, app.config)
+	if err != nil {
+		return fmt.Errorf("cannot set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	conn, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	if app.config.MigrateOnStart {
+		if err := migration.Up(ctx, conn, app.config.DBDriver); err != nil {
+			return fmt.Errorf("cannot migrate db: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// the account activity streamed by the HTTP server is notified by Postgres, so the activity written
+	// by every replica is streamed, or published in-process by the store on SQLite
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(conn)
+	broker := activity.NewBroker()
+	listenerDone := make(chan struct{})
+	var store db.Store
+	if app.config.DBDriver == db.SQLiteDriver {
+		store = activity.NewPublishingStore(db.NewObservedSQLiteStore(conn, appMetrics), broker)
+		close(listenerDone)
+	} else {
+		store = db.NewObservedStore(conn, appMetrics)
+		go func() {
+			if err := activity.Listen(ctx, app.config.DBSource, broker); err != nil {
+				slog.Error("cannot listen to account activity", "error", err)
+			}
+			close(listenerDone)
+		}()
+	}
+
+	accruer := interest.NewAccruer(store, app.config.InterestExpenseOwner)
+	accruerDone := make(chan struct{})
+	go func() {
+		accruer.Run(ctx)
+		close(accruerDone)
+	}()
+
+	relayDone := make(chan struct{})
+	if app.config.OutboxWebhookURL != "" {
+		relay := outbox.NewRelay(store, outbox.NewWebhookPublisher(app.config.OutboxWebhookURL, webhookTimeout), app.config.OutboxRelayInterval)
+		go func() {
+			relay.Run(ctx)
+			close(relayDone)
+		}()
+	} else {
+		close(relayDone)
+	}
+
+	deliverer := webhook.NewDeliverer(store, webhookTimeout, app.config.WebhookDeliveryInterval)
+	delivererDone := make(chan struct{})
+	go func() {
+		deliverer.Run(ctx)
+		close(delivererDone)
+	}()
+
+	appMailer, err := mailer.New(app.config)
+	if err != nil {
+		return err
+	}
+	senderDone := make(chan struct{})
+	if appMailer != nil {
+		sender := mailer.NewVerifyEmailSender(store, appMailer, app.config.VerifyEmailURL, app.config.VerifyEmailInterval)
+		go func() {
+			sender.Run(ctx)
+			close(senderDone)
+		}()
+	} else {
+		close(senderDone)
+	}
+
+	limiterStore, err := ratelimit.NewStore(app.config, store)
+	if err != nil {
+		return err
+	}
+	limiter, err := ratelimit.NewLimiter(limiterStore, app.config.RateLimits)
+	if err != nil {
+		return err
+	}
+
+	grpcServer, listener, err := app.newGRPCServer(store, appMetrics, limiter)
+	if err != nil {
+		return err
+	}
+	server, err := api.NewServer(app.config, store, appMetrics, limiter, broker, appMailer)
+	if err != nil {
+		return fmt.Errorf("cannot create HTTP server: %w", err)
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		slog.Info("start gRPC server", "address", listener.Addr().String())
+		errs <- grpcServer.Serve(listener)
+	}()
+	go func() {
+		slog.Info("start HTTP server", "address", app.config.ServerAddress)
+		errs <- server.Start(app.config.ServerAddress)
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down", "timeout", app.config.ShutdownTimeout)
+	case err := <-errs:
+		slog.Error("server stopped, shutting down", "error", err)
+	}
+	stop()
+
+	// in-flight requests, transfers among them, get until the drain timeout to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("cannot shut down HTTP server gracefully", "error", err)
+	}
+	stopGRPCServer(shutdownCtx, grpcServer)
+
+	select {
+	case <-accruerDone:
+	case <-shutdownCtx.Done():
+		slog.Error("interest accruer did not stop in time")
+	}
+
+	select {
+	case <-relayDone:
+	case <-shutdownCtx.Done():
+		slog.Error("outbox relay did not stop in time")
+	}
+
+	select {
+	case <-delivererDone:
+	case <-shutdownCtx.Done():
+		slog.Error("webhook deliverer did not stop in time")
+	}
+
+	select {
+	case <-senderDone:
+	case <-shutdownCtx.Done():
+		slog.Error("verification email sender did not stop in time")
+	}
+
+	select {
+	case <-listenerDone:
+	case <-shutdownCtx.Done():
+		slog.Error("account activity listener did not stop in time")
+	}
+
+	app.close()
+	slog.Info("shutdown complete")
+	return nil
+}
+
+func (app *app) newGRPCServer(store db.Store, appMetrics *metrics.Metrics, limiter *ratelimit.Limiter) (*grpc.Server, net.Listener, error) {
+	server, err := gapi.NewServer(app.config, store, appMetrics, limiter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create gRPC server: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", app.config.GRPCServerAddress)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create gRPC listener: %w", err)
+	}
+	return server.NewGRPCServer(), listener, nil
+}
+
+// stopGRPCServer waits for in-flight calls to finish, or closes every connection once ctx is done
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("cannot shut down gRPC server gracefully", "error", ctx.Err())
+		server.Stop()
+	}
+}
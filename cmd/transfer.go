@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/spf13/cobra"
+)
+
+func printTransfer(w io.Writer, transfer db.Transfer) {
+	printFields(w,
+		"id", transfer.ID,
+		"from account", transfer.FromAccountID,
+		"to account", transfer.ToAccountID,
+		"amount", transfer.Amount,
+		"created at", transfer.CreatedAt.Format(time.RFC3339),
+	)
+	if transfer.ReversalOf != 0 {
+		printFields(w, "reversal of", transfer.ReversalOf)
+	}
+}
+
+func newTransferCommand(app *app) *cobra.Command {
+	transfer := &cobra.Command{
+		Use: "transfer",
+		Short: "Inspect and reverse transfers",
+	}
+	transfer.AddCommand(newTransferShowCommand(app), newTransferReverseCommand(app))
+	return transfer
+}
+
+func newTransferShowCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "show <id>",
+		Short: "Show a transfer",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			transfer, err := store.GetTransfer(cmd.Context(), id)
+			if err != nil {
+				return fmt.Errorf("cannot get transfer: %w", err)
+			}
+
+			return app.print(cmd, transfer, func(w io.Writer) { printTransfer(w, transfer) })
+		},
+	}
+}
+
+func newTransferReverseCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "reverse <id>",
+		Short: "Move the amount of a transfer back to its source account",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			result, err := store.ReverseTransferTx(cmd.Context(), id)
+			if err != nil {
+				return fmt.Errorf("cannot reverse transfer: %w", err)
+			}
+
+			return app.print(cmd, result, func(w io.Writer) { printTransfer(w, result.Transfer) })
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomTransfer() db.Transfer {
+	return db.Transfer{
+		ID: util.RandomInt(1, 1000),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID: util.RandomInt(1, 1000),
+		Amount: util.RandomMoney(),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestTransferShowCommand(t *testing.T) {
+	transfer := randomTransfer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(2).Return(transfer, nil)
+
+	output, err := executeCommand(t, store, "", "transfer", "show", fmt.Sprint(transfer.ID))
+	require.NoError(t, err)
+	require.Contains(t, output, "amount:")
+	require.NotContains(t, output, "reversal of:")
+
+	output, err = executeCommand(t, store, "", "transfer", "show", fmt.Sprint(transfer.ID), "-o", "json")
+	require.NoError(t, err)
+
+	var got db.Transfer
+	require.NoError(t, json.Unmarshal([]byte(output), &got))
+	require.Equal(t, transfer.ID, got.ID)
+	require.Equal(t, transfer.Amount, got.Amount)
+}
+
+func TestTransferReverseCommand(t *testing.T) {
+	transfer := randomTransfer()
+
+	testCases := []struct {
+		name string
+		args []string
+		buildStubs func(store *mockdb.MockStore)
+		checkOutput func(t *testing.T, output string, err error)
+	}{
+		{
+			name: "OK",
+			args: []string{"transfer", "reverse", fmt.Sprint(transfer.ID)},
+			buildStubs: func(store *mockdb.MockStore) {
+				reversal := randomTransfer()
+				reversal.ReversalOf = transfer.ID
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), transfer.ID).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: reversal}, nil)
+			},
+			checkOutput: func(t *testing.T, output string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, output, "reversal of:")
+			},
+		},
+		{
+			name: "AlreadyReversed",
+			args: []string{"transfer", "reverse", fmt.Sprint(transfer.ID)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), transfer.ID).
+					Times(1).
+					Return(db.CreateTransferTxResult{}, db.ErrTransferAlreadyReversed)
+			},
+			checkOutput: func(t *testing.T, output string, err error) {
+				require.ErrorIs(t, err, db.ErrTransferAlreadyReversed)
+			},
+		},
+		{
+			name: "InvalidID",
+			args: []string{"transfer", "reverse", "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkOutput: func(t *testing.T, output string, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			output, err := executeCommand(t, store, "", tc.args...)
+			tc.checkOutput(t, output, err)
+		})
+	}
+}
+
+func TestReconcileCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ListUnbalancedAccounts(gomock.Any()).Times(1).Return(nil, nil),
+		store.EXPECT().ListUnbalancedAccounts(gomock.Any()).Times(1).Return([]db.ListUnbalancedAccountsRow{
+			{ID: 1, Currency: util.EUR, Balance: 100, EntriesTotal: 90},
+		}, nil),
+	)
+
+	output, err := executeCommand(t, store, "", "reconcile")
+	require.NoError(t, err)
+	require.Contains(t, output, "every account balance matches")
+
+	output, err = executeCommand(t, store, "", "reconcile")
+	require.ErrorContains(t, err, "1 accounts do not match")
+	require.Contains(t, output, "ACCOUNT")
+}
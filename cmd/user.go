@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/spf13/cobra"
+)
+
+const minPasswordLength = 6
+
+// userView is a user as shown by the commands, without its hashed password
+type userView struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Email string `json:"email"`
+	IsDisabled bool `json:"is_disabled"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Password is only set when it was generated by the command
+	Password string `json:"password,omitempty"`
+}
+
+func newUserView(user db.User) userView {
+	view := userView{
+		Username: user.Username,
+		FullName: user.FullName,
+		Email: user.Email,
+		IsDisabled: user.IsDisabled,
+		CreatedAt: user.CreatedAt,
+	}
+	if user.IsDisabled {
+		view.DisabledAt = &user.DisabledAt
+	}
+	return view
+}
+
+func (view userView) printText(w io.Writer) {
+	printFields(w,
+		"username", view.Username,
+		"full name", view.FullName,
+		"email", view.Email,
+		"disabled", view.IsDisabled,
+		"created at", view.CreatedAt.Format(time.RFC3339),
+	)
+	if view.DisabledAt != nil {
+		printFields(w, "disabled at", view.DisabledAt.Format(time.RFC3339))
+	}
+	if view.Password != "" {
+		printFields(w, "password", view.Password)
+	}
+}
+
+func newUserCommand(app *app) *cobra.Command {
+	user := &cobra.Command{
+		Use: "user",
+		Short: "Manage users",
+	}
+	user.AddCommand(newUserCreateCommand(app), newUserDisableCommand(app))
+	return user
+}
+
+func newUserCreateCommand(app *app) *cobra.Command {
+	var username, fullName, email string
+	var passwordStdin bool
+
+	create := &cobra.Command{
+		Use: "create",
+		Short: "Create a user, with a generated password unless one is read from stdin",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := mail.ParseAddress(email); err != nil {
+				return fmt.Errorf("invalid email: %s", email)
+			}
+
+			password, generated := "", false
+			if passwordStdin {
+				line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && !errors.Is(err, io.EOF) {
+					return fmt.Errorf("cannot read password: %w", err)
+				}
+				password = strings.TrimRight(line, "\r\n")
+				if len(password) < minPasswordLength {
+					return fmt.Errorf("password must have at least %d characters", minPasswordLength)
+				}
+			} else {
+				var err error
+				password, err = generatePassword()
+				if err != nil {
+					return err
+				}
+				generated = true
+			}
+
+			hashedPassword, err := util.HashPassword(password)
+			if err != nil {
+				return err
+			}
+
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			user, err := store.CreateUserTx(cmd.Context(), db.CreateUserParams{
+				Username: username,
+				HashedPassword: hashedPassword,
+				FullName: fullName,
+				Email: email,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot create user: %w", err)
+			}
+
+			view := newUserView(user)
+			if generated {
+				view.Password = password
+			}
+			return app.print(cmd, view, view.printText)
+		},
+	}
+
+	create.Flags().StringVar(&username, "username", "", "username (required)")
+	create.Flags().StringVar(&fullName, "full-name", "", "full name (required)")
+	create.Flags().StringVar(&email, "email", "", "email (required)")
+	create.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin instead of generating one")
+	create.MarkFlagRequired("username")
+	create.MarkFlagRequired("full-name")
+	create.MarkFlagRequired("email")
+	return create
+}
+
+func newUserDisableCommand(app *app) *cobra.Command {
+	return &cobra.Command{
+		Use: "disable <username>",
+		Short: "Disable a user so it can no longer log in",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := app.openStore(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			user, err := store.DisableUser(cmd.Context(), args[0])
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("user %s does not exist or is disabled already", args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("cannot disable user: %w", err)
+			}
+
+			view := newUserView(user)
+			return app.print(cmd, view, view.printText)
+		},
+	}
+}
+
+// generatePassword returns a random password to be handed to the user
+func generatePassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cannot generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCreateCommand(t *testing.T) {
+	username := util.RandomOwner()
+	email := util.RandomEmail()
+	args := []string{"user", "create", "--username", username, "--full-name", "John Doe", "--email", email, "-o", "json"}
+
+	testCases := []struct {
+		name string
+		args []string
+		stdin string
+		buildStubs func(store *mockdb.MockStore, password *string)
+		checkOutput func(t *testing.T, output string, password string, err error)
+	}{
+		{
+			name: "GeneratedPassword",
+			args: args,
+			buildStubs: func(store *mockdb.MockStore, password *string) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, "John Doe", arg.FullName)
+						require.Equal(t, email, arg.Email)
+						return db.User{Username: arg.Username, FullName: arg.FullName, Email: arg.Email, HashedPassword: arg.HashedPassword}, nil
+					})
+			},
+			checkOutput: func(t *testing.T, output string, password string, err error) {
+				require.NoError(t, err)
+				require.NotContains(t, output, "hashed_password")
+
+				var view userView
+				require.NoError(t, json.Unmarshal([]byte(output), &view))
+				require.Equal(t, username, view.Username)
+				require.GreaterOrEqual(t, len(view.Password), minPasswordLength)
+			},
+		},
+		{
+			name: "PasswordStdin",
+			args: append(args, "--password-stdin"),
+			stdin: "supersecret\n",
+			buildStubs: func(store *mockdb.MockStore, password *string) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+						require.NoError(t, util.CheckPassword("supersecret", arg.HashedPassword))
+						return db.User{Username: arg.Username}, nil
+					})
+			},
+			checkOutput: func(t *testing.T, output string, password string, err error) {
+				require.NoError(t, err)
+				require.NotContains(t, output, "supersecret")
+				require.NotContains(t, output, `"password"`)
+			},
+		},
+		{
+			name: "ShortPassword",
+			args: append(args, "--password-stdin"),
+			stdin: "short\n",
+			buildStubs: func(store *mockdb.MockStore, password *string) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkOutput: func(t *testing.T, output string, password string, err error) {
+				require.ErrorContains(t, err, "password must have at least")
+			},
+		},
+		{
+			name: "InvalidEmail",
+			args: []string{"user", "create", "--username", username, "--full-name", "John Doe", "--email", "john"},
+			buildStubs: func(store *mockdb.MockStore, password *string) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkOutput: func(t *testing.T, output string, password string, err error) {
+				require.ErrorContains(t, err, "invalid email")
+			},
+		},
+		{
+			name: "MissingFlag",
+			args: []string{"user", "create", "--username", username},
+			buildStubs: func(store *mockdb.MockStore, password *string) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkOutput: func(t *testing.T, output string, password string, err error) {
+				require.ErrorContains(t, err, "required flag")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var password string
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, &password)
+
+			output, err := executeCommand(t, store, tc.stdin, tc.args...)
+			tc.checkOutput(t, output, password, err)
+		})
+	}
+}
+
+func TestUserDisableCommand(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+		IsDisabled: true,
+		DisabledAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkOutput func(t *testing.T, output string, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DisableUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			checkOutput: func(t *testing.T, output string, err error) {
+				require.NoError(t, err)
+
+				var view userView
+				require.NoError(t, json.Unmarshal([]byte(output), &view))
+				require.True(t, view.IsDisabled)
+				require.True(t, user.DisabledAt.Equal(*view.DisabledAt))
+			},
+		},
+		{
+			name: "NotFoundOrDisabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DisableUser(gomock.Any(), user.Username).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkOutput: func(t *testing.T, output string, err error) {
+				require.ErrorContains(t, err, "does not exist or is disabled already")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			output, err := executeCommand(t, store, "", "user", "disable", user.Username, "-o", "json")
+			tc.checkOutput(t, output, err)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "interest_accruals";
+DROP TABLE IF EXISTS "interest_rates";
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_type_check";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "accrued_interest";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "account_type";
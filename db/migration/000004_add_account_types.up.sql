@@ -0,0 +1,35 @@
+ALTER TABLE "accounts" ADD COLUMN "account_type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts" ADD COLUMN "accrued_interest" numeric(24,10) NOT NULL DEFAULT 0;
+ALTER TABLE "accounts" ADD CONSTRAINT "account_type_check" CHECK ("account_type" IN ('checking', 'savings'));
+
+COMMENT ON COLUMN "accounts"."accrued_interest" IS 'interest accrued but not posted yet, in fractional cents';
+
+CREATE TABLE "interest_rates" (
+  "id" bigserial PRIMARY KEY,
+  "account_type" varchar NOT NULL,
+  "annual_rate" numeric(10,8) NOT NULL,
+  "effective_from" date NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate" numeric(10,8) NOT NULL,
+  "amount" numeric(24,10) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "interest_rates" ("account_type", "effective_from");
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+
+COMMENT ON COLUMN "interest_rates"."annual_rate" IS 'yearly rate as a fraction, 0.015 is 1.5%';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end of day balance';
+
+COMMENT ON COLUMN "interest_accruals"."amount" IS 'accrued interest in fractional cents';
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "account_number_key";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "account_number";
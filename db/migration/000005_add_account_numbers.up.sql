@@ -0,0 +1,15 @@
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar;
+
+-- Number existing accounts with the default country (ES) and bank code (9999).
+-- Check digits are computed as in ISO 13616: 98 - (BBAN || numeric country code || '00') mod 97
+WITH "numbers" AS (
+  SELECT "id", '9999' || lpad(floor(random() * 1e16)::bigint::text, 16, '0') AS "bban" FROM "accounts"
+)
+UPDATE "accounts" SET "account_number" = 'ES' || lpad((98 - (("numbers"."bban" || '142800')::numeric % 97))::text, 2, '0') || "numbers"."bban"
+FROM "numbers"
+WHERE "accounts"."id" = "numbers"."id";
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET NOT NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "account_number_key" UNIQUE ("account_number");
+
+COMMENT ON COLUMN "accounts"."account_number" IS 'IBAN, used to address the account externally';
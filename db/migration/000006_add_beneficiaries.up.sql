@@ -0,0 +1,18 @@
+CREATE TABLE "beneficiaries" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "is_verified" boolean NOT NULL DEFAULT false,
+  "verified_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "beneficiaries" ("owner");
+ALTER TABLE "beneficiaries" ADD CONSTRAINT "owner_nickname_key" UNIQUE ("owner", "nickname");
+ALTER TABLE "beneficiaries" ADD CONSTRAINT "owner_account_key" UNIQUE ("owner", "account_id");
+
+COMMENT ON COLUMN "beneficiaries"."verified_at" IS 'start of the cooling-off period for large transfers';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "transfers_reversal_of_key";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reversal_of";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "disabled_at";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "is_disabled";
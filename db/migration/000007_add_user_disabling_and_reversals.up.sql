@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN "is_disabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD COLUMN "disabled_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint NOT NULL DEFAULT 0;
+
+-- A transfer can be reversed only once
+CREATE UNIQUE INDEX "transfers_reversal_of_key" ON "transfers" ("reversal_of") WHERE "reversal_of" != 0;
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'id of the transfer this one reverses, 0 if none';
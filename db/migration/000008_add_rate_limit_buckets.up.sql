@@ -0,0 +1,9 @@
+CREATE TABLE "rate_limit_buckets" (
+  "key" varchar PRIMARY KEY,
+  "tokens" double precision NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
+
+COMMENT ON COLUMN "rate_limit_buckets"."tokens" IS 'tokens left at updated_at, refilled lazily when taking one';
@@ -0,0 +1,15 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z'
+);
+
+-- The relay only looks for the events not published yet
+CREATE INDEX "outbox_events_unpublished_idx" ON "outbox_events" ("id") WHERE "published_at" = '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "outbox_events"."aggregate_id" IS 'id or username of the row the event is about, events of the same aggregate are published in id order';
+COMMENT ON COLUMN "outbox_events"."published_at" IS 'zero until the relay publishes the event';
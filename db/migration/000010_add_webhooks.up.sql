@@ -0,0 +1,38 @@
+CREATE TABLE "webhooks" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "webhook_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z'
+);
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT "webhook_delivery_status_check" CHECK ("status" IN ('pending', 'succeeded', 'failed'));
+
+CREATE INDEX ON "webhooks" ("account_id");
+CREATE INDEX ON "webhook_deliveries" ("webhook_id");
+
+-- The deliverer only looks for the pending deliveries
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "webhooks"."secret" IS 'key of the HMAC-SHA256 signature of the deliveries';
+COMMENT ON COLUMN "webhook_deliveries"."event_id" IS 'id of the outbox event delivered';
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed once every attempt is exhausted';
+COMMENT ON COLUMN "webhook_deliveries"."delivered_at" IS 'zero until the delivery succeeds';
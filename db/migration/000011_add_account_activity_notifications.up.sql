@@ -0,0 +1,29 @@
+-- Every replica listens on the account_activity channel to stream the activity of the accounts to their owners.
+-- Notifications are sent on commit, in the order they were raised, and only to the sessions listening then.
+CREATE FUNCTION "notify_entry_created"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_activity', json_build_object(
+    'type', 'entry',
+    'account_id', NEW."account_id",
+    'data', row_to_json(NEW)
+  )::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION "notify_balance_updated"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('account_activity', json_build_object(
+    'type', 'balance',
+    'account_id', NEW."id",
+    'data', json_build_object('account_id', NEW."id", 'balance', NEW."balance", 'currency', NEW."currency")
+  )::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "entries_notify_created" AFTER INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION "notify_entry_created"();
+
+CREATE TRIGGER "accounts_notify_balance_updated" AFTER UPDATE OF "balance" ON "accounts"
+FOR EACH ROW WHEN (OLD."balance" IS DISTINCT FROM NEW."balance") EXECUTE FUNCTION "notify_balance_updated"();
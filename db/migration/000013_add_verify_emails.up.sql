@@ -0,0 +1,18 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "verify_emails" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "email" varchar NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL,
+  "sent_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z'
+);
+
+-- The sender only looks for the emails not sent yet
+CREATE INDEX "verify_emails_unsent_idx" ON "verify_emails" ("id") WHERE "sent_at" = '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "verify_emails"."email" IS 'address being verified, the user email when the verification was created';
+COMMENT ON COLUMN "verify_emails"."sent_at" IS 'zero until the verification email is sent';
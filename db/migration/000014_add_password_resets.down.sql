@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "password_resets";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "password_changed_at";
@@ -0,0 +1,15 @@
+ALTER TABLE "users" ADD COLUMN "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "password_resets" ("username");
+
+COMMENT ON COLUMN "users"."password_changed_at" IS 'access tokens issued before are revoked, zero if the password never changed';
+COMMENT ON COLUMN "password_resets"."token_hash" IS 'hex SHA-256 of the token sent to the user, the token itself is never stored';
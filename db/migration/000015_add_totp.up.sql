@@ -0,0 +1,16 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "is_totp_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "code_hash" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("username", "code_hash")
+);
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'base32 TOTP secret, set on enrollment and kept once confirmed';
+COMMENT ON COLUMN "users"."totp_last_step" IS 'time step of the last TOTP code accepted, so codes cannot be replayed';
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'hex SHA-256 of the recovery code given to the user, the code itself is never stored';
@@ -0,0 +1,12 @@
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "period" date NOT NULL,
+  "amount" bigint NOT NULL,
+  "transfer_id" bigint NOT NULL REFERENCES "transfers" ("id"),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("account_id", "period")
+);
+
+COMMENT ON COLUMN "interest_postings"."period" IS 'first day of the month the interest is posted for';
+COMMENT ON COLUMN "interest_postings"."amount" IS 'whole cents posted';
@@ -0,0 +1,3 @@
+ALTER TABLE "outbox_events" ADD COLUMN "leased_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "outbox_events"."leased_until" IS 'until when the event is kept from other relays while a relay publishes it, zero if it is not';
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "verify_emails" DROP COLUMN IF EXISTS "leased_until";
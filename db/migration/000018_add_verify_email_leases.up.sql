@@ -0,0 +1,3 @@
+ALTER TABLE "verify_emails" ADD COLUMN "leased_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "verify_emails"."leased_until" IS 'until when the email is kept from other senders while a sender sends it, zero if it is not';
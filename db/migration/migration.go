@@ -0,0 +1,308 @@
+// Package migration embeds the database migrations and applies them.
+// The version is kept in the schema_migrations table used by the migrate CLI,
+// so databases migrated with either can be migrated with the other.
+//
+// SQLite databases have migrations of their own, in the sqlite directory. They start from the schema
+// of the version their first migration is numbered after, and every later Postgres migration must be
+// ported to SQLite with the same version.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// advisoryLockID identifies the Postgres advisory lock held while migrating,
+// so instances starting at the same time do not apply migrations concurrently
+const advisoryLockID = 7236547101
+
+var (
+	ErrDirty = errors.New("database schema is dirty, a migration failed halfway and must be fixed by hand")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrUnsupportedDriver = errors.New("unsupported database driver")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a pair of embedded up and down scripts
+type Migration struct {
+	Version int64
+	Name string
+	Up string
+	Down string
+}
+
+// dialect holds the migrations of a database engine and how they are applied
+type dialect struct {
+	migrations []Migration
+	// lock keeps other instances from migrating the database until unlock is called
+	lock func(ctx context.Context, conn *sql.Conn) (unlock func(), err error)
+}
+
+// dialects are indexed by the name of their database/sql driver
+var dialects = map[string]*dialect{
+	"postgres": {migrations: mustLoad(files, "."), lock: advisoryLock},
+	"sqlite": {migrations: mustLoad(sqliteFiles, "sqlite"), lock: transactionLock},
+}
+
+var migrations = dialects["postgres"].migrations
+
+func mustLoad(fsys fs.FS, dir string) []Migration {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	loaded, err := load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+// dialectOf returns the dialect of databases opened with driver
+func dialectOf(driver string) (*dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
+	}
+	return d, nil
+}
+
+// advisoryLock takes a Postgres advisory lock.
+// Advisory locks belong to the session, so they are taken and released on the same connection.
+func advisoryLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		return nil, err
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+	}, nil
+}
+
+// transactionLock takes no lock: SQLite serializes the transactions migrations run in,
+// and a migration applied meanwhile makes the next one fail instead of being applied twice
+func transactionLock(context.Context, *sql.Conn) (func(), error) {
+	return func() {}, nil
+}
+
+// load reads the migrations in fsys, sorted by version
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	loaded := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both an up and a down script", migration.Version, migration.Name)
+		}
+		loaded = append(loaded, *migration)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Version < loaded[j].Version })
+	return loaded, nil
+}
+
+// Migrations returns the embedded Postgres migrations, sorted by version
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// LatestVersion returns the version of the last embedded migration, the same for every driver
+func LatestVersion() int64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// previousVersion returns the version before the given one, or 0 if it is the first
+func (d *dialect) previousVersion(version int64) (int64, error) {
+	for i, migration := range d.migrations {
+		if migration.Version == version {
+			if i == 0 {
+				return 0, nil
+			}
+			return d.migrations[i-1].Version, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+}
+
+// Up migrates the database, opened with driver, to the latest version
+func Up(ctx context.Context, db *sql.DB, driver string) error {
+	return Migrate(ctx, db, driver, LatestVersion())
+}
+
+// Down reverts the last migration applied to the database, if any
+func Down(ctx context.Context, db *sql.DB, driver string) error {
+	d, err := dialectOf(driver)
+	if err != nil {
+		return err
+	}
+	return d.migrate(ctx, db, func(current int64) (int64, error) {
+		if current == 0 {
+			return 0, nil
+		}
+		return d.previousVersion(current)
+	})
+}
+
+// Migrate applies up or down migrations until the database is at version; 0 reverts every migration.
+func Migrate(ctx context.Context, db *sql.DB, driver string, version int64) error {
+	d, err := dialectOf(driver)
+	if err != nil {
+		return err
+	}
+	if version != 0 {
+		if _, err := d.previousVersion(version); err != nil {
+			return err
+		}
+	}
+	return d.migrate(ctx, db, func(int64) (int64, error) { return version, nil })
+}
+
+// migrate moves the database from its current version to the one chosen by target.
+// Each migration runs in its own transaction along with the version update, so a failed migration
+// leaves the database at the previous version.
+func (d *dialect) migrate(ctx context.Context, db *sql.DB, target func(current int64) (int64, error)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := d.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("cannot lock migrations: %w", err)
+	}
+	defer unlock()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	current, err := currentVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	version, err := target(current)
+	if err != nil {
+		return err
+	}
+
+	for i := range d.migrations {
+		migration := d.migrations[i]
+		if migration.Version > current && migration.Version <= version {
+			err = apply(ctx, conn, migration.Up, migration.Version)
+			if err != nil {
+				return fmt.Errorf("cannot apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "migration applied", "version", migration.Version, "name", migration.Name)
+		}
+	}
+
+	for i := len(d.migrations) - 1; i >= 0; i-- {
+		migration := d.migrations[i]
+		if migration.Version <= current && migration.Version > version {
+			previous, _ := d.previousVersion(migration.Version)
+			err = apply(ctx, conn, migration.Down, previous)
+			if err != nil {
+				return fmt.Errorf("cannot revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "migration reverted", "version", migration.Version, "name", migration.Name)
+		}
+	}
+
+	return nil
+}
+
+// currentVersion reads the version the database is at, 0 if no migration was applied
+func currentVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return version, fmt.Errorf("%w: version %d", ErrDirty, version)
+	}
+	return version, nil
+}
+
+// apply runs script and records version as the current one, within a single transaction
+func apply(ctx context.Context, conn *sql.Conn, script string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
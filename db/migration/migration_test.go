@@ -0,0 +1,162 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gorkaio/simplebank/util"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations := Migrations()
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		require.Equal(t, int64(i+1), migration.Version, "migration versions must be consecutive")
+		require.NotEmpty(t, migration.Name)
+		require.NotEmpty(t, migration.Up)
+		require.NotEmpty(t, migration.Down)
+	}
+	require.Equal(t, migrations[len(migrations)-1].Version, LatestVersion())
+}
+
+func TestDialectsAreUpToDate(t *testing.T) {
+	for driver, d := range dialects {
+		require.NotEmpty(t, d.migrations, driver)
+		require.Equal(t, LatestVersion(), d.migrations[len(d.migrations)-1].Version, "%s migrations are not up to date", driver)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name string
+		files fstest.MapFS
+		checkResult func(t *testing.T, migrations []Migration, err error)
+	}{
+		{
+			name: "OK",
+			files: fstest.MapFS{
+				"000010_b.up.sql": {Data: []byte("up b")},
+				"000010_b.down.sql": {Data: []byte("down b")},
+				"000002_a.up.sql": {Data: []byte("up a")},
+				"000002_a.down.sql": {Data: []byte("down a")},
+			},
+			checkResult: func(t *testing.T, migrations []Migration, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []Migration{
+					{Version: 2, Name: "a", Up: "up a", Down: "down a"},
+					{Version: 10, Name: "b", Up: "up b", Down: "down b"},
+				}, migrations)
+			},
+		},
+		{
+			name: "MissingDown",
+			files: fstest.MapFS{
+				"000001_a.up.sql": {Data: []byte("up a")},
+			},
+			checkResult: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "must have both an up and a down script")
+			},
+		},
+		{
+			name: "InvalidName",
+			files: fstest.MapFS{
+				"add_users.sql": {Data: []byte("up a")},
+			},
+			checkResult: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "invalid migration file name")
+			},
+		},
+		{
+			name: "NameMismatch",
+			files: fstest.MapFS{
+				"000001_a.up.sql": {Data: []byte("up a")},
+				"000001_b.down.sql": {Data: []byte("down b")},
+			},
+			checkResult: func(t *testing.T, migrations []Migration, err error) {
+				require.ErrorContains(t, err, "has two names")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := load(tc.files)
+			tc.checkResult(t, migrations, err)
+		})
+	}
+}
+
+func TestMigrateUnknownVersion(t *testing.T) {
+	err := Migrate(context.Background(), nil, "postgres", LatestVersion()+1)
+	require.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrateUnsupportedDriver(t *testing.T) {
+	err := Up(context.Background(), nil, "mysql")
+	require.ErrorIs(t, err, ErrUnsupportedDriver)
+}
+
+func TestMigrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres is skipped in short mode")
+	}
+
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// migrating twice is a no-op
+	require.NoError(t, Up(context.Background(), conn, config.DBDriver))
+	require.NoError(t, Up(context.Background(), conn, config.DBDriver))
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRow("SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	require.NoError(t, err)
+	require.Equal(t, LatestVersion(), version)
+	require.False(t, dirty)
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	// the sqlite dialect is the one of the db.SQLiteDriver connections, plain SQLite ones run its migrations too
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "simplebank.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	requireVersion := func(expected int64) {
+		var version int64
+		err := conn.QueryRow("SELECT version FROM schema_migrations").Scan(&version)
+		if expected == 0 {
+			require.ErrorIs(t, err, sql.ErrNoRows)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, version)
+	}
+
+	require.NoError(t, Up(context.Background(), conn, "sqlite"))
+	require.NoError(t, Up(context.Background(), conn, "sqlite"))
+	requireVersion(LatestVersion())
+
+	require.NoError(t, Down(context.Background(), conn, "sqlite"))
+	requireVersion(LatestVersion() - 1)
+
+	require.NoError(t, Migrate(context.Background(), conn, "sqlite", 0))
+	requireVersion(0)
+	_, err = conn.Exec("SELECT * FROM accounts")
+	require.ErrorContains(t, err, "no such table")
+
+	require.NoError(t, Migrate(context.Background(), conn, "sqlite", LatestVersion()))
+	requireVersion(LatestVersion())
+}
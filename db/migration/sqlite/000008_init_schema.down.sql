@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "rate_limit_buckets";
+DROP TABLE IF EXISTS "beneficiaries";
+DROP TABLE IF EXISTS "interest_accruals";
+DROP TABLE IF EXISTS "interest_rates";
+DROP TABLE IF EXISTS "transfers";
+DROP TABLE IF EXISTS "entries";
+DROP TABLE IF EXISTS "accounts";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,101 @@
+-- SQLite databases start with the schema of Postgres migration 8.
+-- Timestamps are stored in UTC, dates at midnight UTC, and numerics as decimal text.
+CREATE TABLE "users" (
+  "username" varchar PRIMARY KEY,
+  "hashed_password" varchar NOT NULL,
+  "full_name" varchar NOT NULL,
+  "email" varchar UNIQUE NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "updated_at" timestamp NOT NULL,
+  "is_disabled" boolean NOT NULL DEFAULT false,
+  "disabled_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'
+);
+
+CREATE TABLE "accounts" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "balance" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "account_type" varchar NOT NULL DEFAULT 'checking',
+  "accrued_interest" text NOT NULL DEFAULT '0.0000000000',
+  "account_number" varchar NOT NULL,
+  CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency"),
+  CONSTRAINT "account_number_key" UNIQUE ("account_number"),
+  CONSTRAINT "account_status_check" CHECK ("status" IN ('active', 'frozen', 'closed')),
+  CONSTRAINT "account_type_check" CHECK ("account_type" IN ('checking', 'savings'))
+);
+
+CREATE TABLE "entries" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "amount" bigint NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "transfers" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "from_account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "to_account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "amount" bigint NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "reversal_of" bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "interest_rates" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_type" varchar NOT NULL,
+  "annual_rate" text NOT NULL,
+  "effective_from" date NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "interest_accruals" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate" text NOT NULL,
+  "amount" text NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "beneficiaries" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "is_verified" boolean NOT NULL DEFAULT false,
+  "verified_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  "created_at" timestamp NOT NULL,
+  CONSTRAINT "owner_nickname_key" UNIQUE ("owner", "nickname"),
+  CONSTRAINT "owner_account_key" UNIQUE ("owner", "account_id")
+);
+
+CREATE TABLE "rate_limit_buckets" (
+  "key" varchar PRIMARY KEY,
+  "tokens" double precision NOT NULL,
+  "updated_at" timestamp NOT NULL
+);
+
+CREATE INDEX "accounts_owner_idx" ON "accounts" ("owner");
+
+CREATE INDEX "entries_account_id_idx" ON "entries" ("account_id");
+
+CREATE INDEX "transfers_from_account_id_idx" ON "transfers" ("from_account_id");
+
+CREATE INDEX "transfers_to_account_id_idx" ON "transfers" ("to_account_id");
+
+CREATE INDEX "transfers_from_account_id_to_account_id_idx" ON "transfers" ("from_account_id", "to_account_id");
+
+-- A transfer can be reversed only once
+CREATE UNIQUE INDEX "transfers_reversal_of_key" ON "transfers" ("reversal_of") WHERE "reversal_of" != 0;
+
+CREATE UNIQUE INDEX "interest_rates_account_type_effective_from_idx" ON "interest_rates" ("account_type", "effective_from");
+
+CREATE UNIQUE INDEX "interest_accruals_account_id_accrual_date_idx" ON "interest_accruals" ("account_id", "accrual_date");
+
+CREATE INDEX "beneficiaries_owner_idx" ON "beneficiaries" ("owner");
+
+CREATE INDEX "rate_limit_buckets_updated_at_idx" ON "rate_limit_buckets" ("updated_at");
@@ -0,0 +1,12 @@
+CREATE TABLE "outbox_events" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" text NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "published_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'
+);
+
+-- The relay only looks for the events not published yet
+CREATE INDEX "outbox_events_unpublished_idx" ON "outbox_events" ("id") WHERE "published_at" = '0001-01-01 00:00:00+00:00';
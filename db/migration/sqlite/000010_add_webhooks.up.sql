@@ -0,0 +1,32 @@
+CREATE TABLE "webhooks" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  -- a JSON array, SQLite has no arrays
+  "event_types" text NOT NULL,
+  "created_at" timestamp NOT NULL
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "webhook_id" bigint NOT NULL REFERENCES "webhooks" ("id") ON DELETE CASCADE,
+  "event_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" text NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamp NOT NULL,
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "delivered_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  CONSTRAINT "webhook_delivery_status_check" CHECK ("status" IN ('pending', 'succeeded', 'failed'))
+);
+
+CREATE INDEX "webhooks_account_id_idx" ON "webhooks" ("account_id");
+
+CREATE INDEX "webhook_deliveries_webhook_id_idx" ON "webhook_deliveries" ("webhook_id");
+
+-- The deliverer only looks for the pending deliveries
+CREATE INDEX "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
@@ -0,0 +1,2 @@
+-- SQLite has no LISTEN/NOTIFY: the account activity is published in-process, by the store of the server.
+SELECT 1;
@@ -0,0 +1 @@
+ALTER TABLE "accounts" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
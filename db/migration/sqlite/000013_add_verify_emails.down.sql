@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "verify_emails";
+ALTER TABLE "users" DROP COLUMN "is_email_verified";
@@ -0,0 +1,15 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "verify_emails" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "email" varchar NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL,
+  "expired_at" timestamp NOT NULL,
+  "sent_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'
+);
+
+-- The sender only looks for the emails not sent yet
+CREATE INDEX "verify_emails_unsent_idx" ON "verify_emails" ("id") WHERE "sent_at" = '0001-01-01 00:00:00+00:00';
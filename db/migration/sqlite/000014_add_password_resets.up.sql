@@ -0,0 +1,12 @@
+ALTER TABLE "users" ADD COLUMN "password_changed_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+
+CREATE TABLE "password_resets" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL,
+  "expired_at" timestamp NOT NULL
+);
+
+CREATE INDEX "password_resets_username_idx" ON "password_resets" ("username");
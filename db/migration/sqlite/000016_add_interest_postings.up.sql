@@ -0,0 +1,9 @@
+CREATE TABLE "interest_postings" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL REFERENCES "accounts" ("id"),
+  "period" date NOT NULL,
+  "amount" bigint NOT NULL,
+  "transfer_id" bigint NOT NULL REFERENCES "transfers" ("id"),
+  "created_at" timestamp NOT NULL,
+  UNIQUE ("account_id", "period")
+);
@@ -0,0 +1 @@
+ALTER TABLE "outbox_events" ADD COLUMN "leased_until" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
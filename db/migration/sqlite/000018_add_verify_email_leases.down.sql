@@ -0,0 +1 @@
+ALTER TABLE "verify_emails" DROP COLUMN "leased_until";
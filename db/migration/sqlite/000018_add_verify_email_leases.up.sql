@@ -0,0 +1 @@
+ALTER TABLE "verify_emails" ADD COLUMN "leased_until" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
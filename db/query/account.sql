@@ -1,8 +1,8 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-    owner, balance, currency 
+    owner, balance, currency, account_type, account_number
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: GetAccount :one
@@ -13,9 +13,26 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 LIMIT 1;
+
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
+-- name: GetAccountEndOfDayBalance :one
+-- Balance at the given instant, computed by reverting the entries created after it
+SELECT (a.balance - COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(end_of_day)
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = sqlc.arg(id);
+
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount), version = version + 1
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
@@ -24,8 +41,29 @@ SELECT * FROM accounts
 ORDER BY id
 LIMIT $1 OFFSET $2;
 
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: ListAccountsByType :many
+SELECT * FROM accounts
+WHERE account_type = $1 AND status != 'closed'
+ORDER BY id;
+
+-- name: AddAccountAccruedInterest :one
+UPDATE accounts
+SET accrued_interest = accrued_interest + sqlc.arg(amount)::numeric, version = version + 1
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: UpdateAccount :one
-UPDATE accounts SET balance = $2 WHERE id = $1 RETURNING *;
+-- Updates the balance only if the account is still at the given version
+UPDATE accounts SET balance = $2, version = version + 1 WHERE id = $1 AND version = $3 RETURNING *;
 
 -- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1;
\ No newline at end of file
+DELETE FROM accounts WHERE id = $1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts SET status = sqlc.arg(status), version = version + 1 WHERE id = sqlc.arg(id) RETURNING *;
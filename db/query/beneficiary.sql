@@ -0,0 +1,37 @@
+-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (
+    owner, nickname, account_id
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetBeneficiary :one
+SELECT * FROM beneficiaries
+WHERE id = $1 LIMIT 1;
+
+-- name: GetBeneficiaryByOwnerAndAccount :one
+SELECT * FROM beneficiaries
+WHERE owner = $1 AND account_id = $2 LIMIT 1;
+
+-- name: ListBeneficiaries :many
+SELECT * FROM beneficiaries
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateBeneficiaryNickname :one
+UPDATE beneficiaries
+SET nickname = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: VerifyBeneficiary :one
+-- Verifying twice keeps the original verification time, so it does not restart the cooling-off period
+UPDATE beneficiaries
+SET is_verified = true, verified_at = CASE WHEN is_verified THEN verified_at ELSE now() END
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteBeneficiary :exec
+DELETE FROM beneficiaries
+WHERE id = $1;
@@ -18,4 +18,13 @@ LIMIT $1 OFFSET $2;
 SELECT * FROM entries
 WHERE account_id = $1
 ORDER BY id
-LIMIT $2 OFFSET $3;
\ No newline at end of file
+LIMIT $2 OFFSET $3;
+
+-- name: ListUnbalancedAccounts :many
+-- Accounts whose balance differs from the sum of their entries
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance != COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
@@ -0,0 +1,41 @@
+-- name: CreateInterestRate :one
+INSERT INTO interest_rates (
+    account_type, annual_rate, effective_from
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+WHERE account_type = $1
+ORDER BY effective_from;
+
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+    account_id, accrual_date, balance, annual_rate, amount
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING *;
+
+-- name: GetLastInterestAccrual :one
+SELECT * FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date DESC
+LIMIT 1;
+
+-- name: ListInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date
+LIMIT $2 OFFSET $3;
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+    account_id, period, amount, transfer_id
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (account_id, period) DO NOTHING
+RETURNING *;
@@ -0,0 +1,45 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+    aggregate_type, aggregate_id, event_type, payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListUnpublishedOutboxEvents :many
+-- Oldest events not published yet. They stay locked until the transaction ends,
+-- so relays running concurrently claim them one after the other.
+SELECT * FROM outbox_events
+WHERE published_at = '0001-01-01 00:00:00Z'
+ORDER BY id
+LIMIT $1
+FOR UPDATE;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now()
+WHERE id = $1;
+
+-- name: ClaimOutboxEvents :many
+-- Oldest events not published yet, leased to the relay claiming them while it publishes them outside of any transaction.
+-- Nothing is claimed while another relay holds a lease, so the events of an aggregate are published in order,
+-- and the events of a relay stopping halfway are claimed again once its lease runs out.
+UPDATE outbox_events
+SET leased_until = now() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at = '0001-01-01 00:00:00Z'
+    ORDER BY id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE
+)
+AND NOT EXISTS (
+    SELECT 1 FROM outbox_events
+    WHERE published_at = '0001-01-01 00:00:00Z' AND leased_until > now()
+)
+RETURNING *;
+
+-- name: ReleaseOutboxEvent :exec
+-- Lets other relays claim an event which was not published
+UPDATE outbox_events
+SET leased_until = '0001-01-01 00:00:00Z'
+WHERE id = $1;
@@ -0,0 +1,19 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    username, token_hash, expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+-- Marks the reset used if it is neither used nor expired
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1 AND NOT is_used AND expired_at > now()
+RETURNING *;
+
+-- name: InvalidatePasswordResets :exec
+-- Marks every reset of the user not used yet as used, so none of them can be used anymore
+UPDATE password_resets
+SET is_used = true
+WHERE username = $1 AND NOT is_used;
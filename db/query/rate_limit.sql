@@ -0,0 +1,23 @@
+-- name: TakeRateLimitToken :one
+-- Takes a token from the bucket, refilled at rate tokens per second up to burst, creating it full if missing.
+-- No row is returned if the bucket is empty.
+INSERT INTO rate_limit_buckets AS b (
+    key, tokens, updated_at
+) VALUES (
+    sqlc.arg(key), sqlc.arg(burst)::float8 - 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST(sqlc.arg(burst)::float8, b.tokens + sqlc.arg(rate)::float8 * EXTRACT(EPOCH FROM now() - b.updated_at)) - 1,
+    updated_at = now()
+WHERE LEAST(sqlc.arg(burst)::float8, b.tokens + sqlc.arg(rate)::float8 * EXTRACT(EPOCH FROM now() - b.updated_at)) >= 1
+RETURNING *;
+
+-- name: GetRateLimitTokens :one
+-- Tokens in the bucket now, refilled at rate tokens per second up to burst
+SELECT LEAST(sqlc.arg(burst)::float8, tokens + sqlc.arg(rate)::float8 * EXTRACT(EPOCH FROM now() - updated_at))::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = sqlc.arg(key);
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < now() - make_interval(secs => sqlc.arg(idle_seconds)::float8);
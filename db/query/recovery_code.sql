@@ -0,0 +1,17 @@
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    username, code_hash
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: UseRecoveryCode :one
+-- Marks the code used if it was not
+UPDATE recovery_codes
+SET is_used = true
+WHERE username = $1 AND code_hash = $2 AND NOT is_used
+RETURNING *;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
@@ -16,4 +16,18 @@ WHERE
     (CASE WHEN sqlc.arg(from_account_id)::bigint != 0 THEN from_account_id = sqlc.arg(from_account_id)::bigint ELSE TRUE END) AND
     (CASE WHEN sqlc.arg(to_account_id)::bigint != 0 THEN to_account_id = sqlc.arg(to_account_id)::bigint ELSE TRUE END)
 ORDER BY id
-LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: GetTransferReversal :one
+SELECT * FROM transfers
+WHERE reversal_of = $1 LIMIT 1;
+
+-- name: SetTransferReversalOf :one
+UPDATE transfers
+SET reversal_of = sqlc.arg(reversal_of)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -7,4 +7,49 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: DisableUser :one
+UPDATE users
+SET is_disabled = true, disabled_at = now(), updated_at = now()
+WHERE username = $1 AND NOT is_disabled
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+-- Flags the email of the user as verified, if it is still the address that was verified
+UPDATE users
+SET is_email_verified = true, updated_at = now()
+WHERE username = sqlc.arg(username) AND email = sqlc.arg(email)
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+-- Revokes the access tokens issued before, by moving password_changed_at past them
+UPDATE users
+SET hashed_password = sqlc.arg(hashed_password), password_changed_at = now(), updated_at = now()
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+-- Replaces the secret of an enrollment not confirmed yet
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret), updated_at = now()
+WHERE username = sqlc.arg(username) AND NOT is_totp_enabled
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+-- Confirms the enrollment, if the secret is still the one the code was checked against
+UPDATE users
+SET is_totp_enabled = true, totp_last_step = sqlc.arg(totp_last_step), updated_at = now()
+WHERE username = sqlc.arg(username) AND totp_secret = sqlc.arg(totp_secret) AND NOT is_totp_enabled
+RETURNING *;
+
+-- name: UseUserTOTPStep :one
+-- Records the step of an accepted code, unless it or a later one was used already
+UPDATE users
+SET totp_last_step = sqlc.arg(totp_last_step)
+WHERE username = sqlc.arg(username) AND is_totp_enabled AND totp_last_step < sqlc.arg(totp_last_step)
+RETURNING *;
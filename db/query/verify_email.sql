@@ -0,0 +1,38 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username, email, secret_code, expired_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ClaimVerifyEmails :many
+-- Oldest verification emails not sent yet and not expired, leased to the sender claiming them while it sends them
+-- outside of any transaction, so other senders skip them. They are claimed again once the lease runs out.
+UPDATE verify_emails
+SET leased_until = now() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+    SELECT id FROM verify_emails
+    WHERE sent_at = '0001-01-01 00:00:00Z' AND expired_at > now() AND leased_until <= now()
+    ORDER BY id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkVerifyEmailSent :exec
+UPDATE verify_emails
+SET sent_at = now()
+WHERE id = $1;
+
+-- name: ReleaseVerifyEmail :exec
+-- Lets other senders claim an email which was not sent
+UPDATE verify_emails
+SET leased_until = '0001-01-01 00:00:00Z'
+WHERE id = $1;
+
+-- name: UseVerifyEmail :one
+-- Marks the verification used if its secret code matches and it is neither used nor expired
+UPDATE verify_emails
+SET is_used = true
+WHERE id = sqlc.arg(id) AND secret_code = sqlc.arg(secret_code) AND NOT is_used AND expired_at > now()
+RETURNING *;
@@ -0,0 +1,75 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    account_id, url, secret, event_types
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE account_id = $1
+ORDER BY id;
+
+-- name: ListWebhooksForEvent :many
+-- Webhooks of the account subscribed to the event type
+SELECT * FROM webhooks
+WHERE account_id = sqlc.arg(account_id) AND sqlc.arg(event_type)::varchar = ANY(event_types)
+ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    webhook_id, event_id, event_type, payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: ClaimWebhookDeliveries :many
+-- Pending deliveries due now. Their next attempt is put off by the lease, so other deliverers skip them
+-- while they are being delivered, and they are attempted again if the deliverer stops halfway.
+UPDATE webhook_deliveries
+SET next_attempt_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY next_attempt_at, id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+    status = sqlc.arg(status),
+    attempts = attempts + 1,
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    delivered_at = sqlc.arg(delivered_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ReplayWebhookDelivery :one
+-- Makes the delivery pending again, due now and with every attempt available
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = now()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gorkaio/simplebank/util"
+)
+
+// accountNumberAttempts bounds how many account numbers CreateAccountTx draws when they are taken already
+const accountNumberAttempts = 5
+
+// generateAccountNumber draws the account numbers of new accounts, tests replace it to draw taken ones
+var generateAccountNumber = util.GenerateIBAN
+
+type CreateAccountTxParams struct {
+	Owner string `json:"owner"`
+	Currency string `json:"currency"`
+	AccountType string `json:"account_type"`
+	Balance int64 `json:"balance"`
+	// Country and bank codes of the IBAN generated as the account number
+	IBANCountryCode string `json:"iban_country_code"`
+	IBANBankCode string `json:"iban_bank_code"`
+}
+
+// CreateAccountTx creates an account with a new random account number and records EventAccountCreated within a transaction.
+// The number is drawn again, in a new transaction, when it belongs to another account already.
+func (store *txStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var account Account
+
+	for attempt := 1; ; attempt++ {
+		accountNumber, err := generateAccountNumber(arg.IBANCountryCode, arg.IBANBankCode)
+		if err != nil {
+			return Account{}, err
+		}
+
+		err = store.execTx(ctx, func(q Querier) error {
+			var err error
+			account, err = q.CreateAccount(ctx, CreateAccountParams{
+				Owner: arg.Owner,
+				Balance: arg.Balance,
+				Currency: arg.Currency,
+				AccountType: arg.AccountType,
+				AccountNumber: accountNumber,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = recordEvent(ctx, q, AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountCreated, account)
+			return err
+		})
+		if isUniqueViolation(err, "account_number_key") && attempt < accountNumberAttempts {
+			continue
+		}
+		return account, err
+	}
+}
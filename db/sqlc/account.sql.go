@@ -5,13 +5,44 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
+const addAccountAccruedInterest = `-- name: AddAccountAccruedInterest :one
+UPDATE accounts
+SET accrued_interest = accrued_interest + $1::numeric, version = version + 1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version
+`
+
+type AddAccountAccruedInterestParams struct {
+	Amount string `json:"amount"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) AddAccountAccruedInterest(ctx context.Context, arg AddAccountAccruedInterestParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountAccruedInterest, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
+	)
+	return i, err
+}
+
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET balance = balance + $1, version = version + 1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version
 `
 
 type AddAccountBalanceParams struct {
@@ -28,26 +59,39 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
-    owner, balance, currency 
+    owner, balance, currency, account_type, account_number
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+    $1, $2, $3, $4, $5
+) RETURNING id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner         string `json:"owner"`
+	Balance       int64  `json:"balance"`
+	Currency      string `json:"currency"`
+	AccountType   string `json:"account_type"`
+	AccountNumber string `json:"account_number"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountType,
+		arg.AccountNumber,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -55,6 +99,11 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
 	)
 	return i, err
 }
@@ -69,7 +118,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -82,12 +131,90 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
 	)
 	return i, err
 }
 
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getAccountEndOfDayBalance = `-- name: GetAccountEndOfDayBalance :one
+SELECT (a.balance - COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= $1
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = $2
+`
+
+type GetAccountEndOfDayBalanceParams struct {
+	EndOfDay time.Time `json:"end_of_day"`
+	ID       int64     `json:"id"`
+}
+
+// Balance at the given instant, computed by reverting the entries created after it
+func (q *Queries) GetAccountEndOfDayBalance(ctx context.Context, arg GetAccountEndOfDayBalanceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAccountEndOfDayBalance, arg.EndOfDay, arg.ID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
 WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
 `
 
@@ -100,12 +227,17 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
 ORDER BY id
 LIMIT $1 OFFSET $2
 `
@@ -130,6 +262,98 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.AccountType,
+			&i.AccruedInterest,
+			&i.AccountNumber,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3
+`
+
+type ListAccountsByOwnerParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByOwner, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.AccountType,
+			&i.AccruedInterest,
+			&i.AccountNumber,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByType = `-- name: ListAccountsByType :many
+SELECT id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version FROM accounts
+WHERE account_type = $1 AND status != 'closed'
+ORDER BY id
+`
+
+func (q *Queries) ListAccountsByType(ctx context.Context, accountType string) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByType, accountType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.AccountType,
+			&i.AccruedInterest,
+			&i.AccountNumber,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -145,16 +369,45 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 }
 
 const updateAccount = `-- name: UpdateAccount :one
-UPDATE accounts SET balance = $2 WHERE id = $1 RETURNING id, owner, balance, currency, created_at
+UPDATE accounts SET balance = $2, version = version + 1 WHERE id = $1 AND version = $3 RETURNING id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version
 `
 
 type UpdateAccountParams struct {
 	ID      int64 `json:"id"`
 	Balance int64 `json:"balance"`
+	Version int64 `json:"version"`
 }
 
+// Updates the balance only if the account is still at the given version
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance)
+	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance, arg.Version)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts SET status = $1, version = version + 1 WHERE id = $2 RETURNING id, owner, balance, currency, created_at, status, account_type, accrued_interest, account_number, version
+`
+
+type UpdateAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -162,6 +415,11 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
 	)
 	return i, err
 }
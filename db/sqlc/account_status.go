@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+var (
+	ErrAccountFrozen = errors.New("account is frozen")
+	ErrAccountClosed = errors.New("account is closed")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	ErrNonZeroBalance = errors.New("account balance must be zero or swept to another account")
+	ErrInvalidSweepAccount = errors.New("sweep account must be a different account with the same currency")
+	ErrAccountVersionMismatch = errors.New("account has been modified since it was read")
+)
+
+// Allowed target statuses for every account status.
+// Frozen accounts must be reactivated before they can be closed.
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+	AccountStatusClosed: {AccountStatusActive},
+}
+
+func CanTransitionAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAccountActive returns ErrAccountFrozen or ErrAccountClosed if the account cannot take part in transfers
+func CheckAccountActive(account Account) error {
+	switch account.Status {
+	case AccountStatusFrozen:
+		return ErrAccountFrozen
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Status string `json:"status"`
+	// Account receiving the remaining balance when closing, optional
+	SweepAccountID int64 `json:"sweep_account_id"`
+	// Version the account must still be at, zero to update it whatever its version
+	Version int64 `json:"version"`
+}
+
+type UpdateAccountStatusTxResult struct {
+	Account Account `json:"account"`
+	SweepTransfer *Transfer `json:"sweep_transfer,omitempty"`
+}
+
+// Updating the account status happens within a transaction holding a lock on the account, and on the sweep account
+// when closing:
+//	- check the account is still at the expected version, if any
+//	- check the status transition is allowed
+//	- when closing, sweep any remaining balance to the sweep account
+//	- update the account status
+func (store *txStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error) {
+	var result UpdateAccountStatusTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		// the transaction may be retried, nothing must be left from a previous attempt
+		result = UpdateAccountStatusTxResult{}
+
+		account, err := lockAccountForStatusUpdate(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		if arg.Version != 0 && account.Version != arg.Version {
+			return ErrAccountVersionMismatch
+		}
+
+		if !CanTransitionAccountStatus(account.Status, arg.Status) {
+			return ErrInvalidStatusTransition
+		}
+
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			transfer, err := sweepBalance(ctx, q, account, arg.SweepAccountID)
+			if err != nil {
+				return err
+			}
+			result.SweepTransfer = &transfer
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID: arg.AccountID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// lockAccountForStatusUpdate locks the account, and the sweep account when closing, in id order as transfers
+// lock them, so closing an account cannot deadlock with a transfer between the same two accounts
+func lockAccountForStatusUpdate(ctx context.Context, q Querier, arg UpdateAccountStatusTxParams) (Account, error) {
+	if arg.Status == AccountStatusClosed && arg.SweepAccountID != 0 && arg.SweepAccountID < arg.AccountID {
+		// a missing sweep account is reported by sweepBalance, if the balance needs sweeping at all
+		if _, err := q.GetAccountForUpdate(ctx, arg.SweepAccountID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return Account{}, err
+		}
+	}
+
+	account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if arg.Status == AccountStatusClosed && arg.SweepAccountID > arg.AccountID {
+		if _, err := q.GetAccountForUpdate(ctx, arg.SweepAccountID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return Account{}, err
+		}
+	}
+	return account, nil
+}
+
+func sweepBalance(ctx context.Context, q Querier, account Account, sweepAccountID int64) (Transfer, error) {
+	if sweepAccountID == 0 || account.Balance < 0 {
+		return Transfer{}, ErrNonZeroBalance
+	}
+
+	if sweepAccountID == account.ID {
+		return Transfer{}, ErrInvalidSweepAccount
+	}
+
+	sweepAccount, err := q.GetAccount(ctx, sweepAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Transfer{}, ErrInvalidSweepAccount
+		}
+		return Transfer{}, err
+	}
+
+	if sweepAccount.Currency != account.Currency {
+		return Transfer{}, ErrInvalidSweepAccount
+	}
+
+	result, err := transferMoney(ctx, q, CreateTransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID: sweepAccountID,
+		Amount: account.Balance,
+	})
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	return result.Transfer, recordTransferCreated(ctx, q, result.Transfer)
+}
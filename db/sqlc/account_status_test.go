@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountWithCurrency(t *testing.T, store Store, currency string, balance int64) Account {
+	user := createRandomUser(t, store)
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner: user.Username,
+		Balance: balance,
+		Currency: currency,
+		AccountType: AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestCanTransitionAccountStatus(t *testing.T) {
+	require.True(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusFrozen))
+	require.True(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusClosed))
+	require.True(t, CanTransitionAccountStatus(AccountStatusFrozen, AccountStatusActive))
+	require.True(t, CanTransitionAccountStatus(AccountStatusClosed, AccountStatusActive))
+
+	require.False(t, CanTransitionAccountStatus(AccountStatusActive, AccountStatusActive))
+	require.False(t, CanTransitionAccountStatus(AccountStatusFrozen, AccountStatusClosed))
+	require.False(t, CanTransitionAccountStatus(AccountStatusClosed, AccountStatusFrozen))
+	require.False(t, CanTransitionAccountStatus(AccountStatusActive, "deleted"))
+}
+
+func testFreezeAccountTx(t *testing.T, store Store) {
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	result, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, result.Account.Status)
+	require.Nil(t, result.SweepTransfer)
+
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID: account1.ID,
+		Amount: 10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// failed transfers must not change balances
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	result, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, result.Account.Status)
+}
+
+func testUpdateAccountStatusTxVersion(t *testing.T, store Store) {
+	account := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	result, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status: AccountStatusFrozen,
+		Version: account.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, result.Account.Status)
+	require.Equal(t, account.Version+1, result.Account.Version)
+
+	// the account changed since it was read
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status: AccountStatusActive,
+		Version: account.Version,
+	})
+	require.ErrorIs(t, err, ErrAccountVersionMismatch)
+
+	unchanged, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Account, unchanged)
+}
+
+func testCloseAccountTx(t *testing.T, store Store) {
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 50)
+	account3 := createRandomAccountWithCurrency(t, store, util.USD, 0)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrNonZeroBalance)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusClosed,
+		SweepAccountID: account3.ID,
+	})
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	result, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusClosed,
+		SweepAccountID: account2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+	require.NotNil(t, result.SweepTransfer)
+	require.Equal(t, account1.Balance, result.SweepTransfer.Amount)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+account1.Balance, updatedAccount2.Balance)
+
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID: account1.ID,
+		Amount: 10,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// closed accounts with zero balance can be reopened
+	result, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account1.ID,
+		Status: AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, result.Account.Status)
+}
+
+// Closing an account sweeps its balance while transfers between the same accounts go on. The rows are locked in the
+// order transfers lock them, so they cannot deadlock whichever account has the lower id.
+func testCloseAccountTxConcurrentTransfers(t *testing.T, store Store) {
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+	require.Less(t, account1.ID, account2.ID)
+
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID: account2.ID,
+				Amount: 10,
+			})
+			errs <- err
+		}()
+	}
+
+	// the account with the higher id is closed into the one with the lower id
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status: AccountStatusClosed,
+		SweepAccountID: account1.ID,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err != nil {
+			require.ErrorIs(t, err, ErrAccountClosed)
+		}
+	}
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, updatedAccount2.Status)
+	require.Zero(t, updatedAccount2.Balance)
+	require.Equal(t, account1.Balance+account2.Balance, updatedAccount1.Balance)
+}
@@ -3,41 +3,56 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"strconv"
 	"testing"
 
 	"github.com/gorkaio/simplebank/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomAccount(t *testing.T) Account {
-	user := createRandomUser(t)
+// requirePQError checks err is the Postgres error of violating constraint
+func requirePQError(t *testing.T, err error, code pq.ErrorCode, constraint string) {
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, code, pqErr.Code)
+	require.Equal(t, constraint, pqErr.Constraint)
+}
+
+func createRandomAccount(t *testing.T, store Store) Account {
+	user := createRandomUser(t, store)
 	arg := CreateAccountParams{
 		Owner: user.Username,
 		Balance: util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		AccountType: AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
 	}
 
-	account, err := testQueries.CreateAccount(context.Background(), arg)
+	account, err := store.CreateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
 
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.AccountNumber, account.AccountNumber)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
+	require.Equal(t, AccountStatusActive, account.Status)
 
 	return account
 }
 
-func TestCreateAccount(t *testing.T) {
-	createRandomAccount(t)
+func testCreateAccount(t *testing.T, store Store) {
+	createRandomAccount(t, store)
 }
 
-func TestGetAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+func testGetAccount(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
+	account2, err := store.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -48,13 +63,26 @@ func TestGetAccount(t *testing.T) {
 	require.Equal(t, account1.CreatedAt, account2.CreatedAt)
 }
 
-func TestUpdateAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
+func testGetAccountByNumber(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
+	account2, err := store.GetAccountByNumber(context.Background(), account1.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.AccountNumber, account2.AccountNumber)
+
+	_, err = store.GetAccountByNumber(context.Background(), util.RandomAccountNumber())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testUpdateAccount(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
+	require.Equal(t, int64(1), account1.Version)
 	args := UpdateAccountParams{
 		ID: account1.ID,
 		Balance: util.RandomMoney(),
+		Version: account1.Version,
 	}
-	account2, err := testQueries.UpdateAccount(context.Background(), args)
+	account2, err := store.UpdateAccount(context.Background(), args)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -63,24 +91,84 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, account2.Balance, args.Balance)
 	require.Equal(t, account2.Currency, account1.Currency)
 	require.Equal(t, account2.CreatedAt, account1.CreatedAt)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	// a stale version updates nothing
+	_, err = store.UpdateAccount(context.Background(), args)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	account3, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2, account3)
 }
 
+func testAccountVersion(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
+
+	account2, err := store.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account1.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, account1.Version+1, account2.Version)
+
+	account3, err := store.AddAccountAccruedInterest(context.Background(), AddAccountAccruedInterestParams{ID: account1.ID, Amount: "0.5"})
+	require.NoError(t, err)
+	require.Equal(t, account2.Version+1, account3.Version)
 
-func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
+	account4, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{ID: account1.ID, Status: AccountStatusFrozen})
+	require.NoError(t, err)
+	require.Equal(t, account3.Version+1, account4.Version)
+}
+
+
+func testDeleteAccount(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
 	
-	err := testQueries.DeleteAccount(context.Background(), account1.ID)
+	err := store.DeleteAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := store.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, account2)
 }
 
-func TestListAccounts(t *testing.T) {
+func testCreateAccountConstraints(t *testing.T, store Store) {
+	account := createRandomAccount(t, store)
+
+	_, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner: account.Owner,
+		Currency: account.Currency,
+		AccountType: AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	requirePQError(t, err, "23505", "owner_currency_key")
+
+	_, err = store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner: util.RandomOwner(),
+		Currency: util.EUR,
+		AccountType: AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	requirePQError(t, err, "23503", "accounts_owner_fkey")
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID: account.ID,
+		Status: "unknown",
+	})
+	requirePQError(t, err, "23514", "account_status_check")
+}
+
+func testDeleteReferencedAccount(t *testing.T, store Store) {
+	entry := createRandomEntry(t, store)
+
+	err := store.DeleteAccount(context.Background(), entry.AccountID)
+	requirePQError(t, err, "23503", "entries_account_id_fkey")
+
+	_, err = store.GetAccount(context.Background(), entry.AccountID)
+	require.NoError(t, err)
+}
+
+func testListAccounts(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		createRandomAccount(t, store)
 	}
 
 	arg := ListAccountsParams{
@@ -88,11 +176,97 @@ func TestListAccounts(t *testing.T) {
 		Offset: 5,
 	}
 
-	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	accounts, err := store.ListAccounts(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, accounts, 5)
 
 	for _, account := range accounts {
 		require.NotEmpty(t, account)
 	}
-}
\ No newline at end of file
+}
+
+func testListAccountsByOwner(t *testing.T, store Store) {
+	account1 := createRandomAccount(t, store)
+	createRandomAccount(t, store)
+
+	accounts, err := store.ListAccountsByOwner(context.Background(), ListAccountsByOwnerParams{
+		Owner: account1.Owner,
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+}
+
+func testCreateAccountTx(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner: user.Username,
+		Currency: util.EUR,
+		AccountType: AccountTypeChecking,
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	})
+	require.NoError(t, err)
+	require.NoError(t, util.ValidateIBAN(account.AccountNumber))
+
+	events := unpublishedEvents(t, store, AggregateAccount, strconv.FormatInt(account.ID, 10))
+	require.Len(t, events, 1)
+	require.Equal(t, EventAccountCreated, events[0].EventType)
+
+	var payload Account
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.ID)
+	require.Equal(t, account.Owner, payload.Owner)
+	require.Equal(t, account.AccountNumber, payload.AccountNumber)
+
+	_, err = store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner: util.RandomOwner(),
+		Currency: util.EUR,
+		AccountType: AccountTypeChecking,
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	})
+	requirePQError(t, err, "23503", "accounts_owner_fkey")
+}
+
+func testCreateAccountTxAccountNumberTaken(t *testing.T, store Store) {
+	taken := createRandomAccount(t, store)
+	user := createRandomUser(t, store)
+
+	// the first number drawn belongs to another account already
+	drawn := 0
+	generateAccountNumber = func(countryCode string, bankCode string) (string, error) {
+		drawn++
+		if drawn == 1 {
+			return taken.AccountNumber, nil
+		}
+		return util.GenerateIBAN(countryCode, bankCode)
+	}
+	defer func() { generateAccountNumber = util.GenerateIBAN }()
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner: user.Username,
+		Currency: util.EUR,
+		AccountType: AccountTypeChecking,
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, drawn)
+	require.NotEqual(t, taken.AccountNumber, account.AccountNumber)
+	require.Len(t, unpublishedEvents(t, store, AggregateAccount, strconv.FormatInt(account.ID, 10)), 1)
+
+	// every number drawn is taken
+	generateAccountNumber = func(string, string) (string, error) { return taken.AccountNumber, nil }
+	_, err = store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		Owner: user.Username,
+		Currency: util.USD,
+		AccountType: AccountTypeChecking,
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	})
+	requirePQError(t, err, "23505", "account_number_key")
+}
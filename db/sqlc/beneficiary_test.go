@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBeneficiary(t *testing.T, store Store, owner User) Beneficiary {
+	account := createRandomAccount(t, store)
+	arg := CreateBeneficiaryParams{
+		Owner: owner.Username,
+		Nickname: util.RandomOwner(),
+		AccountID: account.ID,
+	}
+
+	beneficiary, err := store.CreateBeneficiary(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, beneficiary.ID)
+	require.Equal(t, arg.Owner, beneficiary.Owner)
+	require.Equal(t, arg.Nickname, beneficiary.Nickname)
+	require.Equal(t, arg.AccountID, beneficiary.AccountID)
+	require.False(t, beneficiary.IsVerified)
+	require.True(t, beneficiary.VerifiedAt.IsZero())
+	require.NotZero(t, beneficiary.CreatedAt)
+
+	return beneficiary
+}
+
+func testCreateBeneficiary(t *testing.T, store Store) {
+	createRandomBeneficiary(t, store, createRandomUser(t, store))
+}
+
+func testCreateDuplicateBeneficiary(t *testing.T, store Store) {
+	beneficiary := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	_, err := store.CreateBeneficiary(context.Background(), CreateBeneficiaryParams{
+		Owner: beneficiary.Owner,
+		Nickname: util.RandomOwner(),
+		AccountID: beneficiary.AccountID,
+	})
+	require.Error(t, err)
+}
+
+func testGetBeneficiary(t *testing.T, store Store) {
+	beneficiary1 := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	beneficiary2, err := store.GetBeneficiary(context.Background(), beneficiary1.ID)
+	require.NoError(t, err)
+	require.Equal(t, beneficiary1, beneficiary2)
+}
+
+func testGetBeneficiaryByOwnerAndAccount(t *testing.T, store Store) {
+	beneficiary1 := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	beneficiary2, err := store.GetBeneficiaryByOwnerAndAccount(context.Background(), GetBeneficiaryByOwnerAndAccountParams{
+		Owner: beneficiary1.Owner,
+		AccountID: beneficiary1.AccountID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, beneficiary1, beneficiary2)
+
+	_, err = store.GetBeneficiaryByOwnerAndAccount(context.Background(), GetBeneficiaryByOwnerAndAccountParams{
+		Owner: util.RandomOwner(),
+		AccountID: beneficiary1.AccountID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testListBeneficiaries(t *testing.T, store Store) {
+	owner := createRandomUser(t, store)
+	for i := 0; i < 4; i++ {
+		createRandomBeneficiary(t, store, owner)
+	}
+	createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	beneficiaries, err := store.ListBeneficiaries(context.Background(), ListBeneficiariesParams{
+		Owner: owner.Username,
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, beneficiaries, 4)
+
+	for _, beneficiary := range beneficiaries {
+		require.Equal(t, owner.Username, beneficiary.Owner)
+	}
+}
+
+func testUpdateBeneficiaryNickname(t *testing.T, store Store) {
+	beneficiary1 := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	nickname := util.RandomOwner()
+	beneficiary2, err := store.UpdateBeneficiaryNickname(context.Background(), UpdateBeneficiaryNicknameParams{
+		ID: beneficiary1.ID,
+		Nickname: nickname,
+	})
+	require.NoError(t, err)
+	require.Equal(t, nickname, beneficiary2.Nickname)
+	require.Equal(t, beneficiary1.AccountID, beneficiary2.AccountID)
+}
+
+func testVerifyBeneficiary(t *testing.T, store Store) {
+	beneficiary1 := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	beneficiary2, err := store.VerifyBeneficiary(context.Background(), beneficiary1.ID)
+	require.NoError(t, err)
+	require.True(t, beneficiary2.IsVerified)
+	require.WithinDuration(t, time.Now(), beneficiary2.VerifiedAt, time.Second)
+
+	// verifying again does not restart the cooling-off period
+	beneficiary3, err := store.VerifyBeneficiary(context.Background(), beneficiary1.ID)
+	require.NoError(t, err)
+	require.Equal(t, beneficiary2.VerifiedAt, beneficiary3.VerifiedAt)
+}
+
+func testDeleteBeneficiary(t *testing.T, store Store) {
+	beneficiary := createRandomBeneficiary(t, store, createRandomUser(t, store))
+
+	err := store.DeleteBeneficiary(context.Background(), beneficiary.ID)
+	require.NoError(t, err)
+
+	_, err = store.GetBeneficiary(context.Background(), beneficiary.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// The stores on engines other than Postgres emulate its semantics with these helpers,
+// so callers can handle their errors the same way.
+
+// currentTimestamp returns the current time with the precision of a timestamptz
+func currentTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// dateOf truncates t to a date column
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func uniqueViolation(table string, constraint string) error {
+	return &pq.Error{
+		Code: "23505",
+		Message: fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Table: table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return &pq.Error{
+		Code: "23503",
+		Message: fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table: table,
+		Constraint: constraint,
+	}
+}
+
+// referenceViolation is the foreign key violation of deleting a row still referenced from table
+func referenceViolation(table string, constraint string, referenced string) error {
+	return &pq.Error{
+		Code: "23503",
+		Message: fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q", referenced, constraint, table),
+		Table: referenced,
+		Constraint: constraint,
+	}
+}
+
+func checkViolation(table string, constraint string) error {
+	return &pq.Error{
+		Code: "23514",
+		Message: fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		Table: table,
+		Constraint: constraint,
+	}
+}
+
+// parseNumeric parses value as an unconstrained numeric
+func parseNumeric(value string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Decimal{}, &pq.Error{
+			Code: "22P02",
+			Message: fmt.Sprintf("invalid input syntax for type numeric: %q", value),
+		}
+	}
+	return d, nil
+}
+
+// checkJSON fails as Postgres does when value is not valid JSON for a jsonb column
+func checkJSON(value json.RawMessage) error {
+	if !json.Valid(value) {
+		return &pq.Error{Code: "22P02", Message: "invalid input syntax for type json"}
+	}
+	return nil
+}
+
+// numeric parses value into a numeric(precision, scale) column
+func numeric(value string, precision int32, scale int32) (decimal.Decimal, error) {
+	d, err := parseNumeric(value)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	d = d.Round(scale)
+	if d.Abs().Cmp(decimal.New(1, precision-scale)) >= 0 {
+		return decimal.Decimal{}, &pq.Error{Code: "22003", Message: "numeric field overflow"}
+	}
+	return d, nil
+}
+
+// checkPage fails as Postgres does on a negative LIMIT or OFFSET, which other engines ignore
+func checkPage(limit int32, offset int32) error {
+	if limit < 0 {
+		return &pq.Error{Code: "2201W", Message: "LIMIT must not be negative"}
+	}
+	if offset < 0 {
+		return &pq.Error{Code: "2201X", Message: "OFFSET must not be negative"}
+	}
+	return nil
+}
+
+// page applies LIMIT and OFFSET to rows
+func page[T any](rows []T, limit int32, offset int32) ([]T, error) {
+	if err := checkPage(limit, offset); err != nil {
+		return nil, err
+	}
+	if int(offset) >= len(rows) {
+		return []T{}, nil
+	}
+	rows = rows[offset:]
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// refilledTokens returns the tokens of bucket at now, refilled at rate tokens per second up to burst
+func refilledTokens(bucket RateLimitBucket, now time.Time, rate float64, burst float64) float64 {
+	return math.Min(burst, bucket.Tokens+rate*now.Sub(bucket.UpdatedAt).Seconds())
+}
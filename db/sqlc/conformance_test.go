@@ -0,0 +1,99 @@
+package db
+
+import (
+	"testing"
+)
+
+// storeTests run against every backend, holding the memory store to the semantics of Postgres
+var storeTests = []struct {
+	name string
+	test func(t *testing.T, store Store)
+}{
+	{"FreezeAccountTx", testFreezeAccountTx},
+	{"CloseAccountTx", testCloseAccountTx},
+	{"CloseAccountTxConcurrentTransfers", testCloseAccountTxConcurrentTransfers},
+	{"UpdateAccountStatusTxVersion", testUpdateAccountStatusTxVersion},
+	{"CreateAccount", testCreateAccount},
+	{"GetAccount", testGetAccount},
+	{"GetAccountByNumber", testGetAccountByNumber},
+	{"UpdateAccount", testUpdateAccount},
+	{"AccountVersion", testAccountVersion},
+	{"CreateAccountConstraints", testCreateAccountConstraints},
+	{"DeleteAccount", testDeleteAccount},
+	{"DeleteReferencedAccount", testDeleteReferencedAccount},
+	{"ListAccounts", testListAccounts},
+	{"ListAccountsByOwner", testListAccountsByOwner},
+	{"CreateBeneficiary", testCreateBeneficiary},
+	{"CreateDuplicateBeneficiary", testCreateDuplicateBeneficiary},
+	{"GetBeneficiary", testGetBeneficiary},
+	{"GetBeneficiaryByOwnerAndAccount", testGetBeneficiaryByOwnerAndAccount},
+	{"ListBeneficiaries", testListBeneficiaries},
+	{"UpdateBeneficiaryNickname", testUpdateBeneficiaryNickname},
+	{"VerifyBeneficiary", testVerifyBeneficiary},
+	{"DeleteBeneficiary", testDeleteBeneficiary},
+	{"CreateEntry", testCreateEntry},
+	{"GetEntry", testGetEntry},
+	{"ListEntries", testListEntries},
+	{"ListEntriesForAccount", testListEntriesForAccount},
+	{"ListUnbalancedAccounts", testListUnbalancedAccounts},
+	{"CreateInterestRate", testCreateInterestRate},
+	{"AccrueAndPostInterestTx", testAccrueAndPostInterestTx},
+	{"PostInterestTxOncePerPeriod", testPostInterestTxOncePerPeriod},
+	{"GetLastInterestAccrual", testGetLastInterestAccrual},
+	{"CreateUserTx", testCreateUserTx},
+	{"CreateAccountTx", testCreateAccountTx},
+	{"CreateAccountTxAccountNumberTaken", testCreateAccountTxAccountNumberTaken},
+	{"TransferTxOutbox", testTransferTxOutbox},
+	{"RelayOutboxTx", testRelayOutboxTx},
+	{"RelayOutboxTxLease", testRelayOutboxTxLease},
+	{"CreateOutboxEventInvalidPayload", testCreateOutboxEventInvalidPayload},
+	{"TakeRateLimitToken", testTakeRateLimitToken},
+	{"DeleteIdleRateLimitBuckets", testDeleteIdleRateLimitBuckets},
+	{"ReverseTransferTx", testReverseTransferTx},
+	{"ReverseTransferTxInsufficientFunds", testReverseTransferTxInsufficientFunds},
+	{"TransferTx", testTransferTx},
+	{"TransferTxDeadlock", testTransferTxDeadlock},
+	{"TransferTxInsufficientFunds", testTransferTxInsufficientFunds},
+	{"CreateTransfer", testCreateTransfer},
+	{"GetTransfer", testGetTransfer},
+	{"ListTransfers", testListTransfers},
+	{"ListTransfersFromAccount", testListTransfersFromAccount},
+	{"ListTransfersToAccount", testListTransfersToAccount},
+	{"ListTransfersBetweenAccounts", testListTransfersBetweenAccounts},
+	{"CreateUser", testCreateUser},
+	{"GetUser", testGetUser},
+	{"DisableUser", testDisableUser},
+	{"GetUserByEmail", testGetUserByEmail},
+	{"UpdateUserPassword", testUpdateUserPassword},
+	{"CreateUserTxVerifyEmail", testCreateUserTxVerifyEmail},
+	{"VerifyEmailTx", testVerifyEmailTx},
+	{"VerifyEmailTxExpired", testVerifyEmailTxExpired},
+	{"SendVerifyEmailsTx", testSendVerifyEmailsTx},
+	{"SendVerifyEmailsTxLease", testSendVerifyEmailsTxLease},
+	{"CreatePasswordResetTx", testCreatePasswordResetTx},
+	{"ResetPasswordTx", testResetPasswordTx},
+	{"ResetPasswordTxExpired", testResetPasswordTxExpired},
+	{"CreateRecoveryCode", testCreateRecoveryCode},
+	{"ConfirmTOTPTx", testConfirmTOTPTx},
+	{"VerifyTOTPTx", testVerifyTOTPTx},
+	{"VerifyTOTPTxRecoveryCode", testVerifyTOTPTxRecoveryCode},
+	{"CreateTransferTxStepUp", testCreateTransferTxStepUp},
+	{"CreateWebhook", testCreateWebhook},
+	{"ListWebhooksForEvent", testListWebhooksForEvent},
+	{"DeleteWebhook", testDeleteWebhook},
+	{"CreateWebhookDelivery", testCreateWebhookDelivery},
+	{"WebhookDeliveryAttempts", testWebhookDeliveryAttempts},
+	{"TransferTxWebhookDeliveries", testTransferTxWebhookDeliveries},
+}
+
+func TestStoreConformance(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, tc := range storeTests {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.test(t, backend.store)
+				})
+			}
+		})
+	}
+}
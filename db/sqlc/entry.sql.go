@@ -128,3 +128,48 @@ func (q *Queries) ListEntriesForAccount(ctx context.Context, arg ListEntriesForA
 	}
 	return items, nil
 }
+
+const listUnbalancedAccounts = `-- name: ListUnbalancedAccounts :many
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance != COALESCE(SUM(e.amount), 0)
+ORDER BY a.id
+`
+
+type ListUnbalancedAccountsRow struct {
+	ID           int64  `json:"id"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+}
+
+// Accounts whose balance differs from the sum of their entries
+func (q *Queries) ListUnbalancedAccounts(ctx context.Context) ([]ListUnbalancedAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnbalancedAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnbalancedAccountsRow{}
+	for rows.Next() {
+		var i ListUnbalancedAccountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -8,18 +8,18 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomEntry(t *testing.T) Entry {
-	account := createRandomAccount(t)
-	return createRandomEntryForAccount(t, account)
+func createRandomEntry(t *testing.T, store Store) Entry {
+	account := createRandomAccount(t, store)
+	return createRandomEntryForAccount(t, store, account)
 }
 
-func createRandomEntryForAccount(t *testing.T, account Account) Entry {
+func createRandomEntryForAccount(t *testing.T, store Store, account Account) Entry {
 	arg := CreateEntryParams{
 		AccountID: account.ID,
 		Amount: util.RandomMoney(),
 	}
 
-	entry, err := testQueries.CreateEntry(context.Background(), arg)
+	entry, err := store.CreateEntry(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
 
@@ -32,14 +32,14 @@ func createRandomEntryForAccount(t *testing.T, account Account) Entry {
 	return entry
 }
 
-func TestCreateEntry(t *testing.T) {
-	createRandomEntry(t)
+func testCreateEntry(t *testing.T, store Store) {
+	createRandomEntry(t, store)
 }
 
-func TestGetEntry(t *testing.T) {
-	entry1 := createRandomEntry(t)
+func testGetEntry(t *testing.T, store Store) {
+	entry1 := createRandomEntry(t, store)
 
-	entry2, err := testQueries.GetEntry(context.Background(), entry1.ID)
+	entry2, err := store.GetEntry(context.Background(), entry1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry2)
 
@@ -49,9 +49,9 @@ func TestGetEntry(t *testing.T) {
 	require.Equal(t, entry2.CreatedAt, entry1.CreatedAt)
 }
 
-func TestListEntries(t *testing.T) {
+func testListEntries(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t)
+		createRandomEntry(t, store)
 	}
 
 	arg := ListEntriesParams{
@@ -59,7 +59,7 @@ func TestListEntries(t *testing.T) {
 		Offset: 5,
 	}
 
-	entries, err := testQueries.ListEntries(context.Background(), arg)
+	entries, err := store.ListEntries(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
@@ -68,14 +68,14 @@ func TestListEntries(t *testing.T) {
 	}
 }
 
-func TestListEntriesForAccount(t *testing.T) {
+func testListEntriesForAccount(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t)
+		createRandomEntry(t, store)
 	}
 
-	account := createRandomAccount(t)
+	account := createRandomAccount(t, store)
 	for i := 0; i < 10; i++ {
-		createRandomEntryForAccount(t, account)
+		createRandomEntryForAccount(t, store, account)
 	}
 
 	arg := ListEntriesForAccountParams{
@@ -84,7 +84,7 @@ func TestListEntriesForAccount(t *testing.T) {
 		Offset: 5,
 	}
 
-	entries, err := testQueries.ListEntriesForAccount(context.Background(), arg)
+	entries, err := store.ListEntriesForAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
@@ -93,3 +93,17 @@ func TestListEntriesForAccount(t *testing.T) {
 		require.Equal(t, entry.AccountID, account.ID)
 	}
 }
+
+func testListUnbalancedAccounts(t *testing.T, store Store) {
+	// the account is created with a balance but no entry backing it
+	account := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	rows, err := store.ListUnbalancedAccounts(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, rows, ListUnbalancedAccountsRow{
+		ID: account.ID,
+		Currency: util.EUR,
+		Balance: 100,
+		EntriesTotal: 0,
+	})
+}
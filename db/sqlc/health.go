@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+)
+
+// SchemaVersion is the last migration applied to the database, as recorded by migrate.
+// A dirty version failed halfway and must be fixed by hand before migrating again.
+type SchemaVersion struct {
+	Version int64 `json:"version"`
+	Dirty bool `json:"dirty"`
+}
+
+const getSchemaVersion = `-- name: GetSchemaVersion :one
+SELECT version, dirty FROM schema_migrations
+LIMIT 1
+`
+
+// GetSchemaVersion reads the migration version of the database.
+// It returns sql.ErrNoRows if no migration was ever applied.
+func (store *SQLStore) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	row := store.Queries.db.QueryRowContext(ctx, getSchemaVersion)
+	var i SchemaVersion
+	err := row.Scan(&i.Version, &i.Dirty)
+	return i, err
+}
+
+// Ping checks the database can be reached
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings = "savings"
+)
+
+var AccountTypes = []string{AccountTypeChecking, AccountTypeSavings}
+
+var (
+	ErrInterestAlreadyAccrued = errors.New("interest already accrued for this date")
+	ErrInterestAlreadyPosted = errors.New("interest already posted for this period")
+	ErrNoInterestToPost = errors.New("less than a cent of interest accrued")
+)
+
+type AccrueInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	Balance int64 `json:"balance"`
+	AnnualRate string `json:"annual_rate"`
+	Amount string `json:"amount"`
+}
+
+type AccrueInterestTxResult struct {
+	Accrual InterestAccrual `json:"accrual"`
+	Account Account `json:"account"`
+}
+
+// Accruing interest records the daily accrual and adds it to the account accrued interest within a transaction.
+// Accruing twice for the same account and date fails with ErrInterestAlreadyAccrued.
+func (store *txStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error) {
+	var result AccrueInterestTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		var err error
+
+		result.Accrual, err = q.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+			AccountID: arg.AccountID,
+			AccrualDate: arg.AccrualDate,
+			Balance: arg.Balance,
+			AnnualRate: arg.AnnualRate,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInterestAlreadyAccrued
+			}
+			return err
+		}
+
+		result.Account, err = q.AddAccountAccruedInterest(ctx, AddAccountAccruedInterestParams{
+			ID: arg.AccountID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Bank account paying the interest, in the same currency
+	ExpenseAccountID int64 `json:"expense_account_id"`
+	// Month the interest is posted for, only its year and month are kept
+	Period time.Time `json:"period"`
+}
+
+type PostInterestTxResult struct {
+	Transfer CreateTransferTxResult `json:"transfer"`
+	Posting InterestPosting `json:"posting"`
+	Account Account `json:"account"`
+}
+
+// Posting interest moves the whole cents accrued from the expense account and deducts them from the accrued interest.
+// The amount is read from the account while it is locked, and fractional cents stay accrued for the next posting.
+// Posting twice for the same account and period fails with ErrInterestAlreadyPosted,
+// and an account with less than a cent accrued fails with ErrNoInterestToPost.
+func (store *txStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		account, err := lockAccountsInOrder(ctx, q, arg.AccountID, arg.ExpenseAccountID)
+		if err != nil {
+			return err
+		}
+
+		accrued, err := decimal.NewFromString(account.AccruedInterest)
+		if err != nil {
+			return err
+		}
+		cents := accrued.Floor().IntPart()
+		if cents <= 0 {
+			return ErrNoInterestToPost
+		}
+
+		result.Transfer, err = transferMoney(ctx, q, CreateTransferTxParams{
+			FromAccountID: arg.ExpenseAccountID,
+			ToAccountID: arg.AccountID,
+			Amount: cents,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordTransferCreated(ctx, q, result.Transfer.Transfer); err != nil {
+			return err
+		}
+
+		result.Posting, err = q.CreateInterestPosting(ctx, CreateInterestPostingParams{
+			AccountID: arg.AccountID,
+			Period: InterestPeriod(arg.Period),
+			Amount: cents,
+			TransferID: result.Transfer.Transfer.ID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInterestAlreadyPosted
+			}
+			return err
+		}
+
+		result.Account, err = q.AddAccountAccruedInterest(ctx, AddAccountAccruedInterestParams{
+			ID: arg.AccountID,
+			Amount: fmt.Sprint(-cents),
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// InterestPeriod returns the first day of the month of t, the period interest is posted for.
+func InterestPeriod(t time.Time) time.Time {
+	year, month, _ := t.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// lockAccountsInOrder locks both accounts in id order, as transfers do, and returns the first one.
+func lockAccountsInOrder(ctx context.Context, q Querier, accountID int64, otherID int64) (Account, error) {
+	if otherID < accountID {
+		if _, err := q.GetAccountForUpdate(ctx, otherID); err != nil {
+			return Account{}, err
+		}
+	}
+	account, err := q.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		return Account{}, err
+	}
+	if otherID > accountID {
+		if _, err := q.GetAccountForUpdate(ctx, otherID); err != nil {
+			return Account{}, err
+		}
+	}
+	return account, nil
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateInterestRate(t *testing.T, store Store) {
+	effectiveFrom := time.Date(1900+int(util.RandomInt(0, 1000)), time.January, 1, 0, 0, 0, 0, time.UTC)
+	rate, err := store.CreateInterestRate(context.Background(), CreateInterestRateParams{
+		AccountType: AccountTypeSavings,
+		AnnualRate: "0.0125",
+		EffectiveFrom: effectiveFrom,
+	})
+	if err != nil {
+		// the random effective date may already be taken by a previous run
+		t.Skip(err)
+	}
+	require.Equal(t, AccountTypeSavings, rate.AccountType)
+	require.Equal(t, effectiveFrom, rate.EffectiveFrom.UTC())
+
+	rates, err := store.ListInterestRates(context.Background(), AccountTypeSavings)
+	require.NoError(t, err)
+	require.NotEmpty(t, rates)
+}
+
+func testAccrueAndPostInterestTx(t *testing.T, store Store) {
+	currency := util.RandomCurrency()
+	expense := createRandomAccountWithCurrency(t, store, currency, 0)
+	savings := createRandomAccountWithCurrency(t, store, currency, 100000)
+	day := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	balance, err := store.GetAccountEndOfDayBalance(context.Background(), GetAccountEndOfDayBalanceParams{
+		ID: savings.ID,
+		EndOfDay: time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, savings.Balance, balance)
+
+	for _, amount := range []string{"150.7500000000", "150.7500000000"} {
+		_, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+			AccountID: savings.ID,
+			AccrualDate: day,
+			Balance: savings.Balance,
+			AnnualRate: "0.0366",
+			Amount: amount,
+		})
+		day = day.AddDate(0, 0, 1)
+		require.NoError(t, err)
+	}
+
+	_, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+		AccountID: savings.ID,
+		AccrualDate: day.AddDate(0, 0, -1),
+		Balance: savings.Balance,
+		AnnualRate: "0.0366",
+		Amount: "1",
+	})
+	require.ErrorIs(t, err, ErrInterestAlreadyAccrued)
+
+	accrued, err := store.GetAccount(context.Background(), savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, "301.5000000000", accrued.AccruedInterest)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: savings.ID,
+		ExpenseAccountID: expense.ID,
+		Period: day,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.5000000000", result.Account.AccruedInterest)
+	require.Equal(t, savings.Balance+301, result.Account.Balance)
+	require.Equal(t, int64(-301), result.Transfer.FromAccount.Balance)
+	require.Equal(t, int64(301), result.Posting.Amount)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), result.Posting.Period.UTC())
+	require.Equal(t, result.Transfer.Transfer.ID, result.Posting.TransferID)
+
+	_, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: savings.ID,
+		ExpenseAccountID: expense.ID,
+		Period: day.AddDate(0, 1, 0),
+	})
+	require.ErrorIs(t, err, ErrNoInterestToPost)
+
+	accruals, err := store.ListInterestAccruals(context.Background(), ListInterestAccrualsParams{
+		AccountID: savings.ID,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, accruals, 2)
+}
+
+func testGetLastInterestAccrual(t *testing.T, store Store) {
+	savings := createRandomAccountWithCurrency(t, store, util.RandomCurrency(), 100000)
+
+	_, err := store.GetLastInterestAccrual(context.Background(), savings.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, accrualDate := range []time.Time{day, day.AddDate(0, 0, 2), day.AddDate(0, 0, 1)} {
+		_, err = store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+			AccountID: savings.ID,
+			AccrualDate: accrualDate,
+			Balance: savings.Balance,
+			AnnualRate: "0.0366",
+			Amount: "0.0000000000",
+		})
+		require.NoError(t, err)
+	}
+
+	accrual, err := store.GetLastInterestAccrual(context.Background(), savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.ID, accrual.AccountID)
+	require.Equal(t, day.AddDate(0, 0, 2), accrual.AccrualDate.UTC())
+}
+
+func testPostInterestTxOncePerPeriod(t *testing.T, store Store) {
+	currency := util.RandomCurrency()
+	expense := createRandomAccountWithCurrency(t, store, currency, 0)
+	savings := createRandomAccountWithCurrency(t, store, currency, 100000)
+	day := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	accrue := func() {
+		_, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{
+			AccountID: savings.ID,
+			AccrualDate: day,
+			Balance: savings.Balance,
+			AnnualRate: "0.0366",
+			Amount: "10.2500000000",
+		})
+		require.NoError(t, err)
+		day = day.AddDate(0, 0, 1)
+	}
+	post := func(period time.Time) (PostInterestTxResult, error) {
+		return store.PostInterestTx(context.Background(), PostInterestTxParams{
+			AccountID: savings.ID,
+			ExpenseAccountID: expense.ID,
+			Period: period,
+		})
+	}
+
+	accrue()
+	january := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+	result, err := post(january)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Posting.Amount)
+
+	// posting the same month again, e.g. from another replica, changes nothing
+	accrue()
+	_, err = post(january)
+	require.ErrorIs(t, err, ErrInterestAlreadyPosted)
+
+	account, err := store.GetAccount(context.Background(), savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.Balance+10, account.Balance)
+	require.Equal(t, "10.5000000000", account.AccruedInterest)
+
+	// the amount is the one accrued when posting, including the fractions left over
+	result, err = post(january.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Posting.Amount)
+	require.Equal(t, savings.Balance+20, result.Account.Balance)
+	require.Equal(t, "0.5000000000", result.Account.AccruedInterest)
+}
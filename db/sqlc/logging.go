@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gorkaio/simplebank/logger"
+)
+
+// loggingDBTX logs every query at debug level, along with the request ID carried by its context.
+// Only the query name is logged, never its arguments.
+type loggingDBTX struct {
+	DBTX
+}
+
+func (l loggingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := l.DBTX.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return result, err
+}
+
+func (l loggingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	start := time.Now()
+	stmt, err := l.DBTX.PrepareContext(ctx, query)
+	logQuery(ctx, query, start, err)
+	return stmt, err
+}
+
+func (l loggingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := l.DBTX.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return rows, err
+}
+
+func (l loggingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := l.DBTX.QueryRowContext(ctx, query, args...)
+	logQuery(ctx, query, start, row.Err())
+	return row
+}
+
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	log := logger.FromContext(ctx)
+	if !log.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []any{"query", queryName(query), "duration", time.Since(start)}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		attrs = append(attrs, "error", err)
+	}
+	log.DebugContext(ctx, "db query", attrs...)
+}
+
+// queryName extracts the name sqlc puts in the first line of every query ("-- name: GetAccount :one")
+func queryName(query string) string {
+	line, _, _ := strings.Cut(query, "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return "unnamed"
+}
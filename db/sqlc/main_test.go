@@ -1,30 +1,93 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gorkaio/simplebank/db/migration"
 	"github.com/gorkaio/simplebank/util"
 	_ "github.com/lib/pq"
 )
 
-var testQueries *Queries
+// testBackend is a store implementation the conformance suite runs against
+type testBackend struct {
+	name string
+	store Store
+	newObservedStore func(observer Observer) Store
+}
+
+var testBackends []testBackend
+
+// testDB is nil when the tests run with -short, which skips Postgres
 var testDB *sql.DB
 
 func TestMain(m *testing.M) {
-	config, err := util.LoadConfig("../..")
+	flag.Parse()
+
+	testBackends = append(testBackends, testBackend{
+		name: "memory",
+		store: NewMemoryStore(),
+		newObservedStore: NewObservedMemoryStore,
+	})
+
+	dir, err := os.MkdirTemp("", "simplebank")
+	if err != nil {
+		log.Fatal("cannot create sqlite dir: ", err)
+	}
+	sqliteDB, err := sql.Open(SQLiteDriver, filepath.Join(dir, "simplebank.db"))
 	if err != nil {
-		log.Fatal("cannot load configuration:", err)
+		log.Fatal("cannot open sqlite db: ", err)
 	}
+	err = migration.Up(context.Background(), sqliteDB, SQLiteDriver)
+	if err != nil {
+		log.Fatal("cannot migrate sqlite db: ", err)
+	}
+	testBackends = append(testBackends, testBackend{
+		name: "sqlite",
+		store: NewSQLiteStore(sqliteDB),
+		newObservedStore: func(observer Observer) Store {
+			return NewObservedSQLiteStore(sqliteDB, observer)
+		},
+	})
+
+	if !testing.Short() {
+		config, err := util.LoadConfig("../..")
+		if err != nil {
+			log.Fatal("cannot load configuration:", err)
+		}
 
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
-	if err!= nil {
-		log.Fatal("Cannot connect to db: ", err)
+		testDB, err = sql.Open(config.DBDriver, config.DBSource)
+		if err!= nil {
+			log.Fatal("Cannot connect to db: ", err)
+		}
+
+		err = migration.Up(context.Background(), testDB, config.DBDriver)
+		if err != nil {
+			log.Fatal("cannot migrate db: ", err)
+		}
+
+		testBackends = append(testBackends, testBackend{
+			name: "postgres",
+			store: NewStore(testDB),
+			newObservedStore: func(observer Observer) Store {
+				return NewObservedStore(testDB, observer)
+			},
+		})
 	}
 
-	testQueries = New(testDB)
+	code := m.Run()
+	sqliteDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
 
-	os.Exit(m.Run())
-}
\ No newline at end of file
+func requirePostgres(t *testing.T) {
+	if testDB == nil {
+		t.Skip("postgres is skipped in short mode")
+	}
+}
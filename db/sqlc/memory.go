@@ -0,0 +1,1418 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorkaio/simplebank/db/migration"
+	"github.com/shopspring/decimal"
+)
+
+// MemoryStore is a Store keeping its data in memory, for tests and demos.
+// Its queries follow the semantics of the SQL ones: constraint violations are reported as *pq.Error
+// with the Postgres code and constraint name, and missing rows as sql.ErrNoRows.
+// Transactions are serialized, they work on a copy of the data which replaces it on commit.
+type MemoryStore struct {
+	*memoryQueries
+	txStore
+	mu sync.Mutex
+	data *memoryData
+	// sequences are not rolled back with transactions, as in Postgres
+	sequences map[string]int64
+}
+
+func NewMemoryStore() Store {
+	return NewObservedMemoryStore(nopObserver{})
+}
+
+// NewObservedMemoryStore creates a memory store reporting its transactions to observer
+func NewObservedMemoryStore(observer Observer) Store {
+	store := &MemoryStore{
+		data: newMemoryData(),
+		sequences: map[string]int64{},
+	}
+	store.memoryQueries = &memoryQueries{store: store}
+	store.txStore = txStore{execTx: store.execTx, observer: observer}
+	return store
+}
+
+// execTx runs fn on a copy of the data, which replaces the store data if fn succeeds
+func (store *MemoryStore) execTx(ctx context.Context, fn func(Querier) error) (err error) {
+	start := time.Now()
+	defer func() {
+		store.observer.ObserveTx(time.Since(start), err == nil)
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data := store.data.clone()
+	err = fn(&memoryQueries{store: store, data: data})
+	if err != nil {
+		return err
+	}
+
+	store.data = data
+	return nil
+}
+
+// GetSchemaVersion reports the latest migration, the memory store always has the current schema
+func (store *MemoryStore) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	return SchemaVersion{Version: migration.LatestVersion()}, nil
+}
+
+func (store *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// memoryData holds the rows of every table, indexed by primary key
+type memoryData struct {
+	users map[string]User
+	accounts map[int64]Account
+	entries map[int64]Entry
+	transfers map[int64]Transfer
+	beneficiaries map[int64]Beneficiary
+	interestRates map[int64]InterestRate
+	interestAccruals map[int64]InterestAccrual
+	interestPostings map[int64]InterestPosting
+	rateLimitBuckets map[string]RateLimitBucket
+	outboxEvents map[int64]OutboxEvent
+	webhooks map[int64]Webhook
+	webhookDeliveries map[int64]WebhookDelivery
+	verifyEmails map[int64]VerifyEmail
+	passwordResets map[int64]PasswordReset
+	recoveryCodes map[int64]RecoveryCode
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		users: map[string]User{},
+		accounts: map[int64]Account{},
+		entries: map[int64]Entry{},
+		transfers: map[int64]Transfer{},
+		beneficiaries: map[int64]Beneficiary{},
+		interestRates: map[int64]InterestRate{},
+		interestAccruals: map[int64]InterestAccrual{},
+		interestPostings: map[int64]InterestPosting{},
+		rateLimitBuckets: map[string]RateLimitBucket{},
+		outboxEvents: map[int64]OutboxEvent{},
+		webhooks: map[int64]Webhook{},
+		webhookDeliveries: map[int64]WebhookDelivery{},
+		verifyEmails: map[int64]VerifyEmail{},
+		passwordResets: map[int64]PasswordReset{},
+		recoveryCodes: map[int64]RecoveryCode{},
+	}
+}
+
+// clone copies the tables, rows are values so they are copied too.
+// Their slices are never modified in place, only replaced, so they can be shared.
+func (data *memoryData) clone() *memoryData {
+	return &memoryData{
+		users: maps.Clone(data.users),
+		accounts: maps.Clone(data.accounts),
+		entries: maps.Clone(data.entries),
+		transfers: maps.Clone(data.transfers),
+		beneficiaries: maps.Clone(data.beneficiaries),
+		interestRates: maps.Clone(data.interestRates),
+		interestAccruals: maps.Clone(data.interestAccruals),
+		interestPostings: maps.Clone(data.interestPostings),
+		rateLimitBuckets: maps.Clone(data.rateLimitBuckets),
+		outboxEvents: maps.Clone(data.outboxEvents),
+		webhooks: maps.Clone(data.webhooks),
+		webhookDeliveries: maps.Clone(data.webhookDeliveries),
+		verifyEmails: maps.Clone(data.verifyEmails),
+		passwordResets: maps.Clone(data.passwordResets),
+		recoveryCodes: maps.Clone(data.recoveryCodes),
+	}
+}
+
+// memoryQueries implements Querier on the store data, or on the copy of a transaction when data is set
+type memoryQueries struct {
+	store *MemoryStore
+	data *memoryData
+}
+
+var _ Querier = (*memoryQueries)(nil)
+
+// begin returns the data to query and the function releasing it.
+// Transactions hold the store lock already, other queries take it for their duration.
+func (q *memoryQueries) begin() (*memoryData, func()) {
+	if q.data != nil {
+		return q.data, func() {}
+	}
+	q.store.mu.Lock()
+	return q.store.data, q.store.mu.Unlock
+}
+
+// nextID returns the next value of the table sequence, it must be called while holding the data
+func (q *memoryQueries) nextID(table string) int64 {
+	q.store.sequences[table]++
+	return q.store.sequences[table]
+}
+
+// selectRows returns the rows of table matching where, sorted by compare
+func selectRows[K comparable, T any](table map[K]T, where func(T) bool, compare func(a, b T) int) []T {
+	rows := []T{}
+	for _, row := range table {
+		if where(row) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, compare)
+	return rows
+}
+
+func byAccountID(a, b Account) int { return cmp.Compare(a.ID, b.ID) }
+func byEntryID(a, b Entry) int { return cmp.Compare(a.ID, b.ID) }
+func byTransferID(a, b Transfer) int { return cmp.Compare(a.ID, b.ID) }
+func byBeneficiaryID(a, b Beneficiary) int { return cmp.Compare(a.ID, b.ID) }
+func byOutboxEventID(a, b OutboxEvent) int { return cmp.Compare(a.ID, b.ID) }
+func byWebhookID(a, b Webhook) int { return cmp.Compare(a.ID, b.ID) }
+func byWebhookDeliveryID(a, b WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) }
+func byVerifyEmailID(a, b VerifyEmail) int { return cmp.Compare(a.ID, b.ID) }
+
+func (q *memoryQueries) AddAccountAccruedInterest(ctx context.Context, arg AddAccountAccruedInterestParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	amount, err := parseNumeric(arg.Amount)
+	if err != nil {
+		return Account{}, err
+	}
+	account, ok := data.accounts[arg.ID]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	accrued, err := numeric(decimal.RequireFromString(account.AccruedInterest).Add(amount).String(), 24, 10)
+	if err != nil {
+		return Account{}, err
+	}
+	account.AccruedInterest = accrued.StringFixed(10)
+	account.Version++
+	data.accounts[account.ID] = account
+	return account, nil
+}
+
+func (q *memoryQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account, ok := data.accounts[arg.ID]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+	account.Balance += arg.Amount
+	account.Version++
+	data.accounts[account.ID] = account
+	return account, nil
+}
+
+func (q *memoryQueries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	unpublished := selectRows(data.outboxEvents, func(event OutboxEvent) bool {
+		return event.PublishedAt.IsZero()
+	}, byOutboxEventID)
+	events, err := page(unpublished, arg.Limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range unpublished {
+		if event.LeasedUntil.After(now) {
+			return []OutboxEvent{}, nil
+		}
+	}
+
+	for i := range events {
+		events[i].LeasedUntil = now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+		data.outboxEvents[events[i].ID] = events[i]
+	}
+	return events, nil
+}
+
+func (q *memoryQueries) ClaimVerifyEmails(ctx context.Context, arg ClaimVerifyEmailsParams) ([]VerifyEmail, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	verifyEmails := selectRows(data.verifyEmails, func(verifyEmail VerifyEmail) bool {
+		return verifyEmail.SentAt.IsZero() && verifyEmail.ExpiredAt.After(now) && !verifyEmail.LeasedUntil.After(now)
+	}, byVerifyEmailID)
+	verifyEmails, err := page(verifyEmails, arg.Limit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range verifyEmails {
+		verifyEmails[i].LeasedUntil = now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+		data.verifyEmails[verifyEmails[i].ID] = verifyEmails[i]
+	}
+	return verifyEmails, nil
+}
+
+func (q *memoryQueries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	deliveries := selectRows(data.webhookDeliveries, func(delivery WebhookDelivery) bool {
+		return delivery.Status == WebhookDeliveryPending && !delivery.NextAttemptAt.After(now)
+	}, func(a, b WebhookDelivery) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	deliveries, err := page(deliveries, arg.Limit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+		data.webhookDeliveries[deliveries[i].ID] = deliveries[i]
+	}
+	return deliveries, nil
+}
+
+func (q *memoryQueries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account := Account{
+		ID: q.nextID("accounts"),
+		Owner: arg.Owner,
+		Balance: arg.Balance,
+		Currency: arg.Currency,
+		CreatedAt: currentTimestamp(),
+		Status: AccountStatusActive,
+		AccountType: arg.AccountType,
+		AccruedInterest: decimal.Zero.StringFixed(10),
+		AccountNumber: arg.AccountNumber,
+		Version: 1,
+	}
+	if account.AccountType != AccountTypeChecking && account.AccountType != AccountTypeSavings {
+		return Account{}, checkViolation("accounts", "account_type_check")
+	}
+	for _, other := range data.accounts {
+		if other.Owner == account.Owner && other.Currency == account.Currency {
+			return Account{}, uniqueViolation("accounts", "owner_currency_key")
+		}
+	}
+	for _, other := range data.accounts {
+		if other.AccountNumber == account.AccountNumber {
+			return Account{}, uniqueViolation("accounts", "account_number_key")
+		}
+	}
+	if _, ok := data.users[account.Owner]; !ok {
+		return Account{}, foreignKeyViolation("accounts", "accounts_owner_fkey")
+	}
+
+	data.accounts[account.ID] = account
+	return account, nil
+}
+
+func (q *memoryQueries) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	beneficiary := Beneficiary{
+		ID: q.nextID("beneficiaries"),
+		Owner: arg.Owner,
+		Nickname: arg.Nickname,
+		AccountID: arg.AccountID,
+		CreatedAt: currentTimestamp(),
+	}
+	for _, other := range data.beneficiaries {
+		if other.Owner == beneficiary.Owner && other.Nickname == beneficiary.Nickname {
+			return Beneficiary{}, uniqueViolation("beneficiaries", "owner_nickname_key")
+		}
+	}
+	for _, other := range data.beneficiaries {
+		if other.Owner == beneficiary.Owner && other.AccountID == beneficiary.AccountID {
+			return Beneficiary{}, uniqueViolation("beneficiaries", "owner_account_key")
+		}
+	}
+	if _, ok := data.users[beneficiary.Owner]; !ok {
+		return Beneficiary{}, foreignKeyViolation("beneficiaries", "beneficiaries_owner_fkey")
+	}
+	if _, ok := data.accounts[beneficiary.AccountID]; !ok {
+		return Beneficiary{}, foreignKeyViolation("beneficiaries", "beneficiaries_account_id_fkey")
+	}
+
+	data.beneficiaries[beneficiary.ID] = beneficiary
+	return beneficiary, nil
+}
+
+func (q *memoryQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	entry := Entry{
+		ID: q.nextID("entries"),
+		AccountID: arg.AccountID,
+		Amount: arg.Amount,
+		CreatedAt: currentTimestamp(),
+	}
+	if _, ok := data.accounts[entry.AccountID]; !ok {
+		return Entry{}, foreignKeyViolation("entries", "entries_account_id_fkey")
+	}
+
+	data.entries[entry.ID] = entry
+	return entry, nil
+}
+
+func (q *memoryQueries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	annualRate, err := numeric(arg.AnnualRate, 10, 8)
+	if err != nil {
+		return InterestAccrual{}, err
+	}
+	amount, err := numeric(arg.Amount, 24, 10)
+	if err != nil {
+		return InterestAccrual{}, err
+	}
+
+	accrual := InterestAccrual{
+		ID: q.nextID("interest_accruals"),
+		AccountID: arg.AccountID,
+		AccrualDate: dateOf(arg.AccrualDate),
+		Balance: arg.Balance,
+		AnnualRate: annualRate.StringFixed(8),
+		Amount: amount.StringFixed(10),
+		CreatedAt: currentTimestamp(),
+	}
+	// ON CONFLICT DO NOTHING returns no row
+	for _, other := range data.interestAccruals {
+		if other.AccountID == accrual.AccountID && other.AccrualDate.Equal(accrual.AccrualDate) {
+			return InterestAccrual{}, sql.ErrNoRows
+		}
+	}
+	if _, ok := data.accounts[accrual.AccountID]; !ok {
+		return InterestAccrual{}, foreignKeyViolation("interest_accruals", "interest_accruals_account_id_fkey")
+	}
+
+	data.interestAccruals[accrual.ID] = accrual
+	return accrual, nil
+}
+
+func (q *memoryQueries) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	posting := InterestPosting{
+		ID: q.nextID("interest_postings"),
+		AccountID: arg.AccountID,
+		Period: dateOf(arg.Period),
+		Amount: arg.Amount,
+		TransferID: arg.TransferID,
+		CreatedAt: currentTimestamp(),
+	}
+	// ON CONFLICT DO NOTHING returns no row
+	for _, other := range data.interestPostings {
+		if other.AccountID == posting.AccountID && other.Period.Equal(posting.Period) {
+			return InterestPosting{}, sql.ErrNoRows
+		}
+	}
+	if _, ok := data.accounts[posting.AccountID]; !ok {
+		return InterestPosting{}, foreignKeyViolation("interest_postings", "interest_postings_account_id_fkey")
+	}
+	if _, ok := data.transfers[posting.TransferID]; !ok {
+		return InterestPosting{}, foreignKeyViolation("interest_postings", "interest_postings_transfer_id_fkey")
+	}
+
+	data.interestPostings[posting.ID] = posting
+	return posting, nil
+}
+
+func (q *memoryQueries) CreateInterestRate(ctx context.Context, arg CreateInterestRateParams) (InterestRate, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	annualRate, err := numeric(arg.AnnualRate, 10, 8)
+	if err != nil {
+		return InterestRate{}, err
+	}
+
+	rate := InterestRate{
+		ID: q.nextID("interest_rates"),
+		AccountType: arg.AccountType,
+		AnnualRate: annualRate.StringFixed(8),
+		EffectiveFrom: dateOf(arg.EffectiveFrom),
+		CreatedAt: currentTimestamp(),
+	}
+	for _, other := range data.interestRates {
+		if other.AccountType == rate.AccountType && other.EffectiveFrom.Equal(rate.EffectiveFrom) {
+			return InterestRate{}, uniqueViolation("interest_rates", "interest_rates_account_type_effective_from_idx")
+		}
+	}
+
+	data.interestRates[rate.ID] = rate
+	return rate, nil
+}
+
+func (q *memoryQueries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if err := checkJSON(arg.Payload); err != nil {
+		return OutboxEvent{}, err
+	}
+	event := OutboxEvent{
+		ID: q.nextID("outbox_events"),
+		AggregateType: arg.AggregateType,
+		AggregateID: arg.AggregateID,
+		EventType: arg.EventType,
+		Payload: slices.Clone(arg.Payload),
+		CreatedAt: currentTimestamp(),
+	}
+	data.outboxEvents[event.ID] = event
+	return event, nil
+}
+
+func (q *memoryQueries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	passwordReset := PasswordReset{
+		ID: q.nextID("password_resets"),
+		Username: arg.Username,
+		TokenHash: arg.TokenHash,
+		CreatedAt: currentTimestamp(),
+		ExpiredAt: arg.ExpiredAt.UTC().Truncate(time.Microsecond),
+	}
+	if _, ok := data.users[passwordReset.Username]; !ok {
+		return PasswordReset{}, foreignKeyViolation("password_resets", "password_resets_username_fkey")
+	}
+	for _, other := range data.passwordResets {
+		if other.TokenHash == passwordReset.TokenHash {
+			return PasswordReset{}, uniqueViolation("password_resets", "password_resets_token_hash_key")
+		}
+	}
+
+	data.passwordResets[passwordReset.ID] = passwordReset
+	return passwordReset, nil
+}
+
+func (q *memoryQueries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	recoveryCode := RecoveryCode{
+		ID: q.nextID("recovery_codes"),
+		Username: arg.Username,
+		CodeHash: arg.CodeHash,
+		CreatedAt: currentTimestamp(),
+	}
+	if _, ok := data.users[recoveryCode.Username]; !ok {
+		return RecoveryCode{}, foreignKeyViolation("recovery_codes", "recovery_codes_username_fkey")
+	}
+	for _, other := range data.recoveryCodes {
+		if other.Username == recoveryCode.Username && other.CodeHash == recoveryCode.CodeHash {
+			return RecoveryCode{}, uniqueViolation("recovery_codes", "recovery_codes_username_code_hash_key")
+		}
+	}
+
+	data.recoveryCodes[recoveryCode.ID] = recoveryCode
+	return recoveryCode, nil
+}
+
+func (q *memoryQueries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	transfer := Transfer{
+		ID: q.nextID("transfers"),
+		FromAccountID: arg.FromAccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount: arg.Amount,
+		CreatedAt: currentTimestamp(),
+	}
+	if _, ok := data.accounts[transfer.FromAccountID]; !ok {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+	}
+	if _, ok := data.accounts[transfer.ToAccountID]; !ok {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+	}
+
+	data.transfers[transfer.ID] = transfer
+	return transfer, nil
+}
+
+func (q *memoryQueries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	user := User{
+		Username: arg.Username,
+		HashedPassword: arg.HashedPassword,
+		FullName: arg.FullName,
+		Email: arg.Email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, ok := data.users[user.Username]; ok {
+		return User{}, uniqueViolation("users", "users_pkey")
+	}
+	for _, other := range data.users {
+		if other.Email == user.Email {
+			return User{}, uniqueViolation("users", "users_email_key")
+		}
+	}
+
+	data.users[user.Username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	verifyEmail := VerifyEmail{
+		ID: q.nextID("verify_emails"),
+		Username: arg.Username,
+		Email: arg.Email,
+		SecretCode: arg.SecretCode,
+		CreatedAt: currentTimestamp(),
+		ExpiredAt: arg.ExpiredAt.UTC().Truncate(time.Microsecond),
+	}
+	if _, ok := data.users[verifyEmail.Username]; !ok {
+		return VerifyEmail{}, foreignKeyViolation("verify_emails", "verify_emails_username_fkey")
+	}
+
+	data.verifyEmails[verifyEmail.ID] = verifyEmail
+	return verifyEmail, nil
+}
+
+func (q *memoryQueries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	webhook := Webhook{
+		ID: q.nextID("webhooks"),
+		AccountID: arg.AccountID,
+		Url: arg.Url,
+		Secret: arg.Secret,
+		EventTypes: slices.Clone(arg.EventTypes),
+		CreatedAt: currentTimestamp(),
+	}
+	if _, ok := data.accounts[webhook.AccountID]; !ok {
+		return Webhook{}, foreignKeyViolation("webhooks", "webhooks_account_id_fkey")
+	}
+
+	data.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (q *memoryQueries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if err := checkJSON(arg.Payload); err != nil {
+		return WebhookDelivery{}, err
+	}
+	now := currentTimestamp()
+	delivery := WebhookDelivery{
+		ID: q.nextID("webhook_deliveries"),
+		WebhookID: arg.WebhookID,
+		EventID: arg.EventID,
+		EventType: arg.EventType,
+		Payload: slices.Clone(arg.Payload),
+		Status: WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt: now,
+	}
+	if _, ok := data.webhooks[delivery.WebhookID]; !ok {
+		return WebhookDelivery{}, foreignKeyViolation("webhook_deliveries", "webhook_deliveries_webhook_id_fkey")
+	}
+
+	data.webhookDeliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+func (q *memoryQueries) DeleteAccount(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if _, ok := data.accounts[id]; !ok {
+		return nil
+	}
+	for _, entry := range data.entries {
+		if entry.AccountID == id {
+			return referenceViolation("entries", "entries_account_id_fkey", "accounts")
+		}
+	}
+	for _, transfer := range data.transfers {
+		if transfer.FromAccountID == id {
+			return referenceViolation("transfers", "transfers_from_account_id_fkey", "accounts")
+		}
+		if transfer.ToAccountID == id {
+			return referenceViolation("transfers", "transfers_to_account_id_fkey", "accounts")
+		}
+	}
+	for _, beneficiary := range data.beneficiaries {
+		if beneficiary.AccountID == id {
+			return referenceViolation("beneficiaries", "beneficiaries_account_id_fkey", "accounts")
+		}
+	}
+	for _, accrual := range data.interestAccruals {
+		if accrual.AccountID == id {
+			return referenceViolation("interest_accruals", "interest_accruals_account_id_fkey", "accounts")
+		}
+	}
+	for _, posting := range data.interestPostings {
+		if posting.AccountID == id {
+			return referenceViolation("interest_postings", "interest_postings_account_id_fkey", "accounts")
+		}
+	}
+	for _, webhook := range data.webhooks {
+		if webhook.AccountID == id {
+			return referenceViolation("webhooks", "webhooks_account_id_fkey", "accounts")
+		}
+	}
+
+	delete(data.accounts, id)
+	return nil
+}
+
+func (q *memoryQueries) DeleteBeneficiary(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	delete(data.beneficiaries, id)
+	return nil
+}
+
+func (q *memoryQueries) DeleteIdleRateLimitBuckets(ctx context.Context, idleSeconds float64) (int64, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	cutoff := currentTimestamp().Add(-time.Duration(idleSeconds * float64(time.Second)))
+	var deleted int64
+	for key, bucket := range data.rateLimitBuckets {
+		if bucket.UpdatedAt.Before(cutoff) {
+			delete(data.rateLimitBuckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteWebhook deletes the deliveries of the webhook too, as their foreign key cascades
+func (q *memoryQueries) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for id, recoveryCode := range data.recoveryCodes {
+		if recoveryCode.Username == username {
+			delete(data.recoveryCodes, id)
+		}
+	}
+	return nil
+}
+
+func (q *memoryQueries) DeleteWebhook(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	delete(data.webhooks, id)
+	maps.DeleteFunc(data.webhookDeliveries, func(_ int64, delivery WebhookDelivery) bool {
+		return delivery.WebhookID == id
+	})
+	return nil
+}
+
+func (q *memoryQueries) DisableUser(ctx context.Context, username string) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[username]
+	if !ok || user.IsDisabled {
+		return User{}, sql.ErrNoRows
+	}
+	now := currentTimestamp()
+	user.IsDisabled = true
+	user.DisabledAt = now
+	user.UpdatedAt = now
+	data.users[username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[arg.Username]
+	if !ok || user.TotpSecret != arg.TotpSecret || user.IsTotpEnabled {
+		return User{}, sql.ErrNoRows
+	}
+	user.IsTotpEnabled = true
+	user.TotpLastStep = arg.TotpLastStep
+	user.UpdatedAt = currentTimestamp()
+	data.users[user.Username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account, ok := data.accounts[id]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (q *memoryQueries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for _, account := range data.accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return Account{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for _, account := range data.accounts {
+		if account.Owner == arg.Owner && account.Currency == arg.Currency {
+			return account, nil
+		}
+	}
+	return Account{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) GetAccountEndOfDayBalance(ctx context.Context, arg GetAccountEndOfDayBalanceParams) (int64, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account, ok := data.accounts[arg.ID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	balance := account.Balance
+	for _, entry := range data.entries {
+		if entry.AccountID == account.ID && !entry.CreatedAt.Before(arg.EndOfDay) {
+			balance -= entry.Amount
+		}
+	}
+	return balance, nil
+}
+
+// GetAccountForUpdate needs no row lock, transactions are serialized already
+func (q *memoryQueries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *memoryQueries) GetBeneficiary(ctx context.Context, id int64) (Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	beneficiary, ok := data.beneficiaries[id]
+	if !ok {
+		return Beneficiary{}, sql.ErrNoRows
+	}
+	return beneficiary, nil
+}
+
+func (q *memoryQueries) GetBeneficiaryByOwnerAndAccount(ctx context.Context, arg GetBeneficiaryByOwnerAndAccountParams) (Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for _, beneficiary := range data.beneficiaries {
+		if beneficiary.Owner == arg.Owner && beneficiary.AccountID == arg.AccountID {
+			return beneficiary, nil
+		}
+	}
+	return Beneficiary{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	entry, ok := data.entries[id]
+	if !ok {
+		return Entry{}, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (q *memoryQueries) GetLastInterestAccrual(ctx context.Context, accountID int64) (InterestAccrual, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	accruals := selectRows(data.interestAccruals, func(accrual InterestAccrual) bool {
+		return accrual.AccountID == accountID
+	}, func(a, b InterestAccrual) int {
+		return b.AccrualDate.Compare(a.AccrualDate)
+	})
+	if len(accruals) == 0 {
+		return InterestAccrual{}, sql.ErrNoRows
+	}
+	return accruals[0], nil
+}
+
+func (q *memoryQueries) GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	bucket, ok := data.rateLimitBuckets[arg.Key]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return refilledTokens(bucket, currentTimestamp(), arg.Rate, arg.Burst), nil
+}
+
+func (q *memoryQueries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	transfer, ok := data.transfers[id]
+	if !ok {
+		return Transfer{}, sql.ErrNoRows
+	}
+	return transfer, nil
+}
+
+// GetTransferForUpdate needs no row lock, transactions are serialized already
+func (q *memoryQueries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	return q.GetTransfer(ctx, id)
+}
+
+func (q *memoryQueries) GetTransferReversal(ctx context.Context, reversalOf int64) (Transfer, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	transfers := selectRows(data.transfers, func(transfer Transfer) bool {
+		return transfer.ReversalOf == reversalOf
+	}, byTransferID)
+	if len(transfers) == 0 {
+		return Transfer{}, sql.ErrNoRows
+	}
+	return transfers[0], nil
+}
+
+func (q *memoryQueries) GetUser(ctx context.Context, username string) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *memoryQueries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for _, user := range data.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return User{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	webhook, ok := data.webhooks[id]
+	if !ok {
+		return Webhook{}, sql.ErrNoRows
+	}
+	return webhook, nil
+}
+
+func (q *memoryQueries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	delivery, ok := data.webhookDeliveries[id]
+	if !ok {
+		return WebhookDelivery{}, sql.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (q *memoryQueries) InvalidatePasswordResets(ctx context.Context, username string) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for id, passwordReset := range data.passwordResets {
+		if passwordReset.Username == username && !passwordReset.IsUsed {
+			passwordReset.IsUsed = true
+			data.passwordResets[id] = passwordReset
+		}
+	}
+	return nil
+}
+
+func (q *memoryQueries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	accounts := selectRows(data.accounts, func(Account) bool { return true }, byAccountID)
+	return page(accounts, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	accounts := selectRows(data.accounts, func(account Account) bool {
+		return account.Owner == arg.Owner
+	}, byAccountID)
+	return page(accounts, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListAccountsByType(ctx context.Context, accountType string) ([]Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	return selectRows(data.accounts, func(account Account) bool {
+		return account.AccountType == accountType && account.Status != AccountStatusClosed
+	}, byAccountID), nil
+}
+
+func (q *memoryQueries) ListBeneficiaries(ctx context.Context, arg ListBeneficiariesParams) ([]Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	beneficiaries := selectRows(data.beneficiaries, func(beneficiary Beneficiary) bool {
+		return beneficiary.Owner == arg.Owner
+	}, byBeneficiaryID)
+	return page(beneficiaries, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	entries := selectRows(data.entries, func(Entry) bool { return true }, byEntryID)
+	return page(entries, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListEntriesForAccount(ctx context.Context, arg ListEntriesForAccountParams) ([]Entry, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	entries := selectRows(data.entries, func(entry Entry) bool {
+		return entry.AccountID == arg.AccountID
+	}, byEntryID)
+	return page(entries, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	accruals := selectRows(data.interestAccruals, func(accrual InterestAccrual) bool {
+		return accrual.AccountID == arg.AccountID
+	}, func(a, b InterestAccrual) int {
+		return a.AccrualDate.Compare(b.AccrualDate)
+	})
+	return page(accruals, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListInterestRates(ctx context.Context, accountType string) ([]InterestRate, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	return selectRows(data.interestRates, func(rate InterestRate) bool {
+		return rate.AccountType == accountType
+	}, func(a, b InterestRate) int {
+		return a.EffectiveFrom.Compare(b.EffectiveFrom)
+	}), nil
+}
+
+func (q *memoryQueries) ListTranfers(ctx context.Context, arg ListTranfersParams) ([]Transfer, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	transfers := selectRows(data.transfers, func(transfer Transfer) bool {
+		return (arg.FromAccountID == 0 || transfer.FromAccountID == arg.FromAccountID) &&
+			(arg.ToAccountID == 0 || transfer.ToAccountID == arg.ToAccountID)
+	}, byTransferID)
+	return page(transfers, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListUnbalancedAccounts(ctx context.Context) ([]ListUnbalancedAccountsRow, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	totals := map[int64]int64{}
+	for _, entry := range data.entries {
+		totals[entry.AccountID] += entry.Amount
+	}
+
+	unbalanced := selectRows(data.accounts, func(account Account) bool {
+		return account.Balance != totals[account.ID]
+	}, byAccountID)
+
+	rows := []ListUnbalancedAccountsRow{}
+	for _, account := range unbalanced {
+		rows = append(rows, ListUnbalancedAccountsRow{
+			ID: account.ID,
+			Currency: account.Currency,
+			Balance: account.Balance,
+			EntriesTotal: totals[account.ID],
+		})
+	}
+	return rows, nil
+}
+
+func (q *memoryQueries) ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	events := selectRows(data.outboxEvents, func(event OutboxEvent) bool {
+		return event.PublishedAt.IsZero()
+	}, byOutboxEventID)
+	return page(events, limit, 0)
+}
+
+func (q *memoryQueries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	deliveries := selectRows(data.webhookDeliveries, func(delivery WebhookDelivery) bool {
+		return delivery.WebhookID == arg.WebhookID
+	}, byWebhookDeliveryID)
+	return page(deliveries, arg.Limit, arg.Offset)
+}
+
+func (q *memoryQueries) ListWebhooks(ctx context.Context, accountID int64) ([]Webhook, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	return selectRows(data.webhooks, func(webhook Webhook) bool {
+		return webhook.AccountID == accountID
+	}, byWebhookID), nil
+}
+
+func (q *memoryQueries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	return selectRows(data.webhooks, func(webhook Webhook) bool {
+		return webhook.AccountID == arg.AccountID && slices.Contains(webhook.EventTypes, arg.EventType)
+	}, byWebhookID), nil
+}
+
+func (q *memoryQueries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if event, ok := data.outboxEvents[id]; ok {
+		event.PublishedAt = currentTimestamp()
+		data.outboxEvents[id] = event
+	}
+	return nil
+}
+
+func (q *memoryQueries) MarkVerifyEmailSent(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if verifyEmail, ok := data.verifyEmails[id]; ok {
+		verifyEmail.SentAt = currentTimestamp()
+		data.verifyEmails[id] = verifyEmail
+	}
+	return nil
+}
+
+func (q *memoryQueries) ReleaseOutboxEvent(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if event, ok := data.outboxEvents[id]; ok {
+		event.LeasedUntil = time.Time{}
+		data.outboxEvents[id] = event
+	}
+	return nil
+}
+
+func (q *memoryQueries) ReleaseVerifyEmail(ctx context.Context, id int64) error {
+	data, unlock := q.begin()
+	defer unlock()
+
+	if verifyEmail, ok := data.verifyEmails[id]; ok {
+		verifyEmail.LeasedUntil = time.Time{}
+		data.verifyEmails[id] = verifyEmail
+	}
+	return nil
+}
+
+func (q *memoryQueries) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	delivery, ok := data.webhookDeliveries[id]
+	if !ok {
+		return WebhookDelivery{}, sql.ErrNoRows
+	}
+	delivery.Status = WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = currentTimestamp()
+	data.webhookDeliveries[id] = delivery
+	return delivery, nil
+}
+
+func (q *memoryQueries) SetTransferReversalOf(ctx context.Context, arg SetTransferReversalOfParams) (Transfer, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	transfer, ok := data.transfers[arg.ID]
+	if !ok {
+		return Transfer{}, sql.ErrNoRows
+	}
+	if arg.ReversalOf != 0 {
+		for _, other := range data.transfers {
+			if other.ID != transfer.ID && other.ReversalOf == arg.ReversalOf {
+				return Transfer{}, uniqueViolation("transfers", "transfers_reversal_of_key")
+			}
+		}
+	}
+
+	transfer.ReversalOf = arg.ReversalOf
+	data.transfers[transfer.ID] = transfer
+	return transfer, nil
+}
+
+func (q *memoryQueries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[arg.Username]
+	if !ok || user.IsTotpEnabled {
+		return User{}, sql.ErrNoRows
+	}
+	user.TotpSecret = arg.TotpSecret
+	user.UpdatedAt = currentTimestamp()
+	data.users[user.Username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (RateLimitBucket, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	bucket, ok := data.rateLimitBuckets[arg.Key]
+	if !ok {
+		bucket = RateLimitBucket{Key: arg.Key, Tokens: arg.Burst - 1, UpdatedAt: now}
+		data.rateLimitBuckets[bucket.Key] = bucket
+		return bucket, nil
+	}
+
+	tokens := refilledTokens(bucket, now, arg.Rate, arg.Burst)
+	if tokens < 1 {
+		return RateLimitBucket{}, sql.ErrNoRows
+	}
+	bucket.Tokens = tokens - 1
+	bucket.UpdatedAt = now
+	data.rateLimitBuckets[bucket.Key] = bucket
+	return bucket, nil
+}
+
+func (q *memoryQueries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account, ok := data.accounts[arg.ID]
+	if !ok || account.Version != arg.Version {
+		return Account{}, sql.ErrNoRows
+	}
+	account.Balance = arg.Balance
+	account.Version++
+	data.accounts[account.ID] = account
+	return account, nil
+}
+
+func (q *memoryQueries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	account, ok := data.accounts[arg.ID]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+	switch arg.Status {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusClosed:
+	default:
+		return Account{}, checkViolation("accounts", "account_status_check")
+	}
+
+	account.Status = arg.Status
+	account.Version++
+	data.accounts[account.ID] = account
+	return account, nil
+}
+
+func (q *memoryQueries) UpdateBeneficiaryNickname(ctx context.Context, arg UpdateBeneficiaryNicknameParams) (Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	beneficiary, ok := data.beneficiaries[arg.ID]
+	if !ok {
+		return Beneficiary{}, sql.ErrNoRows
+	}
+	for _, other := range data.beneficiaries {
+		if other.ID != beneficiary.ID && other.Owner == beneficiary.Owner && other.Nickname == arg.Nickname {
+			return Beneficiary{}, uniqueViolation("beneficiaries", "owner_nickname_key")
+		}
+	}
+
+	beneficiary.Nickname = arg.Nickname
+	data.beneficiaries[beneficiary.ID] = beneficiary
+	return beneficiary, nil
+}
+
+func (q *memoryQueries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[arg.Username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	now := currentTimestamp()
+	user.HashedPassword = arg.HashedPassword
+	user.PasswordChangedAt = now
+	user.UpdatedAt = now
+	data.users[user.Username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	delivery, ok := data.webhookDeliveries[arg.ID]
+	if !ok {
+		return WebhookDelivery{}, sql.ErrNoRows
+	}
+	switch arg.Status {
+	case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryFailed:
+	default:
+		return WebhookDelivery{}, checkViolation("webhook_deliveries", "webhook_delivery_status_check")
+	}
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.LastStatusCode = arg.LastStatusCode
+	delivery.LastError = arg.LastError
+	delivery.NextAttemptAt = arg.NextAttemptAt.UTC().Truncate(time.Microsecond)
+	delivery.DeliveredAt = arg.DeliveredAt.UTC().Truncate(time.Microsecond)
+	data.webhookDeliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+func (q *memoryQueries) UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	now := currentTimestamp()
+	for id, passwordReset := range data.passwordResets {
+		if passwordReset.TokenHash == tokenHash && !passwordReset.IsUsed && passwordReset.ExpiredAt.After(now) {
+			passwordReset.IsUsed = true
+			data.passwordResets[id] = passwordReset
+			return passwordReset, nil
+		}
+	}
+	return PasswordReset{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	for id, recoveryCode := range data.recoveryCodes {
+		if recoveryCode.Username == arg.Username && recoveryCode.CodeHash == arg.CodeHash && !recoveryCode.IsUsed {
+			recoveryCode.IsUsed = true
+			data.recoveryCodes[id] = recoveryCode
+			return recoveryCode, nil
+		}
+	}
+	return RecoveryCode{}, sql.ErrNoRows
+}
+
+func (q *memoryQueries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[arg.Username]
+	if !ok || !user.IsTotpEnabled || user.TotpLastStep >= arg.TotpLastStep {
+		return User{}, sql.ErrNoRows
+	}
+	user.TotpLastStep = arg.TotpLastStep
+	data.users[user.Username] = user
+	return user, nil
+}
+
+func (q *memoryQueries) UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	verifyEmail, ok := data.verifyEmails[arg.ID]
+	if !ok || verifyEmail.SecretCode != arg.SecretCode || verifyEmail.IsUsed || !verifyEmail.ExpiredAt.After(currentTimestamp()) {
+		return VerifyEmail{}, sql.ErrNoRows
+	}
+	verifyEmail.IsUsed = true
+	data.verifyEmails[verifyEmail.ID] = verifyEmail
+	return verifyEmail, nil
+}
+
+func (q *memoryQueries) VerifyBeneficiary(ctx context.Context, id int64) (Beneficiary, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	beneficiary, ok := data.beneficiaries[id]
+	if !ok {
+		return Beneficiary{}, sql.ErrNoRows
+	}
+	if !beneficiary.IsVerified {
+		beneficiary.IsVerified = true
+		beneficiary.VerifiedAt = currentTimestamp()
+	}
+	data.beneficiaries[beneficiary.ID] = beneficiary
+	return beneficiary, nil
+}
+
+func (q *memoryQueries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	data, unlock := q.begin()
+	defer unlock()
+
+	user, ok := data.users[arg.Username]
+	if !ok || user.Email != arg.Email {
+		return User{}, sql.ErrNoRows
+	}
+	user.IsEmailVerified = true
+	user.UpdatedAt = currentTimestamp()
+	data.users[user.Username] = user
+	return user, nil
+}
@@ -3,6 +3,7 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,6 +13,26 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// active, frozen or closed
+	Status      string `json:"status"`
+	AccountType string `json:"account_type"`
+	// interest accrued but not posted yet, in fractional cents
+	AccruedInterest string `json:"accrued_interest"`
+	// IBAN, used to address the account externally
+	AccountNumber string `json:"account_number"`
+	// incremented on every change of the account, for optimistic concurrency
+	Version int64 `json:"version"`
+}
+
+type Beneficiary struct {
+	ID         int64  `json:"id"`
+	Owner      string `json:"owner"`
+	Nickname   string `json:"nickname"`
+	AccountID  int64  `json:"account_id"`
+	IsVerified bool   `json:"is_verified"`
+	// start of the cooling-off period for large transfers
+	VerifiedAt time.Time `json:"verified_at"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -22,6 +43,78 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// end of day balance
+	Balance    int64  `json:"balance"`
+	AnnualRate string `json:"annual_rate"`
+	// accrued interest in fractional cents
+	Amount    string    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month the interest is posted for
+	Period time.Time `json:"period"`
+	// whole cents posted
+	Amount     int64     `json:"amount"`
+	TransferID int64     `json:"transfer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type InterestRate struct {
+	ID          int64  `json:"id"`
+	AccountType string `json:"account_type"`
+	// yearly rate as a fraction, 0.015 is 1.5%
+	AnnualRate    string    `json:"annual_rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type OutboxEvent struct {
+	ID            int64  `json:"id"`
+	AggregateType string `json:"aggregate_type"`
+	// id or username of the row the event is about, events of the same aggregate are published in id order
+	AggregateID string          `json:"aggregate_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// zero until the relay publishes the event
+	PublishedAt time.Time `json:"published_at"`
+	// until when the event is kept from other relays while a relay publishes it, zero if it is not
+	LeasedUntil time.Time `json:"leased_until"`
+}
+
+type PasswordReset struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// hex SHA-256 of the token sent to the user, the token itself is never stored
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type RateLimitBucket struct {
+	Key string `json:"key"`
+	// tokens left at updated_at, refilled lazily when taking one
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// hex SHA-256 of the recovery code given to the user, the code itself is never stored
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -29,13 +122,68 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// id of the transfer this one reverses, 0 if none
+	ReversalOf int64 `json:"reversal_of"`
 }
 
 type User struct {
-	Username       string    `json:"username"`
-	HashedPassword string    `json:"hashed_password"`
-	FullName       string    `json:"full_name"`
-	Email          string    `json:"email"`
+	Username        string    `json:"username"`
+	HashedPassword  string    `json:"hashed_password"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	IsDisabled      bool      `json:"is_disabled"`
+	DisabledAt      time.Time `json:"disabled_at"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	// access tokens issued before are revoked, zero if the password never changed
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	// base32 TOTP secret, set on enrollment and kept once confirmed
+	TotpSecret    string `json:"totp_secret"`
+	IsTotpEnabled bool   `json:"is_totp_enabled"`
+	// time step of the last TOTP code accepted, so codes cannot be replayed
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// address being verified, the user email when the verification was created
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	// zero until the verification email is sent
+	SentAt time.Time `json:"sent_at"`
+	// until when the email is kept from other senders while a sender sends it, zero if it is not
+	LeasedUntil time.Time `json:"leased_until"`
+}
+
+type Webhook struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	Url       string `json:"url"`
+	// key of the HMAC-SHA256 signature of the deliveries
+	Secret     string    `json:"secret"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID        int64 `json:"id"`
+	WebhookID int64 `json:"webhook_id"`
+	// id of the outbox event delivered
+	EventID   int64           `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	// pending, succeeded or failed once every attempt is exhausted
+	Status         string    `json:"status"`
+	Attempts       int32     `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastStatusCode int32     `json:"last_status_code"`
+	LastError      string    `json:"last_error"`
 	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	// zero until the delivery succeeds
+	DeliveredAt time.Time `json:"delivered_at"`
 }
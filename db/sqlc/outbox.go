@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Aggregates are the kinds of rows outbox events are about
+const (
+	AggregateUser = "user"
+	AggregateAccount = "account"
+	AggregateTransfer = "transfer"
+)
+
+const (
+	EventUserCreated = "user.created"
+	EventAccountCreated = "account.created"
+	EventTransferCreated = "transfer.created"
+)
+
+// UserEvent is the payload of the user events, which must not carry the password
+type UserEvent struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Email string `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// recordEvent writes an event about the aggregate with payload as JSON to the outbox, within the transaction of q.
+// It must be called while holding the aggregate locks, so its events are recorded in the order they happen.
+func recordEvent(ctx context.Context, q Querier, aggregateType string, aggregateID string, eventType string, payload interface{}) (OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+	return q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID: aggregateID,
+		EventType: eventType,
+		Payload: data,
+	})
+}
+
+// recordTransferCreated records EventTransferCreated, and queues its delivery to the webhooks of both accounts
+func recordTransferCreated(ctx context.Context, q Querier, transfer Transfer) error {
+	event, err := recordEvent(ctx, q, AggregateTransfer, strconv.FormatInt(transfer.ID, 10), EventTransferCreated, transfer)
+	if err != nil {
+		return err
+	}
+
+	err = queueWebhookDeliveries(ctx, q, transfer.FromAccountID, WebhookEventTransferSent, event)
+	if err != nil {
+		return err
+	}
+	return queueWebhookDeliveries(ctx, q, transfer.ToAccountID, WebhookEventTransferReceived, event)
+}
+
+type RelayOutboxTxParams struct {
+	// Limit is the most events relayed
+	Limit int32 `json:"limit"`
+	// Lease is how long the events are kept from other relays, publishing stops halfway through it
+	Lease time.Duration `json:"lease"`
+	// Publish delivers event downstream, it is marked published once Publish returns nil
+	Publish func(ctx context.Context, event OutboxEvent) error `json:"-"`
+}
+
+type RelayOutboxTxResult struct {
+	Published int `json:"published"`
+	// Failed counts the events Publish failed on, and the later events of their aggregates which were held back
+	Failed int `json:"failed"`
+}
+
+// Relaying the outbox publishes the oldest unpublished events, in two short transactions around the publishing:
+//	- claim the events with a lease, unless another relay holds one, so no other relay publishes events meanwhile
+//	- publish the events in id order, outside of any transaction, until half the lease has run out
+//	- hold back the events of an aggregate once one of them fails, so they are published in order on the next relay
+//	- mark every published event, so it is not published again, and release the others
+// Events are published at least once: if marking them fails, they are published again once the lease runs out.
+func (store *txStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+
+	var events []OutboxEvent
+	err := store.execTx(ctx, func(q Querier) error {
+		// waits for the claims of other relays to commit, so their leases are seen by the claim
+		_, err := q.ListUnpublishedOutboxEvents(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		events, err = q.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{
+			LeaseSeconds: arg.Lease.Seconds(),
+			Limit: arg.Limit,
+		})
+		return err
+	})
+	if err != nil || len(events) == 0 {
+		return result, err
+	}
+	slices.SortFunc(events, byOutboxEventID)
+
+	publishCtx, cancel := context.WithTimeout(ctx, arg.Lease/2)
+	defer cancel()
+
+	var published, released []int64
+	failed := map[string]bool{}
+	for _, event := range events {
+		aggregate := event.AggregateType + "/" + event.AggregateID
+		if failed[aggregate] || publishCtx.Err() != nil {
+			released = append(released, event.ID)
+			result.Failed++
+			continue
+		}
+
+		if err := arg.Publish(publishCtx, event); err != nil {
+			failed[aggregate] = true
+			released = append(released, event.ID)
+			result.Failed++
+			continue
+		}
+		published = append(published, event.ID)
+	}
+
+	err = store.execTx(ctx, func(q Querier) error {
+		for _, id := range published {
+			if err := q.MarkOutboxEventPublished(ctx, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range released {
+			if err := q.ReleaseOutboxEvent(ctx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RelayOutboxTxResult{}, err
+	}
+
+	result.Published = len(published)
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// unpublishedEvents returns the unpublished events of the aggregate
+func unpublishedEvents(t *testing.T, store Store, aggregateType string, aggregateID string) []OutboxEvent {
+	all, err := store.ListUnpublishedOutboxEvents(context.Background(), 10000)
+	require.NoError(t, err)
+
+	events := []OutboxEvent{}
+	for _, event := range all {
+		if event.AggregateType == aggregateType && event.AggregateID == aggregateID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// drainOutbox publishes every pending event, so the events of a test are the only ones left
+func drainOutbox(t *testing.T, store Store) {
+	for {
+		result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 1000,
+			Lease: time.Minute,
+			Publish: func(context.Context, OutboxEvent) error { return nil },
+		})
+		require.NoError(t, err)
+		if result.Published == 0 {
+			return
+		}
+	}
+}
+
+func testTransferTxOutbox(t *testing.T, store Store) {
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	result, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 60,
+	})
+	require.NoError(t, err)
+
+	events := unpublishedEvents(t, store, AggregateTransfer, strconv.FormatInt(result.Transfer.ID, 10))
+	require.Len(t, events, 1)
+	require.Equal(t, EventTransferCreated, events[0].EventType)
+
+	var payload Transfer
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Transfer, payload)
+
+	// the event is rolled back with the transfer
+	drainOutbox(t, store)
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 60,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	pending, err := store.ListUnpublishedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func testRelayOutboxTx(t *testing.T, store Store) {
+	drainOutbox(t, store)
+
+	createEvent := func(aggregateID string, eventType string) OutboxEvent {
+		event, err := store.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+			AggregateType: "test",
+			AggregateID: aggregateID,
+			EventType: eventType,
+			Payload: json.RawMessage(`{"n": 1}`),
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	a1 := createEvent("a", "first")
+	b1 := createEvent("b", "first")
+	a2 := createEvent("a", "second")
+	a3 := createEvent("a", "third")
+
+	// a1 fails, the later events of a are held back while b is published
+	var published []int64
+	result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 10,
+		Lease: time.Minute,
+		Publish: func(ctx context.Context, event OutboxEvent) error {
+			if event.ID == a1.ID {
+				return errors.New("unavailable")
+			}
+			published = append(published, event.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, RelayOutboxTxResult{Published: 1, Failed: 3}, result)
+	require.Equal(t, []int64{b1.ID}, published)
+
+	// the next relay publishes the events of a in order
+	published = nil
+	result, err = store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 10,
+		Lease: time.Minute,
+		Publish: func(ctx context.Context, event OutboxEvent) error {
+			published = append(published, event.ID)
+			require.Equal(t, "test", event.AggregateType)
+			require.JSONEq(t, `{"n": 1}`, string(event.Payload))
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, RelayOutboxTxResult{Published: 3}, result)
+	require.Equal(t, []int64{a1.ID, a2.ID, a3.ID}, published)
+
+	pending, err := store.ListUnpublishedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func testRelayOutboxTxLease(t *testing.T, store Store) {
+	drainOutbox(t, store)
+
+	createEvent := func(aggregateID string) OutboxEvent {
+		event, err := store.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+			AggregateType: "test",
+			AggregateID: aggregateID,
+			EventType: "leased",
+			Payload: json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+		return event
+	}
+	relay := func(publish func(ctx context.Context, event OutboxEvent) error) RelayOutboxTxResult {
+		result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 10,
+			Lease: time.Minute,
+			Publish: publish,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	a1 := createEvent("a")
+	var a2 OutboxEvent
+	result := relay(func(ctx context.Context, event OutboxEvent) error {
+		require.Equal(t, a1.ID, event.ID)
+
+		// events are published outside of any transaction, so the store can be written meanwhile
+		a2 = createEvent("a")
+
+		// the events are leased, other relays publish none of them, nor the later ones
+		other := relay(func(context.Context, OutboxEvent) error {
+			t.Error("published by another relay")
+			return nil
+		})
+		require.Equal(t, RelayOutboxTxResult{}, other)
+		return nil
+	})
+	require.Equal(t, RelayOutboxTxResult{Published: 1}, result)
+
+	// the lease of a relay stopping halfway runs out
+	claimed, err := store.ClaimOutboxEvents(context.Background(), ClaimOutboxEventsParams{LeaseSeconds: 0.1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, a2.ID, claimed[0].ID)
+	require.Equal(t, RelayOutboxTxResult{}, relay(func(context.Context, OutboxEvent) error { return nil }))
+
+	time.Sleep(200 * time.Millisecond)
+	var published []int64
+	result = relay(func(ctx context.Context, event OutboxEvent) error {
+		published = append(published, event.ID)
+		return nil
+	})
+	require.Equal(t, RelayOutboxTxResult{Published: 1}, result)
+	require.Equal(t, []int64{a2.ID}, published)
+}
+
+func testCreateOutboxEventInvalidPayload(t *testing.T, store Store) {
+	_, err := store.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		AggregateType: "test",
+		AggregateID: "a",
+		EventType: "invalid",
+		Payload: json.RawMessage(`{`),
+	})
+	requirePQError(t, err, "22P02", "")
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrInvalidPasswordReset = errors.New("password reset token is invalid, used or expired")
+
+// PasswordResetDuration is how long the token sent to reset a forgotten password can be used
+const PasswordResetDuration = time.Hour
+
+// hashPasswordResetToken returns the hash stored for a reset token, so the tokens cannot be read from the database
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type CreatePasswordResetTxParams struct {
+	Username string `json:"username"`
+}
+
+type CreatePasswordResetTxResult struct {
+	PasswordReset PasswordReset `json:"password_reset"`
+	// Token is sent to the user, only its hash is stored
+	Token string `json:"-"`
+}
+
+// Creating a password reset happens within a transaction:
+//	- invalidate the resets of the user not used yet, so only the last token sent works
+//	- create the reset with the hash of a new random token
+func (store *txStore) CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (CreatePasswordResetTxResult, error) {
+	var result CreatePasswordResetTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		if err := q.InvalidatePasswordResets(ctx, arg.Username); err != nil {
+			return err
+		}
+
+		token, err := generateSecretCode()
+		if err != nil {
+			return err
+		}
+
+		result.PasswordReset, err = q.CreatePasswordReset(ctx, CreatePasswordResetParams{
+			Username: arg.Username,
+			TokenHash: hashPasswordResetToken(token),
+			ExpiredAt: currentTimestamp().Add(PasswordResetDuration),
+		})
+		result.Token = token
+		return err
+	})
+
+	return result, err
+}
+
+type ResetPasswordTxParams struct {
+	Token string `json:"-"`
+	HashedPassword string `json:"-"`
+}
+
+type ResetPasswordTxResult struct {
+	User User `json:"user"`
+}
+
+// Resetting a password happens within a transaction:
+//	- mark the reset used, so its token cannot be used again
+//	- invalidate the other resets of the user
+//	- change the password of the user, revoking the access tokens issued before
+// Wrong, used or expired tokens fail with ErrInvalidPasswordReset.
+func (store *txStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		passwordReset, err := q.UsePasswordReset(ctx, hashPasswordResetToken(arg.Token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidPasswordReset
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := q.InvalidatePasswordResets(ctx, passwordReset.Username); err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			HashedPassword: arg.HashedPassword,
+			Username: passwordReset.Username,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreatePasswordResetTx(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+
+	result, err := store.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{Username: user.Username})
+	require.NoError(t, err)
+	require.Len(t, result.Token, 64)
+	require.Equal(t, user.Username, result.PasswordReset.Username)
+	require.Equal(t, hashPasswordResetToken(result.Token), result.PasswordReset.TokenHash)
+	require.NotEqual(t, result.Token, result.PasswordReset.TokenHash)
+	require.False(t, result.PasswordReset.IsUsed)
+	require.WithinDuration(t, time.Now().Add(PasswordResetDuration), result.PasswordReset.ExpiredAt, time.Minute)
+
+	_, err = store.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{Username: util.RandomOwner()})
+	requirePQError(t, err, "23503", "password_resets_username_fkey")
+}
+
+func testResetPasswordTx(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+
+	first, err := store.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{Username: user.Username})
+	require.NoError(t, err)
+	second, err := store.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{Username: user.Username})
+	require.NoError(t, err)
+
+	// only the last token sent works
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: first.Token, HashedPassword: "new hashed"})
+	require.ErrorIs(t, err, ErrInvalidPasswordReset)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: "wrong", HashedPassword: "new hashed"})
+	require.ErrorIs(t, err, ErrInvalidPasswordReset)
+
+	result, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: second.Token, HashedPassword: "new hashed"})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, "new hashed", result.User.HashedPassword)
+	require.WithinDuration(t, time.Now(), result.User.PasswordChangedAt, time.Second)
+
+	got, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, "new hashed", got.HashedPassword)
+
+	// tokens can be used only once
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: second.Token, HashedPassword: "other hashed"})
+	require.ErrorIs(t, err, ErrInvalidPasswordReset)
+}
+
+func testResetPasswordTxExpired(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+	token := util.RandomString(32)
+	_, err := store.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username: user.Username,
+		TokenHash: hashPasswordResetToken(token),
+		ExpiredAt: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: token, HashedPassword: "new hashed"})
+	require.ErrorIs(t, err, ErrInvalidPasswordReset)
+
+	got, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, got.HashedPassword)
+	require.True(t, got.PasswordChangedAt.IsZero())
+}
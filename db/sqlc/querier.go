@@ -7,22 +7,112 @@ import (
 )
 
 type Querier interface {
+	AddAccountAccruedInterest(ctx context.Context, arg AddAccountAccruedInterestParams) (Account, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// Oldest events not published yet, leased to the relay claiming them while it publishes them outside of any transaction.
+	// Nothing is claimed while another relay holds a lease, so the events of an aggregate are published in order,
+	// and the events of a relay stopping halfway are claimed again once its lease runs out.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error)
+	// Oldest verification emails not sent yet and not expired, leased to the sender claiming them while it sends them
+	// outside of any transaction, so other senders skip them. They are claimed again once the lease runs out.
+	ClaimVerifyEmails(ctx context.Context, arg ClaimVerifyEmailsParams) ([]VerifyEmail, error)
+	// Pending deliveries due now. Their next attempt is put off by the lease, so other deliverers skip them
+	// while they are being delivered, and they are attempted again if the deliverer stops halfway.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	CreateInterestRate(ctx context.Context, arg CreateInterestRateParams) (InterestRate, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteBeneficiary(ctx context.Context, id int64) error
+	DeleteIdleRateLimitBuckets(ctx context.Context, idleSeconds float64) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	DisableUser(ctx context.Context, username string) (User, error)
+	// Confirms the enrollment, if the secret is still the one the code was checked against
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
+	// Balance at the given instant, computed by reverting the entries created after it
+	GetAccountEndOfDayBalance(ctx context.Context, arg GetAccountEndOfDayBalanceParams) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetBeneficiary(ctx context.Context, id int64) (Beneficiary, error)
+	GetBeneficiaryByOwnerAndAccount(ctx context.Context, arg GetBeneficiaryByOwnerAndAccountParams) (Beneficiary, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetLastInterestAccrual(ctx context.Context, accountID int64) (InterestAccrual, error)
+	// Tokens in the bucket now, refilled at rate tokens per second up to burst
+	GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferReversal(ctx context.Context, reversalOf int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	// Marks every reset of the user not used yet as used, so none of them can be used anymore
+	InvalidatePasswordResets(ctx context.Context, username string) error
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error)
+	ListAccountsByType(ctx context.Context, accountType string) ([]Account, error)
+	ListBeneficiaries(ctx context.Context, arg ListBeneficiariesParams) ([]Beneficiary, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
 	ListEntriesForAccount(ctx context.Context, arg ListEntriesForAccountParams) ([]Entry, error)
+	ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error)
+	ListInterestRates(ctx context.Context, accountType string) ([]InterestRate, error)
 	ListTranfers(ctx context.Context, arg ListTranfersParams) ([]Transfer, error)
+	// Accounts whose balance differs from the sum of their entries
+	ListUnbalancedAccounts(ctx context.Context) ([]ListUnbalancedAccountsRow, error)
+	// Oldest events not published yet. They stay locked until the transaction ends,
+	// so relays running concurrently claim them one after the other.
+	ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, accountID int64) ([]Webhook, error)
+	// Webhooks of the account subscribed to the event type
+	ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	MarkVerifyEmailSent(ctx context.Context, id int64) error
+	// Lets other relays claim an event which was not published
+	ReleaseOutboxEvent(ctx context.Context, id int64) error
+	// Lets other senders claim an email which was not sent
+	ReleaseVerifyEmail(ctx context.Context, id int64) error
+	// Makes the delivery pending again, due now and with every attempt available
+	ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	SetTransferReversalOf(ctx context.Context, arg SetTransferReversalOfParams) (Transfer, error)
+	// Replaces the secret of an enrollment not confirmed yet
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	// Takes a token from the bucket, refilled at rate tokens per second up to burst, creating it full if missing.
+	// No row is returned if the bucket is empty.
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (RateLimitBucket, error)
+	// Updates the balance only if the account is still at the given version
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateBeneficiaryNickname(ctx context.Context, arg UpdateBeneficiaryNicknameParams) (Beneficiary, error)
+	// Revokes the access tokens issued before, by moving password_changed_at past them
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error)
+	// Marks the reset used if it is neither used nor expired
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	// Marks the code used if it was not
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	// Records the step of an accepted code, unless it or a later one was used already
+	UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error)
+	// Marks the verification used if its secret code matches and it is neither used nor expired
+	UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error)
+	// Verifying twice keeps the original verification time, so it does not restart the cooling-off period
+	VerifyBeneficiary(ctx context.Context, id int64) (Beneficiary, error)
+	// Flags the email of the user as verified, if it is still the address that was verified
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
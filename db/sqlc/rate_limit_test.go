@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testTakeRateLimitToken(t *testing.T, store Store) {
+	arg := TakeRateLimitTokenParams{
+		Key: util.RandomString(12),
+		Burst: 2,
+		// too slow to refill during the test
+		Rate: 0.0001,
+	}
+
+	bucket, err := store.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Key, bucket.Key)
+	require.Equal(t, float64(1), bucket.Tokens)
+	require.NotZero(t, bucket.UpdatedAt)
+
+	bucket, err = store.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 0, bucket.Tokens, 0.01)
+
+	_, err = store.TakeRateLimitToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	tokens, err := store.GetRateLimitTokens(context.Background(), GetRateLimitTokensParams{
+		Burst: arg.Burst,
+		Rate: arg.Rate,
+		Key: arg.Key,
+	})
+	require.NoError(t, err)
+	require.Less(t, tokens, float64(1))
+}
+
+func testDeleteIdleRateLimitBuckets(t *testing.T, store Store) {
+	key := util.RandomString(12)
+	_, err := store.TakeRateLimitToken(context.Background(), TakeRateLimitTokenParams{Key: key, Burst: 1, Rate: 1})
+	require.NoError(t, err)
+
+	// the bucket was just used
+	_, err = store.DeleteIdleRateLimitBuckets(context.Background(), 60)
+	require.NoError(t, err)
+	_, err = store.GetRateLimitTokens(context.Background(), GetRateLimitTokensParams{Burst: 1, Rate: 1, Key: key})
+	require.NoError(t, err)
+
+	deleted, err := store.DeleteIdleRateLimitBuckets(context.Background(), 0)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+	_, err = store.GetRateLimitTokens(context.Background(), GetRateLimitTokensParams{Burst: 1, Rate: 1, Key: key})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrTransferAlreadyReversed = errors.New("transfer is already reversed")
+	ErrReversalNotReversible = errors.New("reversal transfers cannot be reversed")
+)
+
+// Reversing a transfer moves its amount back from the destination to the source account, within a transaction
+// holding a lock on the original transfer:
+//	- check the transfer is neither a reversal nor reversed already
+//	- create the opposite transfer, failing like any other transfer on inactive accounts or insufficient funds
+//	- link it to the original transfer
+//	- record EventTransferCreated for it in the outbox
+func (store *txStore) ReverseTransferTx(ctx context.Context, transferID int64) (CreateTransferTxResult, error) {
+	var result CreateTransferTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		transfer, err := q.GetTransferForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+
+		if transfer.ReversalOf != 0 {
+			return ErrReversalNotReversible
+		}
+
+		_, err = q.GetTransferReversal(ctx, transfer.ID)
+		if err == nil {
+			return ErrTransferAlreadyReversed
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		result, err = transferMoney(ctx, q, CreateTransferTxParams{
+			FromAccountID: transfer.ToAccountID,
+			ToAccountID: transfer.FromAccountID,
+			Amount: transfer.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		if result.FromAccount.Balance < 0 {
+			return ErrInsufficientFunds
+		}
+
+		result.Transfer, err = q.SetTransferReversalOf(ctx, SetTransferReversalOfParams{
+			ID: result.Transfer.ID,
+			ReversalOf: transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordTransferCreated(ctx, q, result.Transfer)
+	})
+	if err == nil {
+		store.observer.TransferCreated(result.FromAccount.Currency, result.Transfer.Amount)
+	}
+
+	return result, err
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testReverseTransferTx(t *testing.T, store Store) {
+
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	original, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 30,
+	})
+	require.NoError(t, err)
+
+	reversal, err := store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.ID, reversal.Transfer.ReversalOf)
+	require.Equal(t, account2.ID, reversal.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, reversal.Transfer.ToAccountID)
+	require.Equal(t, int64(30), reversal.Transfer.Amount)
+	require.Equal(t, account1.Balance, reversal.ToAccount.Balance)
+	require.Equal(t, account2.Balance, reversal.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+
+	_, err = store.ReverseTransferTx(context.Background(), reversal.Transfer.ID)
+	require.ErrorIs(t, err, ErrReversalNotReversible)
+}
+
+func testReverseTransferTxInsufficientFunds(t *testing.T, store Store) {
+
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 0)
+
+	original, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 30,
+	})
+	require.NoError(t, err)
+
+	// the destination spent the money already
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID: account1.ID,
+		Amount: 20,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), original.Transfer.ID)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.GetTransferReversal(context.Background(), original.Transfer.ID)
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SQLiteDriver is the database/sql driver to open SQLite databases with, set DB_DRIVER to it to use SQLite.
+// Its connections enforce foreign keys and wait for the database lock instead of failing right away.
+// In-memory databases are not shared between connections, use a file.
+const SQLiteDriver = "sqlite"
+
+func init() {
+	sql.Register(SQLiteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			_, err := conn.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000; PRAGMA journal_mode = WAL", nil)
+			return err
+		},
+	})
+}
+
+// SQLiteStore is a Store on a SQLite database opened with SQLiteDriver and migrated with the sqlite migrations.
+// Like MemoryStore, it reports constraint violations as the *pq.Error Postgres would have returned.
+type SQLiteStore struct {
+	*sqliteQueries
+	txStore
+	db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) Store {
+	return NewObservedSQLiteStore(db, nopObserver{})
+}
+
+// NewObservedSQLiteStore creates a SQLite store reporting its transactions to observer
+func NewObservedSQLiteStore(db *sql.DB, observer Observer) Store {
+	store := &SQLiteStore{
+		db: db,
+		sqliteQueries: &sqliteQueries{db: instrument(db, sqliteSystem, nil)},
+	}
+	store.txStore = txStore{execTx: store.execTx, observer: observer}
+	return store
+}
+
+// execTx runs fn within a database transaction.
+// Transactions take the write lock when they begin, so those reading rows before updating them wait
+// for each other instead of failing with SQLITE_BUSY: SQLite has no row locks, this makes them safe.
+// database/sql cannot begin such a transaction, so it is managed by hand on a dedicated connection.
+func (store *SQLiteStore) execTx(ctx context.Context, fn func(Querier) error) error {
+	return traceTx(ctx, store.observer, func(ctx context.Context, span trace.Span) error {
+		conn, err := store.db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return err
+		}
+
+		q := &sqliteQueries{db: instrument(conn, sqliteSystem, span)}
+		err = fn(q)
+		if err == nil {
+			span.AddEvent("commit")
+			_, err = conn.ExecContext(ctx, "COMMIT")
+			if err == nil {
+				return nil
+			}
+		}
+
+		span.AddEvent("rollback")
+		// the connection goes back to the pool, it must not be left within the transaction
+		if _, rbErr := conn.ExecContext(context.Background(), "ROLLBACK"); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		logger.FromContext(ctx).DebugContext(ctx, "db transaction rolled back", "error", err)
+		return err
+	})
+}
+
+func (store *SQLiteStore) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	row := store.db.QueryRowContext(ctx, getSchemaVersion)
+	var i SchemaVersion
+	err := row.Scan(&i.Version, &i.Dirty)
+	return i, err
+}
+
+func (store *SQLiteStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// sqliteUniqueConstraints names the unique constraints by the columns SQLite reports when they are violated
+var sqliteUniqueConstraints = map[string]string{
+	"users.username": "users_pkey",
+	"users.email": "users_email_key",
+	"accounts.owner, accounts.currency": "owner_currency_key",
+	"accounts.account_number": "account_number_key",
+	"transfers.reversal_of": "transfers_reversal_of_key",
+	"beneficiaries.owner, beneficiaries.nickname": "owner_nickname_key",
+	"beneficiaries.owner, beneficiaries.account_id": "owner_account_key",
+	"interest_rates.account_type, interest_rates.effective_from": "interest_rates_account_type_effective_from_idx",
+	"password_resets.token_hash": "password_resets_token_hash_key",
+	"recovery_codes.username, recovery_codes.code_hash": "recovery_codes_username_code_hash_key",
+}
+
+// reference is a foreign key of a row being written, exists checks the row it references
+type reference struct {
+	constraint string
+	exists string
+	key interface{}
+}
+
+// constraintError returns err, written to table, as the *pq.Error Postgres would have returned.
+// SQLite does not name the foreign key a row violates, it is the first of refs whose row does not exist.
+func (q *sqliteQueries) constraintError(ctx context.Context, err error, table string, refs ...reference) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return err
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		columns := strings.TrimPrefix(sqliteErr.Error(), "UNIQUE constraint failed: ")
+		if constraint, ok := sqliteUniqueConstraints[columns]; ok {
+			return uniqueViolation(table, constraint)
+		}
+	case sqlite3.ErrConstraintCheck:
+		return checkViolation(table, strings.TrimPrefix(sqliteErr.Error(), "CHECK constraint failed: "))
+	case sqlite3.ErrConstraintForeignKey:
+		for _, ref := range refs {
+			var exists bool
+			if err := q.db.QueryRowContext(ctx, ref.exists, ref.key).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return foreignKeyViolation(table, ref.constraint)
+			}
+		}
+	}
+	return err
+}
+
+func isForeignKeyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
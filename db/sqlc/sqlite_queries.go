@@ -0,0 +1,1455 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// sqliteQueries implements Querier with the SQLite dialect of the queries in db/query.
+// Timestamps are written by the store in UTC, so they compare as text.
+type sqliteQueries struct {
+	db DBTX
+}
+
+var _ Querier = (*sqliteQueries)(nil)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRows runs a :many query and scans its rows with scan
+func queryRows[T any](ctx context.Context, db DBTX, scan func(rowScanner) (T, error), query string, args ...interface{}) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []T{}
+	for rows.Next() {
+		i, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func scanAccount(row rowScanner) (Account, error) {
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AccountType,
+		&i.AccruedInterest,
+		&i.AccountNumber,
+		&i.Version,
+	)
+	return i, err
+}
+
+func scanBeneficiary(row rowScanner) (Beneficiary, error) {
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.IsVerified,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func scanEntry(row rowScanner) (Entry, error) {
+	var i Entry
+	err := row.Scan(&i.ID, &i.AccountID, &i.Amount, &i.CreatedAt)
+	return i, err
+}
+
+func scanInterestAccrual(row rowScanner) (InterestAccrual, error) {
+	var i InterestAccrual
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.AnnualRate,
+		&i.Amount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func scanInterestPosting(row rowScanner) (InterestPosting, error) {
+	var i InterestPosting
+	err := row.Scan(&i.ID, &i.AccountID, &i.Period, &i.Amount, &i.TransferID, &i.CreatedAt)
+	return i, err
+}
+
+func scanInterestRate(row rowScanner) (InterestRate, error) {
+	var i InterestRate
+	err := row.Scan(&i.ID, &i.AccountType, &i.AnnualRate, &i.EffectiveFrom, &i.CreatedAt)
+	return i, err
+}
+
+func scanOutboxEvent(row rowScanner) (OutboxEvent, error) {
+	var i OutboxEvent
+	// the payload is text, which database/sql only converts to plain byte slices
+	var payload []byte
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.EventType,
+		&payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LeasedUntil,
+	)
+	i.Payload = payload
+	return i, err
+}
+
+func scanRateLimitBucket(row rowScanner) (RateLimitBucket, error) {
+	var i RateLimitBucket
+	err := row.Scan(&i.Key, &i.Tokens, &i.UpdatedAt)
+	return i, err
+}
+
+func scanRecoveryCode(row rowScanner) (RecoveryCode, error) {
+	var i RecoveryCode
+	err := row.Scan(&i.ID, &i.Username, &i.CodeHash, &i.IsUsed, &i.CreatedAt)
+	return i, err
+}
+
+func scanTransfer(row rowScanner) (Transfer, error) {
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+func scanUser(row rowScanner) (User, error) {
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+func scanPasswordReset(row rowScanner) (PasswordReset, error) {
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+func scanVerifyEmail(row rowScanner) (VerifyEmail, error) {
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+		&i.SentAt,
+		&i.LeasedUntil,
+	)
+	return i, err
+}
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var i Webhook
+	var eventTypes string
+	err := row.Scan(&i.ID, &i.AccountID, &i.Url, &i.Secret, &eventTypes, &i.CreatedAt)
+	if err != nil {
+		return i, err
+	}
+	err = json.Unmarshal([]byte(eventTypes), &i.EventTypes)
+	return i, err
+}
+
+func scanWebhookDelivery(row rowScanner) (WebhookDelivery, error) {
+	var i WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.EventType,
+		&payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	i.Payload = payload
+	return i, err
+}
+
+const sqliteUserExists = `-- name: UserExists :one
+SELECT EXISTS (SELECT 1 FROM users WHERE username = ?)
+`
+
+const sqliteAccountExists = `-- name: AccountExists :one
+SELECT EXISTS (SELECT 1 FROM accounts WHERE id = ?)
+`
+
+const sqliteTransferExists = `-- name: TransferExists :one
+SELECT EXISTS (SELECT 1 FROM transfers WHERE id = ?)
+`
+
+const sqliteWebhookExists = `-- name: WebhookExists :one
+SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = ?)
+`
+
+const sqliteSetAccountAccruedInterest = `-- name: SetAccountAccruedInterest :one
+UPDATE accounts
+SET accrued_interest = ?1, version = version + 1
+WHERE id = ?2 AND accrued_interest = ?3
+RETURNING *
+`
+
+// AddAccountAccruedInterest adds in Go, SQLite has no decimal arithmetic.
+// The sum is written only if the accrued interest has not changed since it was read, retrying otherwise.
+func (q *sqliteQueries) AddAccountAccruedInterest(ctx context.Context, arg AddAccountAccruedInterestParams) (Account, error) {
+	amount, err := parseNumeric(arg.Amount)
+	if err != nil {
+		return Account{}, err
+	}
+
+	for {
+		account, err := q.GetAccount(ctx, arg.ID)
+		if err != nil {
+			return Account{}, err
+		}
+		previous, err := parseNumeric(account.AccruedInterest)
+		if err != nil {
+			return Account{}, err
+		}
+		accrued, err := numeric(previous.Add(amount).String(), 24, 10)
+		if err != nil {
+			return Account{}, err
+		}
+
+		row := q.db.QueryRowContext(ctx, sqliteSetAccountAccruedInterest, accrued.StringFixed(10), arg.ID, account.AccruedInterest)
+		account, err = scanAccount(row)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return account, err
+		}
+	}
+}
+
+const sqliteAddAccountBalance = `-- name: AddAccountBalance :one
+UPDATE accounts
+SET balance = balance + ?1, version = version + 1
+WHERE id = ?2
+RETURNING *
+`
+
+func (q *sqliteQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteAddAccountBalance, arg.Amount, arg.ID)
+	return scanAccount(row)
+}
+
+const sqliteClaimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET leased_until = ?1
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at = '0001-01-01 00:00:00+00:00'
+    ORDER BY id
+    LIMIT ?3
+)
+AND NOT EXISTS (
+    SELECT 1 FROM outbox_events
+    WHERE published_at = '0001-01-01 00:00:00+00:00' AND leased_until > ?2
+)
+RETURNING *
+`
+
+// ClaimOutboxEvents needs no row locks, the transactions of the store are serialized
+func (q *sqliteQueries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error) {
+	if err := checkPage(arg.Limit, 0); err != nil {
+		return nil, err
+	}
+	now := currentTimestamp()
+	leased := now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+	return queryRows(ctx, q.db, scanOutboxEvent, sqliteClaimOutboxEvents, leased, now, arg.Limit)
+}
+
+const sqliteClaimVerifyEmails = `-- name: ClaimVerifyEmails :many
+UPDATE verify_emails
+SET leased_until = ?1
+WHERE id IN (
+    SELECT id FROM verify_emails
+    WHERE sent_at = '0001-01-01 00:00:00+00:00' AND expired_at > ?2 AND leased_until <= ?2
+    ORDER BY id
+    LIMIT ?3
+)
+RETURNING *
+`
+
+// ClaimVerifyEmails needs no row locks, the transactions of the store are serialized
+func (q *sqliteQueries) ClaimVerifyEmails(ctx context.Context, arg ClaimVerifyEmailsParams) ([]VerifyEmail, error) {
+	if err := checkPage(arg.Limit, 0); err != nil {
+		return nil, err
+	}
+	now := currentTimestamp()
+	leased := now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+	return queryRows(ctx, q.db, scanVerifyEmail, sqliteClaimVerifyEmails, leased, now, arg.Limit)
+}
+
+const sqliteClaimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = ?1
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= ?2
+    ORDER BY next_attempt_at, id
+    LIMIT ?3
+)
+RETURNING *
+`
+
+// ClaimWebhookDeliveries needs no row locks, the transactions of the store are serialized
+func (q *sqliteQueries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	if err := checkPage(arg.Limit, 0); err != nil {
+		return nil, err
+	}
+	now := currentTimestamp()
+	leased := now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))).Truncate(time.Microsecond)
+	return queryRows(ctx, q.db, scanWebhookDelivery, sqliteClaimWebhookDeliveries, leased, now, arg.Limit)
+}
+
+const sqliteCreateAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (
+  owner, balance, currency, account_type, account_number, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountType,
+		arg.AccountNumber,
+		currentTimestamp(),
+	)
+	account, err := scanAccount(row)
+	if err != nil {
+		return Account{}, q.constraintError(ctx, err, "accounts",
+			reference{"accounts_owner_fkey", sqliteUserExists, arg.Owner},
+		)
+	}
+	return account, nil
+}
+
+const sqliteCreateBeneficiary = `-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (
+  owner, nickname, account_id, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateBeneficiary, arg.Owner, arg.Nickname, arg.AccountID, currentTimestamp())
+	beneficiary, err := scanBeneficiary(row)
+	if err != nil {
+		return Beneficiary{}, q.constraintError(ctx, err, "beneficiaries",
+			reference{"beneficiaries_owner_fkey", sqliteUserExists, arg.Owner},
+			reference{"beneficiaries_account_id_fkey", sqliteAccountExists, arg.AccountID},
+		)
+	}
+	return beneficiary, nil
+}
+
+const sqliteCreateEntry = `-- name: CreateEntry :one
+INSERT INTO entries (
+  account_id, amount, created_at
+) VALUES (
+  ?1, ?2, ?3
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateEntry, arg.AccountID, arg.Amount, currentTimestamp())
+	entry, err := scanEntry(row)
+	if err != nil {
+		return Entry{}, q.constraintError(ctx, err, "entries",
+			reference{"entries_account_id_fkey", sqliteAccountExists, arg.AccountID},
+		)
+	}
+	return entry, nil
+}
+
+const sqliteCreateInterestAccrual = `-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id, accrual_date, balance, annual_rate, amount, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING *
+`
+
+func (q *sqliteQueries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	annualRate, err := numeric(arg.AnnualRate, 10, 8)
+	if err != nil {
+		return InterestAccrual{}, err
+	}
+	amount, err := numeric(arg.Amount, 24, 10)
+	if err != nil {
+		return InterestAccrual{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, sqliteCreateInterestAccrual,
+		arg.AccountID,
+		dateOf(arg.AccrualDate),
+		arg.Balance,
+		annualRate.StringFixed(8),
+		amount.StringFixed(10),
+		currentTimestamp(),
+	)
+	accrual, err := scanInterestAccrual(row)
+	if err != nil {
+		return InterestAccrual{}, q.constraintError(ctx, err, "interest_accruals",
+			reference{"interest_accruals_account_id_fkey", sqliteAccountExists, arg.AccountID},
+		)
+	}
+	return accrual, nil
+}
+
+const sqliteCreateInterestPosting = `-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id, period, amount, transfer_id, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5
+)
+ON CONFLICT (account_id, period) DO NOTHING
+RETURNING *
+`
+
+func (q *sqliteQueries) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateInterestPosting,
+		arg.AccountID,
+		dateOf(arg.Period),
+		arg.Amount,
+		arg.TransferID,
+		currentTimestamp(),
+	)
+	posting, err := scanInterestPosting(row)
+	if err != nil {
+		return InterestPosting{}, q.constraintError(ctx, err, "interest_postings",
+			reference{"interest_postings_account_id_fkey", sqliteAccountExists, arg.AccountID},
+			reference{"interest_postings_transfer_id_fkey", sqliteTransferExists, arg.TransferID},
+		)
+	}
+	return posting, nil
+}
+
+const sqliteCreateInterestRate = `-- name: CreateInterestRate :one
+INSERT INTO interest_rates (
+  account_type, annual_rate, effective_from, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateInterestRate(ctx context.Context, arg CreateInterestRateParams) (InterestRate, error) {
+	annualRate, err := numeric(arg.AnnualRate, 10, 8)
+	if err != nil {
+		return InterestRate{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, sqliteCreateInterestRate,
+		arg.AccountType,
+		annualRate.StringFixed(8),
+		dateOf(arg.EffectiveFrom),
+		currentTimestamp(),
+	)
+	rate, err := scanInterestRate(row)
+	if err != nil {
+		return InterestRate{}, q.constraintError(ctx, err, "interest_rates")
+	}
+	return rate, nil
+}
+
+const sqliteCreateOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  aggregate_type, aggregate_id, event_type, payload, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	if err := checkJSON(arg.Payload); err != nil {
+		return OutboxEvent{}, err
+	}
+	// as text, bytes would be stored as a blob
+	row := q.db.QueryRowContext(ctx, sqliteCreateOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		string(arg.Payload),
+		currentTimestamp(),
+	)
+	return scanOutboxEvent(row)
+}
+
+const sqliteCreatePasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  username, token_hash, expired_at, created_at
+) VALUES (
+  ?, ?, ?, ?
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreatePasswordReset,
+		arg.Username,
+		arg.TokenHash,
+		arg.ExpiredAt.UTC().Truncate(time.Microsecond),
+		currentTimestamp(),
+	)
+	passwordReset, err := scanPasswordReset(row)
+	if err != nil {
+		return PasswordReset{}, q.constraintError(ctx, err, "password_resets",
+			reference{"password_resets_username_fkey", sqliteUserExists, arg.Username},
+		)
+	}
+	return passwordReset, nil
+}
+
+const sqliteCreateRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+  username, code_hash, created_at
+) VALUES (
+  ?, ?, ?
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateRecoveryCode, arg.Username, arg.CodeHash, currentTimestamp())
+	recoveryCode, err := scanRecoveryCode(row)
+	if err != nil {
+		return RecoveryCode{}, q.constraintError(ctx, err, "recovery_codes",
+			reference{"recovery_codes_username_fkey", sqliteUserExists, arg.Username},
+		)
+	}
+	return recoveryCode, nil
+}
+
+const sqliteCreateTransfer = `-- name: CreateTransfer :one
+INSERT INTO transfers (
+  from_account_id, to_account_id, amount, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount, currentTimestamp())
+	transfer, err := scanTransfer(row)
+	if err != nil {
+		return Transfer{}, q.constraintError(ctx, err, "transfers",
+			reference{"transfers_from_account_id_fkey", sqliteAccountExists, arg.FromAccountID},
+			reference{"transfers_to_account_id_fkey", sqliteAccountExists, arg.ToAccountID},
+		)
+	}
+	return transfer, nil
+}
+
+const sqliteCreateUser = `-- name: CreateUser :one
+INSERT INTO users (
+  username, hashed_password, full_name, email, created_at, updated_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?5
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateUser,
+		arg.Username,
+		arg.HashedPassword,
+		arg.FullName,
+		arg.Email,
+		currentTimestamp(),
+	)
+	user, err := scanUser(row)
+	if err != nil {
+		return User{}, q.constraintError(ctx, err, "users")
+	}
+	return user, nil
+}
+
+const sqliteCreateVerifyEmail = `-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+  username, email, secret_code, expired_at, created_at
+) VALUES (
+  ?, ?, ?, ?, ?
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRowContext(ctx, sqliteCreateVerifyEmail,
+		arg.Username,
+		arg.Email,
+		arg.SecretCode,
+		arg.ExpiredAt.UTC().Truncate(time.Microsecond),
+		currentTimestamp(),
+	)
+	verifyEmail, err := scanVerifyEmail(row)
+	if err != nil {
+		return VerifyEmail{}, q.constraintError(ctx, err, "verify_emails",
+			reference{"verify_emails_username_fkey", sqliteUserExists, arg.Username},
+		)
+	}
+	return verifyEmail, nil
+}
+
+const sqliteCreateWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  account_id, url, secret, event_types, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	eventTypes := arg.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	// as a JSON array, SQLite has no arrays
+	data, err := json.Marshal(eventTypes)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, sqliteCreateWebhook, arg.AccountID, arg.Url, arg.Secret, string(data), currentTimestamp())
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return Webhook{}, q.constraintError(ctx, err, "webhooks",
+			reference{"webhooks_account_id_fkey", sqliteAccountExists, arg.AccountID},
+		)
+	}
+	return webhook, nil
+}
+
+const sqliteCreateWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  webhook_id, event_id, event_type, payload, next_attempt_at, created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?5
+) RETURNING *
+`
+
+func (q *sqliteQueries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	if err := checkJSON(arg.Payload); err != nil {
+		return WebhookDelivery{}, err
+	}
+	row := q.db.QueryRowContext(ctx, sqliteCreateWebhookDelivery,
+		arg.WebhookID,
+		arg.EventID,
+		arg.EventType,
+		string(arg.Payload),
+		currentTimestamp(),
+	)
+	delivery, err := scanWebhookDelivery(row)
+	if err != nil {
+		return WebhookDelivery{}, q.constraintError(ctx, err, "webhook_deliveries",
+			reference{"webhook_deliveries_webhook_id_fkey", sqliteWebhookExists, arg.WebhookID},
+		)
+	}
+	return delivery, nil
+}
+
+const sqliteDeleteAccount = `-- name: DeleteAccount :exec
+DELETE FROM accounts WHERE id = ?
+`
+
+// sqliteAccountReferences are the foreign keys to accounts, with the query finding whether an account is referenced
+var sqliteAccountReferences = []struct {
+	table string
+	constraint string
+	exists string
+}{
+	{"entries", "entries_account_id_fkey", "-- name: AccountHasEntries :one\nSELECT EXISTS (SELECT 1 FROM entries WHERE account_id = ?)"},
+	{"transfers", "transfers_from_account_id_fkey", "-- name: AccountHasOutgoingTransfers :one\nSELECT EXISTS (SELECT 1 FROM transfers WHERE from_account_id = ?)"},
+	{"transfers", "transfers_to_account_id_fkey", "-- name: AccountHasIncomingTransfers :one\nSELECT EXISTS (SELECT 1 FROM transfers WHERE to_account_id = ?)"},
+	{"beneficiaries", "beneficiaries_account_id_fkey", "-- name: AccountHasBeneficiaries :one\nSELECT EXISTS (SELECT 1 FROM beneficiaries WHERE account_id = ?)"},
+	{"interest_accruals", "interest_accruals_account_id_fkey", "-- name: AccountHasInterestAccruals :one\nSELECT EXISTS (SELECT 1 FROM interest_accruals WHERE account_id = ?)"},
+	{"interest_postings", "interest_postings_account_id_fkey", "-- name: AccountHasInterestPostings :one\nSELECT EXISTS (SELECT 1 FROM interest_postings WHERE account_id = ?)"},
+	{"webhooks", "webhooks_account_id_fkey", "-- name: AccountHasWebhooks :one\nSELECT EXISTS (SELECT 1 FROM webhooks WHERE account_id = ?)"},
+}
+
+func (q *sqliteQueries) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteDeleteAccount, id)
+	if !isForeignKeyError(err) {
+		return err
+	}
+
+	// the account is still referenced, find out by which foreign key
+	for _, ref := range sqliteAccountReferences {
+		var exists bool
+		if err := q.db.QueryRowContext(ctx, ref.exists, id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return referenceViolation(ref.table, ref.constraint, "accounts")
+		}
+	}
+	return err
+}
+
+const sqliteDeleteBeneficiary = `-- name: DeleteBeneficiary :exec
+DELETE FROM beneficiaries
+WHERE id = ?
+`
+
+func (q *sqliteQueries) DeleteBeneficiary(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteDeleteBeneficiary, id)
+	return err
+}
+
+const sqliteDeleteIdleRateLimitBuckets = `-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < ?
+`
+
+func (q *sqliteQueries) DeleteIdleRateLimitBuckets(ctx context.Context, idleSeconds float64) (int64, error) {
+	cutoff := currentTimestamp().Add(-time.Duration(idleSeconds * float64(time.Second)))
+	result, err := q.db.ExecContext(ctx, sqliteDeleteIdleRateLimitBuckets, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteDeleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = ?
+`
+
+func (q *sqliteQueries) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, sqliteDeleteRecoveryCodes, username)
+	return err
+}
+
+const sqliteDeleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = ?
+`
+
+func (q *sqliteQueries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteDeleteWebhook, id)
+	return err
+}
+
+const sqliteDisableUser = `-- name: DisableUser :one
+UPDATE users
+SET is_disabled = true, disabled_at = ?2, updated_at = ?2
+WHERE username = ?1 AND NOT is_disabled
+RETURNING *
+`
+
+func (q *sqliteQueries) DisableUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteDisableUser, username, currentTimestamp())
+	return scanUser(row)
+}
+
+const sqliteEnableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true, totp_last_step = ?1, updated_at = ?4
+WHERE username = ?2 AND totp_secret = ?3 AND NOT is_totp_enabled
+RETURNING *
+`
+
+func (q *sqliteQueries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteEnableUserTOTP, arg.TotpLastStep, arg.Username, arg.TotpSecret, currentTimestamp())
+	return scanUser(row)
+}
+
+const sqliteGetAccount = `-- name: GetAccount :one
+SELECT * FROM accounts
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetAccount, id)
+	return scanAccount(row)
+}
+
+const sqliteGetAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetAccountByNumber, accountNumber)
+	return scanAccount(row)
+}
+
+const sqliteGetAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = ? AND currency = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
+	return scanAccount(row)
+}
+
+const sqliteGetAccountEndOfDayBalance = `-- name: GetAccountEndOfDayBalance :one
+SELECT (a.balance - COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= ?1
+), 0)) AS balance
+FROM accounts a
+WHERE a.id = ?2
+`
+
+func (q *sqliteQueries) GetAccountEndOfDayBalance(ctx context.Context, arg GetAccountEndOfDayBalanceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetAccountEndOfDayBalance, arg.EndOfDay.UTC(), arg.ID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const sqliteGetAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT * FROM accounts
+WHERE id = ? LIMIT 1
+`
+
+// GetAccountForUpdate takes no row lock, transactions hold the database write lock already
+func (q *sqliteQueries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetAccountForUpdate, id)
+	return scanAccount(row)
+}
+
+const sqliteGetBeneficiary = `-- name: GetBeneficiary :one
+SELECT * FROM beneficiaries
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetBeneficiary(ctx context.Context, id int64) (Beneficiary, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetBeneficiary, id)
+	return scanBeneficiary(row)
+}
+
+const sqliteGetBeneficiaryByOwnerAndAccount = `-- name: GetBeneficiaryByOwnerAndAccount :one
+SELECT * FROM beneficiaries
+WHERE owner = ? AND account_id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetBeneficiaryByOwnerAndAccount(ctx context.Context, arg GetBeneficiaryByOwnerAndAccountParams) (Beneficiary, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetBeneficiaryByOwnerAndAccount, arg.Owner, arg.AccountID)
+	return scanBeneficiary(row)
+}
+
+const sqliteGetEntry = `-- name: GetEntry :one
+SELECT * FROM entries
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetEntry, id)
+	return scanEntry(row)
+}
+
+const sqliteGetLastInterestAccrual = `-- name: GetLastInterestAccrual :one
+SELECT * FROM interest_accruals
+WHERE account_id = ?
+ORDER BY accrual_date DESC
+LIMIT 1
+`
+
+func (q *sqliteQueries) GetLastInterestAccrual(ctx context.Context, accountID int64) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetLastInterestAccrual, accountID)
+	return scanInterestAccrual(row)
+}
+
+const sqliteGetRateLimitBucket = `-- name: GetRateLimitBucket :one
+SELECT * FROM rate_limit_buckets
+WHERE key = ?
+`
+
+// GetRateLimitTokens refills the bucket in Go, SQLite has no precise time arithmetic
+func (q *sqliteQueries) GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetRateLimitBucket, arg.Key)
+	bucket, err := scanRateLimitBucket(row)
+	if err != nil {
+		return 0, err
+	}
+	return refilledTokens(bucket, currentTimestamp(), arg.Rate, arg.Burst), nil
+}
+
+const sqliteGetTransfer = `-- name: GetTransfer :one
+SELECT * FROM transfers
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetTransfer, id)
+	return scanTransfer(row)
+}
+
+const sqliteGetTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = ? LIMIT 1
+`
+
+// GetTransferForUpdate takes no row lock, transactions hold the database write lock already
+func (q *sqliteQueries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetTransferForUpdate, id)
+	return scanTransfer(row)
+}
+
+const sqliteGetTransferReversal = `-- name: GetTransferReversal :one
+SELECT * FROM transfers
+WHERE reversal_of = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetTransferReversal(ctx context.Context, reversalOf int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetTransferReversal, reversalOf)
+	return scanTransfer(row)
+}
+
+const sqliteGetUser = `-- name: GetUser :one
+SELECT * FROM users
+WHERE username = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetUser, username)
+	return scanUser(row)
+}
+
+const sqliteGetUserByEmail = `-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetUserByEmail, email)
+	return scanUser(row)
+}
+
+const sqliteGetWebhook = `-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetWebhook, id)
+	return scanWebhook(row)
+}
+
+const sqliteGetWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = ? LIMIT 1
+`
+
+func (q *sqliteQueries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, sqliteGetWebhookDelivery, id)
+	return scanWebhookDelivery(row)
+}
+
+const sqliteInvalidatePasswordResets = `-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE username = ? AND NOT is_used
+`
+
+func (q *sqliteQueries) InvalidatePasswordResets(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, sqliteInvalidatePasswordResets, username)
+	return err
+}
+
+const sqliteListAccounts = `-- name: ListAccounts :many
+SELECT * FROM accounts
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanAccount, sqliteListAccounts, arg.Limit, arg.Offset)
+}
+
+const sqliteListAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = ?
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanAccount, sqliteListAccountsByOwner, arg.Owner, arg.Limit, arg.Offset)
+}
+
+const sqliteListAccountsByType = `-- name: ListAccountsByType :many
+SELECT * FROM accounts
+WHERE account_type = ? AND status != 'closed'
+ORDER BY id
+`
+
+func (q *sqliteQueries) ListAccountsByType(ctx context.Context, accountType string) ([]Account, error) {
+	return queryRows(ctx, q.db, scanAccount, sqliteListAccountsByType, accountType)
+}
+
+const sqliteListBeneficiaries = `-- name: ListBeneficiaries :many
+SELECT * FROM beneficiaries
+WHERE owner = ?
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListBeneficiaries(ctx context.Context, arg ListBeneficiariesParams) ([]Beneficiary, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanBeneficiary, sqliteListBeneficiaries, arg.Owner, arg.Limit, arg.Offset)
+}
+
+const sqliteListEntries = `-- name: ListEntries :many
+SELECT * FROM entries
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanEntry, sqliteListEntries, arg.Limit, arg.Offset)
+}
+
+const sqliteListEntriesForAccount = `-- name: ListEntriesForAccount :many
+SELECT * FROM entries
+WHERE account_id = ?
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListEntriesForAccount(ctx context.Context, arg ListEntriesForAccountParams) ([]Entry, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanEntry, sqliteListEntriesForAccount, arg.AccountID, arg.Limit, arg.Offset)
+}
+
+const sqliteListInterestAccruals = `-- name: ListInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = ?
+ORDER BY accrual_date
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanInterestAccrual, sqliteListInterestAccruals, arg.AccountID, arg.Limit, arg.Offset)
+}
+
+const sqliteListInterestRates = `-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+WHERE account_type = ?
+ORDER BY effective_from
+`
+
+func (q *sqliteQueries) ListInterestRates(ctx context.Context, accountType string) ([]InterestRate, error) {
+	return queryRows(ctx, q.db, scanInterestRate, sqliteListInterestRates, accountType)
+}
+
+const sqliteListTranfers = `-- name: ListTranfers :many
+SELECT * FROM transfers
+WHERE
+    (?1 = 0 OR from_account_id = ?1) AND
+    (?2 = 0 OR to_account_id = ?2)
+ORDER BY id
+LIMIT ?3 OFFSET ?4
+`
+
+func (q *sqliteQueries) ListTranfers(ctx context.Context, arg ListTranfersParams) ([]Transfer, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanTransfer, sqliteListTranfers, arg.FromAccountID, arg.ToAccountID, arg.Limit, arg.Offset)
+}
+
+const sqliteListUnbalancedAccounts = `-- name: ListUnbalancedAccounts :many
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0) AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance != COALESCE(SUM(e.amount), 0)
+ORDER BY a.id
+`
+
+func (q *sqliteQueries) ListUnbalancedAccounts(ctx context.Context) ([]ListUnbalancedAccountsRow, error) {
+	return queryRows(ctx, q.db, func(row rowScanner) (ListUnbalancedAccountsRow, error) {
+		var i ListUnbalancedAccountsRow
+		err := row.Scan(&i.ID, &i.Currency, &i.Balance, &i.EntriesTotal)
+		return i, err
+	}, sqliteListUnbalancedAccounts)
+}
+
+const sqliteListUnpublishedOutboxEvents = `-- name: ListUnpublishedOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE published_at = '0001-01-01 00:00:00+00:00'
+ORDER BY id
+LIMIT ?
+`
+
+// ListUnpublishedOutboxEvents needs no row locks, the transactions of the store are serialized
+func (q *sqliteQueries) ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	if err := checkPage(limit, 0); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanOutboxEvent, sqliteListUnpublishedOutboxEvents, limit)
+}
+
+const sqliteListWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+func (q *sqliteQueries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	if err := checkPage(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+	return queryRows(ctx, q.db, scanWebhookDelivery, sqliteListWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+}
+
+const sqliteListWebhooks = `-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE account_id = ?
+ORDER BY id
+`
+
+func (q *sqliteQueries) ListWebhooks(ctx context.Context, accountID int64) ([]Webhook, error) {
+	return queryRows(ctx, q.db, scanWebhook, sqliteListWebhooks, accountID)
+}
+
+const sqliteListWebhooksForEvent = `-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE account_id = ?1 AND EXISTS (SELECT 1 FROM json_each(event_types) WHERE value = ?2)
+ORDER BY id
+`
+
+func (q *sqliteQueries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	return queryRows(ctx, q.db, scanWebhook, sqliteListWebhooksForEvent, arg.AccountID, arg.EventType)
+}
+
+const sqliteMarkOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = ?2
+WHERE id = ?1
+`
+
+func (q *sqliteQueries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteMarkOutboxEventPublished, id, currentTimestamp())
+	return err
+}
+
+const sqliteMarkVerifyEmailSent = `-- name: MarkVerifyEmailSent :exec
+UPDATE verify_emails
+SET sent_at = ?2
+WHERE id = ?1
+`
+
+func (q *sqliteQueries) MarkVerifyEmailSent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteMarkVerifyEmailSent, id, currentTimestamp())
+	return err
+}
+
+const sqliteReleaseOutboxEvent = `-- name: ReleaseOutboxEvent :exec
+UPDATE outbox_events
+SET leased_until = '0001-01-01 00:00:00+00:00'
+WHERE id = ?
+`
+
+func (q *sqliteQueries) ReleaseOutboxEvent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteReleaseOutboxEvent, id)
+	return err
+}
+
+const sqliteReleaseVerifyEmail = `-- name: ReleaseVerifyEmail :exec
+UPDATE verify_emails
+SET leased_until = '0001-01-01 00:00:00+00:00'
+WHERE id = ?
+`
+
+func (q *sqliteQueries) ReleaseVerifyEmail(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, sqliteReleaseVerifyEmail, id)
+	return err
+}
+
+const sqliteReplayWebhookDelivery = `-- name: ReplayWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = ?2
+WHERE id = ?1
+RETURNING *
+`
+
+func (q *sqliteQueries) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, sqliteReplayWebhookDelivery, id, currentTimestamp())
+	return scanWebhookDelivery(row)
+}
+
+const sqliteSetTransferReversalOf = `-- name: SetTransferReversalOf :one
+UPDATE transfers
+SET reversal_of = ?1
+WHERE id = ?2
+RETURNING *
+`
+
+func (q *sqliteQueries) SetTransferReversalOf(ctx context.Context, arg SetTransferReversalOfParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, sqliteSetTransferReversalOf, arg.ReversalOf, arg.ID)
+	transfer, err := scanTransfer(row)
+	if err != nil {
+		return Transfer{}, q.constraintError(ctx, err, "transfers")
+	}
+	return transfer, nil
+}
+
+const sqliteSetUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = ?1, updated_at = ?3
+WHERE username = ?2 AND NOT is_totp_enabled
+RETURNING *
+`
+
+func (q *sqliteQueries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteSetUserTOTPSecret, arg.TotpSecret, arg.Username, currentTimestamp())
+	return scanUser(row)
+}
+
+const sqliteTakeRateLimitToken = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (
+    key, tokens, updated_at
+) VALUES (
+    ?1, ?2 - 1, ?4
+)
+ON CONFLICT (key) DO UPDATE
+SET tokens = MIN(?2, b.tokens + ?3 * (julianday(?4) - julianday(b.updated_at)) * 86400) - 1,
+    updated_at = ?4
+WHERE MIN(?2, b.tokens + ?3 * (julianday(?4) - julianday(b.updated_at)) * 86400) >= 1
+RETURNING *
+`
+
+func (q *sqliteQueries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (RateLimitBucket, error) {
+	row := q.db.QueryRowContext(ctx, sqliteTakeRateLimitToken, arg.Key, arg.Burst, arg.Rate, currentTimestamp())
+	return scanRateLimitBucket(row)
+}
+
+const sqliteUpdateAccount = `-- name: UpdateAccount :one
+UPDATE accounts SET balance = ?2, version = version + 1 WHERE id = ?1 AND version = ?3 RETURNING *
+`
+
+func (q *sqliteQueries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUpdateAccount, arg.ID, arg.Balance, arg.Version)
+	return scanAccount(row)
+}
+
+const sqliteUpdateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts SET status = ?1, version = version + 1 WHERE id = ?2 RETURNING *
+`
+
+func (q *sqliteQueries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUpdateAccountStatus, arg.Status, arg.ID)
+	account, err := scanAccount(row)
+	if err != nil {
+		return Account{}, q.constraintError(ctx, err, "accounts")
+	}
+	return account, nil
+}
+
+const sqliteUpdateBeneficiaryNickname = `-- name: UpdateBeneficiaryNickname :one
+UPDATE beneficiaries
+SET nickname = ?2
+WHERE id = ?1
+RETURNING *
+`
+
+func (q *sqliteQueries) UpdateBeneficiaryNickname(ctx context.Context, arg UpdateBeneficiaryNicknameParams) (Beneficiary, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUpdateBeneficiaryNickname, arg.ID, arg.Nickname)
+	beneficiary, err := scanBeneficiary(row)
+	if err != nil {
+		return Beneficiary{}, q.constraintError(ctx, err, "beneficiaries")
+	}
+	return beneficiary, nil
+}
+
+const sqliteUpdateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = ?1, password_changed_at = ?3, updated_at = ?3
+WHERE username = ?2
+RETURNING *
+`
+
+func (q *sqliteQueries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUpdateUserPassword, arg.HashedPassword, arg.Username, currentTimestamp())
+	return scanUser(row)
+}
+
+const sqliteUpdateWebhookDeliveryAttempt = `-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+    status = ?1,
+    attempts = attempts + 1,
+    last_status_code = ?2,
+    last_error = ?3,
+    next_attempt_at = ?4,
+    delivered_at = ?5
+WHERE id = ?6
+RETURNING *
+`
+
+func (q *sqliteQueries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUpdateWebhookDeliveryAttempt,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt.UTC().Truncate(time.Microsecond),
+		arg.DeliveredAt.UTC().Truncate(time.Microsecond),
+		arg.ID,
+	)
+	delivery, err := scanWebhookDelivery(row)
+	if err != nil {
+		return WebhookDelivery{}, q.constraintError(ctx, err, "webhook_deliveries")
+	}
+	return delivery, nil
+}
+
+const sqliteUsePasswordReset = `-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = ?1 AND NOT is_used AND expired_at > ?2
+RETURNING *
+`
+
+func (q *sqliteQueries) UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUsePasswordReset, tokenHash, currentTimestamp())
+	return scanPasswordReset(row)
+}
+
+const sqliteUseRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET is_used = true
+WHERE username = ?1 AND code_hash = ?2 AND NOT is_used
+RETURNING *
+`
+
+func (q *sqliteQueries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUseRecoveryCode, arg.Username, arg.CodeHash)
+	return scanRecoveryCode(row)
+}
+
+const sqliteUseUserTOTPStep = `-- name: UseUserTOTPStep :one
+UPDATE users
+SET totp_last_step = ?1
+WHERE username = ?2 AND is_totp_enabled AND totp_last_step < ?1
+RETURNING *
+`
+
+func (q *sqliteQueries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUseUserTOTPStep, arg.TotpLastStep, arg.Username)
+	return scanUser(row)
+}
+
+const sqliteUseVerifyEmail = `-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = ?1 AND secret_code = ?2 AND NOT is_used AND expired_at > ?3
+RETURNING *
+`
+
+func (q *sqliteQueries) UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRowContext(ctx, sqliteUseVerifyEmail, arg.ID, arg.SecretCode, currentTimestamp())
+	return scanVerifyEmail(row)
+}
+
+const sqliteVerifyBeneficiary = `-- name: VerifyBeneficiary :one
+UPDATE beneficiaries
+SET is_verified = true, verified_at = CASE WHEN is_verified THEN verified_at ELSE ?2 END
+WHERE id = ?1
+RETURNING *
+`
+
+// VerifyBeneficiary keeps the original verification time when verifying twice, as the Postgres query
+func (q *sqliteQueries) VerifyBeneficiary(ctx context.Context, id int64) (Beneficiary, error) {
+	row := q.db.QueryRowContext(ctx, sqliteVerifyBeneficiary, id, currentTimestamp())
+	return scanBeneficiary(row)
+}
+
+const sqliteVerifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true, updated_at = ?3
+WHERE username = ?1 AND email = ?2
+RETURNING *
+`
+
+func (q *sqliteQueries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, sqliteVerifyUserEmail, arg.Username, arg.Email, currentTimestamp())
+	return scanUser(row)
+}
@@ -3,48 +3,214 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Store interface {
 	Querier
+	CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
 	CreateTransferTx(ctx context.Context, arg CreateTransferTxParams) (CreateTransferTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error)
+	AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	ReverseTransferTx(ctx context.Context, transferID int64) (CreateTransferTxResult, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	SendVerifyEmailsTx(ctx context.Context, arg SendVerifyEmailsTxParams) (SendVerifyEmailsTxResult, error)
+	CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (CreatePasswordResetTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (ConfirmTOTPTxResult, error)
+	VerifyTOTPTx(ctx context.Context, arg VerifyTOTPTxParams) (VerifyTOTPTxResult, error)
+	GetSchemaVersion(ctx context.Context) (SchemaVersion, error)
+	Ping(ctx context.Context) error
+}
+
+// Observer is notified of every store transaction and of the transfers they commit.
+// Transactions run again after a serialization failure or a deadlock are observed once per attempt,
+// and each retry is reported with the name of the Postgres error that caused it.
+type Observer interface {
+	ObserveTx(duration time.Duration, committed bool)
+	TxRetried(reason string)
+	TransferCreated(currency string, amount int64)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveTx(time.Duration, bool) {}
+func (nopObserver) TxRetried(string) {}
+func (nopObserver) TransferCreated(string, int64) {}
+
+type isolationLevelKey struct{}
+
+// WithIsolationLevel returns a context running the store transactions started with it at level,
+// instead of the database default. Only the Postgres store honours it: SQLite and memory store
+// transactions are serializable already.
+func WithIsolationLevel(ctx context.Context, level sql.IsolationLevel) context.Context {
+	return context.WithValue(ctx, isolationLevelKey{}, level)
+}
+
+func isolationLevel(ctx context.Context) sql.IsolationLevel {
+	level, _ := ctx.Value(isolationLevelKey{}).(sql.IsolationLevel)
+	return level
+}
+
+// RetryPolicy bounds how many times a transaction is run when it fails with a retryable error,
+// and how long to wait between attempts: a random delay up to BaseDelay, doubling on every attempt
+// up to MaxDelay, so conflicting transactions do not collide again.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay time.Duration
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay: 10 * time.Millisecond,
+	MaxDelay: 500 * time.Millisecond,
+}
+
+func (policy RetryPolicy) delay(attempt int) time.Duration {
+	delay := policy.MaxDelay
+	if shift := attempt - 1; shift < 32 && policy.BaseDelay<<shift < delay {
+		delay = policy.BaseDelay << shift
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// retryableErrors are the Postgres errors of transactions that may succeed when run again
+var retryableErrors = map[string]bool{
+	"serialization_failure": true,
+	"deadlock_detected": true,
+}
+
+// retryReason returns the name of the Postgres error err is if the transaction failing with it can be retried
+func retryReason(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && retryableErrors[pqErr.Code.Name()] {
+		return pqErr.Code.Name(), true
+	}
+	return "", false
+}
+
+// isUniqueViolation reports whether err is the violation of the unique constraint named constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == constraint
+}
+
+// txStore implements the transactions of the store on top of execTx, so they are shared by every backend
+type txStore struct {
+	// execTx runs fn atomically: the changes made through q are kept if it returns nil, discarded otherwise
+	execTx func(ctx context.Context, fn func(q Querier) error) error
+	observer Observer
 }
 
 type SQLStore struct {
 	*Queries
+	txStore
 	db *sql.DB
+	retry RetryPolicy
 }
 
 func NewStore(db *sql.DB) Store {
-	return &SQLStore{
+	return NewObservedStore(db, nopObserver{})
+}
+
+// NewObservedStore creates a store reporting its transactions to observer
+func NewObservedStore(db *sql.DB, observer Observer) Store {
+	store := &SQLStore{
 		db: db,
-		Queries: New(db),
+		Queries: New(instrument(db, postgresSystem, nil)),
+		retry: DefaultRetryPolicy,
 	}
+	store.txStore = txStore{execTx: store.execTx, observer: observer}
+	return store
 }
 
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil) // use default IsolationLevel
-	if err != nil {
-		return err
-	}
+// execTx runs fn within a database transaction, at the isolation level of ctx.
+// Transactions failing with a serialization failure or a deadlock are rolled back and run again,
+// up to the attempts of the store retry policy, so fn must not have effects outside of q.
+func (store *SQLStore) execTx(ctx context.Context, fn func(Querier) error) error {
+	opts := &sql.TxOptions{Isolation: isolationLevel(ctx)}
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		reason, ok := retryReason(err)
+		if !ok || attempt >= store.retry.MaxAttempts {
+			return err
+		}
 
-	q := New(tx)
-	err = fn(q)
-	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		store.observer.TxRetried(reason)
+		delay := store.retry.delay(attempt)
+		logger.FromContext(ctx).DebugContext(ctx, "db transaction retried", "reason", reason, "attempt", attempt, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
 		}
-		return err
 	}
+}
+
+// runTx makes a single attempt to run fn within a database transaction
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(Querier) error) error {
+	return traceTx(ctx, store.observer, func(ctx context.Context, span trace.Span) error {
+		tx, err := store.db.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		q := New(instrument(tx, postgresSystem, span))
+		err = fn(q)
+		if err != nil {
+			span.AddEvent("rollback")
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			}
+			logger.FromContext(ctx).DebugContext(ctx, "db transaction rolled back", "error", err)
+			return err
+		}
 
-	return tx.Commit()
+		span.AddEvent("commit")
+		return tx.Commit()
+	})
+}
+
+// traceTx runs the transaction tx as a span, which it records commit or rollback events in,
+// and reports its duration and outcome to observer
+func traceTx(ctx context.Context, observer Observer, tx func(ctx context.Context, span trace.Span) error) (err error) {
+	start := time.Now()
+	ctx, span := tracer().Start(ctx, "execTx")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		observer.ObserveTx(time.Since(start), err == nil)
+	}()
+
+	return tx(ctx, span)
 }
 
 type CreateTransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID int64 `json:"to_account_id"`
 	Amount int64 `json:"amount"`
+	// StepUp, if set, is the second factor of the owner of the source account the transfer is authorized with
+	StepUp *VerifyTOTPTxParams `json:"-"`
 }
 
 type CreateTransferTxResult struct {
@@ -55,61 +221,90 @@ type CreateTransferTxResult struct {
 	ToEntry Entry `json:"to_entry"`
 }
 
-// Creating a transfer implies 5 steps that must happen within a transaction:
+// Creating a transfer implies 7 steps that must happen within a transaction:
 //	- create transfer record
 //	- create entry record for from_account with negative amount
 //	- create entry record for to_account with positive amount
 //	- update from_account balance
 //	- update to_account balance
-func (store *SQLStore) CreateTransferTx(ctx context.Context, arg CreateTransferTxParams) (CreateTransferTxResult, error) {
+//	- record EventTransferCreated in the outbox
+//	- use up the StepUp code, once the transfer is known to succeed, so a failing transfer does not use it
+// Frozen or closed accounts make the whole transaction fail with ErrAccountFrozen or ErrAccountClosed,
+// and so does a from_account balance going negative, with ErrInsufficientFunds, and a wrong StepUp code,
+// with ErrInvalidTOTPCode, or ErrTOTPNotEnrolled if the owner has no two-factor authentication.
+func (store *txStore) CreateTransferTx(ctx context.Context, arg CreateTransferTxParams) (CreateTransferTxResult, error) {
 	var result CreateTransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, func(q Querier) error {
 		var err error
-
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID: arg.ToAccountID,
-			Amount: arg.Amount,
-		})
+		result, err = transferMoney(ctx, q, arg)
 		if err != nil {
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount: -arg.Amount,
-		})
-		if err != nil {
-			return err
+		if result.FromAccount.Balance < 0 {
+			return ErrInsufficientFunds
 		}
-
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount: arg.Amount,
-		})
-		if err != nil {
-			return err
+		if arg.StepUp != nil {
+			if _, err := verifyTOTP(ctx, q, *arg.StepUp); err != nil {
+				return err
+			}
 		}
+		return recordTransferCreated(ctx, q, result.Transfer)
+	})
+	if err == nil {
+		store.observer.TransferCreated(result.FromAccount.Currency, arg.Amount)
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		return nil
+func transferMoney(ctx context.Context, q Querier, arg CreateTransferTxParams) (result CreateTransferTxResult, err error) {
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount: arg.Amount,
 	})
+	if err != nil {
+		return
+	}
 
-	return result, err
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount: -arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount: arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return
+	}
+
+	// balances are updated first so the status is checked while holding the row locks
+	if err = CheckAccountActive(result.FromAccount); err != nil {
+		return
+	}
+	err = CheckAccountActive(result.ToAccount)
+	return
 }
 
 func addMoney(
 	ctx context.Context, 
-	q *Queries, 
+	q Querier, 
 	accountID1 int64, 
 	amount1 int64, 
 	accountID2 int64, 
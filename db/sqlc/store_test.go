@@ -2,16 +2,21 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/gorkaio/simplebank/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
-func TestTransferTx(t *testing.T) {
-	store := NewStore(testDB)
+func testTransferTx(t *testing.T, store Store) {
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
 
 	// run n concurrent transfer transactions
 	n := 5
@@ -95,10 +100,10 @@ func TestTransferTx(t *testing.T) {
 	}
 
 	// check final updated balances
-	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	require.Equal(t, account1.Balance - int64(n) * amount, updatedAccount1.Balance)
@@ -106,11 +111,10 @@ func TestTransferTx(t *testing.T) {
 }
 
 // When transfer transactions for the two same accounts run concurrently, it might result in a deadlock
-func TestTransferTxDeadlock(t *testing.T) {
-	store := NewStore(testDB)
+func testTransferTxDeadlock(t *testing.T, store Store) {
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
 
 	// run n concurrent transfer transactions
 	n := 10
@@ -144,12 +148,218 @@ func TestTransferTxDeadlock(t *testing.T) {
 	}
 
 	// check final updated balances
-	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
-}
\ No newline at end of file
+}
+
+func testTransferTxInsufficientFunds(t *testing.T, store Store) {
+
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	_, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 101,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the whole transaction is rolled back
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+type testObserver struct {
+	commits int
+	rollbacks int
+	retries int
+	amounts map[string]int64
+}
+
+func (observer *testObserver) ObserveTx(duration time.Duration, committed bool) {
+	if committed {
+		observer.commits++
+	} else {
+		observer.rollbacks++
+	}
+}
+
+func (observer *testObserver) TxRetried(reason string) {
+	observer.retries++
+}
+
+func (observer *testObserver) TransferCreated(currency string, amount int64) {
+	observer.amounts[currency] += amount
+}
+
+func TestTransferTxObserved(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			observer := &testObserver{amounts: map[string]int64{}}
+			store := backend.newObservedStore(observer)
+
+			account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+			account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+			_, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID: account2.ID,
+				Amount: 60,
+			})
+			require.NoError(t, err)
+
+			_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID: account2.ID,
+				Amount: 60,
+			})
+			require.ErrorIs(t, err, ErrInsufficientFunds)
+
+			require.Equal(t, 1, observer.commits)
+			require.Equal(t, 1, observer.rollbacks)
+			require.Equal(t, map[string]int64{util.EUR: 60}, observer.amounts)
+		})
+	}
+}
+
+func TestTransferTxTraced(t *testing.T) {
+	requirePostgres(t)
+	spans := newTestSpanRecorder(t)
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+
+	for _, amount := range []int64{60, 60} {
+		store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID: account2.ID,
+			Amount: amount,
+		})
+	}
+
+	var events []string
+	queries := map[string]bool{}
+	for _, span := range spans.Ended() {
+		if span.Name() != "execTx" {
+			queries[span.Name()] = true
+			continue
+		}
+		for _, event := range span.Events() {
+			events = append(events, event.Name)
+		}
+	}
+	require.Equal(t, []string{"commit", "rollback"}, events)
+	require.True(t, queries["CreateTransfer"])
+	require.True(t, queries["AddAccountBalance"])
+}
+
+func TestTransferTxRetried(t *testing.T) {
+	requirePostgres(t)
+	observer := &testObserver{amounts: map[string]int64{}}
+	store := NewObservedStore(testDB, observer).(*SQLStore)
+	store.retry.MaxAttempts = 100
+
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 1000)
+
+	// serializable transactions updating the same accounts fail instead of waiting for each other
+	ctx := WithIsolationLevel(context.Background(), sql.LevelSerializable)
+	n := 10
+	amount := int64(10)
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i % 2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			_, err := store.CreateTransferTx(ctx, CreateTransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID: toAccountID,
+				Amount: amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	require.NotZero(t, observer.retries)
+	require.Equal(t, n, observer.commits)
+	require.Equal(t, observer.retries, observer.rollbacks)
+	require.Equal(t, map[string]int64{util.EUR: int64(n) * amount}, observer.amounts)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestRetryReason(t *testing.T) {
+	testCases := []struct {
+		err error
+		reason string
+		ok bool
+	}{
+		{err: &pq.Error{Code: "40001"}, reason: "serialization_failure", ok: true},
+		{err: &pq.Error{Code: "40P01"}, reason: "deadlock_detected", ok: true},
+		{err: fmt.Errorf("transfer: %w", &pq.Error{Code: "40P01"}), reason: "deadlock_detected", ok: true},
+		{err: &pq.Error{Code: "23505"}},
+		{err: ErrInsufficientFunds},
+		{err: errors.New("connection lost")},
+		{},
+	}
+
+	for _, tc := range testCases {
+		reason, ok := retryReason(tc.err)
+		require.Equal(t, tc.reason, reason, tc.err)
+		require.Equal(t, tc.ok, ok, tc.err)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for attempt, max := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		4: 50 * time.Millisecond,
+		64: 50 * time.Millisecond,
+	} {
+		for i := 0; i < 100; i++ {
+			delay := policy.delay(attempt)
+			require.GreaterOrEqual(t, delay, time.Duration(0))
+			require.LessOrEqual(t, delay, max)
+		}
+	}
+}
+
+func TestWithIsolationLevel(t *testing.T) {
+	require.Equal(t, sql.LevelDefault, isolationLevel(context.Background()))
+
+	ctx := WithIsolationLevel(context.Background(), sql.LevelSerializable)
+	require.Equal(t, sql.LevelSerializable, isolationLevel(ctx))
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gorkaio/simplebank/totp"
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidTOTPCode = errors.New("two-factor code is invalid or used")
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets when confirming the enrollment,
+// each of them standing in for a TOTP code once
+const RecoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCode returns a random code formatted as xxxx-xxxx-xxxx-xxxx, to be written down
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cannot generate recovery code: %w", err)
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// hashRecoveryCode returns the hash stored for a recovery code, ignoring the case and the dashes it is typed with
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+type ConfirmTOTPTxParams struct {
+	Username string `json:"username"`
+	Code string `json:"-"`
+}
+
+type ConfirmTOTPTxResult struct {
+	User User `json:"user"`
+	// RecoveryCodes are shown to the user once, only their hashes are stored
+	RecoveryCodes []string `json:"-"`
+}
+
+// Confirming the enrollment in two-factor authentication happens within a transaction:
+//	- check the code against the secret the user enrolled with
+//	- enable two-factor authentication, recording the step of the code so it cannot be used again
+//	- replace the recovery codes of the user by new ones
+// Users not enrolled fail with ErrTOTPNotEnrolled, already enabled with ErrTOTPAlreadyEnabled
+// and wrong codes with ErrInvalidTOTPCode.
+func (store *txStore) ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (ConfirmTOTPTxResult, error) {
+	var result ConfirmTOTPTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		user, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if user.IsTotpEnabled {
+			return ErrTOTPAlreadyEnabled
+		}
+		if user.TotpSecret == "" {
+			return ErrTOTPNotEnrolled
+		}
+
+		step, ok := totp.Validate(user.TotpSecret, arg.Code, currentTimestamp())
+		if !ok {
+			return ErrInvalidTOTPCode
+		}
+
+		result.User, err = q.EnableUserTOTP(ctx, EnableUserTOTPParams{
+			TotpLastStep: step,
+			Username: user.Username,
+			TotpSecret: user.TotpSecret,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTOTPAlreadyEnabled
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteRecoveryCodes(ctx, user.Username); err != nil {
+			return err
+		}
+		result.RecoveryCodes = make([]string, 0, RecoveryCodeCount)
+		for len(result.RecoveryCodes) < RecoveryCodeCount {
+			code, err := generateRecoveryCode()
+			if err != nil {
+				return err
+			}
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: user.Username,
+				CodeHash: hashRecoveryCode(code),
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+type VerifyTOTPTxParams struct {
+	Username string `json:"username"`
+	// Code is the TOTP code of the user, or empty when a RecoveryCode is given instead
+	Code string `json:"-"`
+	RecoveryCode string `json:"-"`
+}
+
+type VerifyTOTPTxResult struct {
+	User User `json:"user"`
+}
+
+// Verifying the second factor of a user happens within a transaction:
+//	- check the TOTP code and record its step, so neither it nor an earlier one can be used again
+//	- or mark the recovery code used
+// Users without two-factor authentication fail with ErrTOTPNotEnrolled and wrong or used codes with ErrInvalidTOTPCode.
+func (store *txStore) VerifyTOTPTx(ctx context.Context, arg VerifyTOTPTxParams) (VerifyTOTPTxResult, error) {
+	var result VerifyTOTPTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		var err error
+		result.User, err = verifyTOTP(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// verifyTOTP checks the TOTP or recovery code of the user and uses it up, within the transaction of q
+func verifyTOTP(ctx context.Context, q Querier, arg VerifyTOTPTxParams) (User, error) {
+	user, err := q.GetUser(ctx, arg.Username)
+	if err != nil {
+		return user, err
+	}
+	if !user.IsTotpEnabled {
+		return user, ErrTOTPNotEnrolled
+	}
+
+	if arg.RecoveryCode != "" {
+		_, err = q.UseRecoveryCode(ctx, UseRecoveryCodeParams{
+			Username: user.Username,
+			CodeHash: hashRecoveryCode(arg.RecoveryCode),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, ErrInvalidTOTPCode
+		}
+		return user, err
+	}
+
+	step, ok := totp.Validate(user.TotpSecret, arg.Code, currentTimestamp())
+	if !ok {
+		return user, ErrInvalidTOTPCode
+	}
+	user, err = q.UseUserTOTPStep(ctx, UseUserTOTPStepParams{
+		TotpLastStep: step,
+		Username: user.Username,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrInvalidTOTPCode
+	}
+	return user, err
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/totp"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// enrollRandomUser returns a new user enrolled in two-factor authentication, not confirmed yet
+func enrollRandomUser(t *testing.T, store Store) User {
+	user := createRandomUser(t, store)
+	require.Empty(t, user.TotpSecret)
+	require.False(t, user.IsTotpEnabled)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	enrolled, err := store.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		TotpSecret: secret,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, secret, enrolled.TotpSecret)
+	require.False(t, enrolled.IsTotpEnabled)
+	return enrolled
+}
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+	return code
+}
+
+// confirmRandomUser returns a new user with two-factor authentication enabled, and its recovery codes
+func confirmRandomUser(t *testing.T, store Store) (User, []string) {
+	user := enrollRandomUser(t, store)
+	result, err := store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, totp.Step(time.Now())),
+	})
+	require.NoError(t, err)
+	return result.User, result.RecoveryCodes
+}
+
+func testConfirmTOTPTx(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+	_, err := store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{Username: user.Username, Code: "123456"})
+	require.ErrorIs(t, err, ErrTOTPNotEnrolled)
+
+	user = enrollRandomUser(t, store)
+	step := totp.Step(time.Now())
+	_, err = store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step+5),
+	})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	result, err := store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step),
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsTotpEnabled)
+	require.Equal(t, user.TotpSecret, result.User.TotpSecret)
+	require.GreaterOrEqual(t, result.User.TotpLastStep, step-1)
+	require.Len(t, result.RecoveryCodes, RecoveryCodeCount)
+	for _, code := range result.RecoveryCodes {
+		require.Len(t, code, 19)
+	}
+
+	_, err = store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step+1),
+	})
+	require.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+
+	// the secret cannot be replaced once confirmed
+	_, err = store.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{TotpSecret: "other", Username: user.Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.ConfirmTOTPTx(context.Background(), ConfirmTOTPTxParams{Username: "missing", Code: "123456"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testVerifyTOTPTx(t *testing.T, store Store) {
+	user, _ := confirmRandomUser(t, store)
+	step := user.TotpLastStep
+
+	// the code confirming the enrollment, and the earlier ones, cannot be used again
+	_, err := store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step),
+	})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	result, err := store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step+1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, step+1, result.User.TotpLastStep)
+
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{
+		Username: user.Username,
+		Code: totpCode(t, user.TotpSecret, step+1),
+	})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: user.Username, Code: "abcdef"})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	enrolled := enrollRandomUser(t, store)
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{
+		Username: enrolled.Username,
+		Code: totpCode(t, enrolled.TotpSecret, totp.Step(time.Now())),
+	})
+	require.ErrorIs(t, err, ErrTOTPNotEnrolled)
+}
+
+func testVerifyTOTPTxRecoveryCode(t *testing.T, store Store) {
+	user, recoveryCodes := confirmRandomUser(t, store)
+
+	// codes are accepted however they are typed, once
+	typed := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", ""))
+	result, err := store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: user.Username, RecoveryCode: typed})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: user.Username, RecoveryCode: recoveryCodes[0]})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	// the codes of other users are not accepted
+	other, otherCodes := confirmRandomUser(t, store)
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: user.Username, RecoveryCode: otherCodes[0]})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: other.Username, RecoveryCode: recoveryCodes[1]})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	_, err = store.VerifyTOTPTx(context.Background(), VerifyTOTPTxParams{Username: user.Username, RecoveryCode: recoveryCodes[1]})
+	require.NoError(t, err)
+}
+
+func testCreateTransferTxStepUp(t *testing.T, store Store) {
+	user, _ := confirmRandomUser(t, store)
+	from, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner: user.Username,
+		Balance: 100,
+		Currency: util.EUR,
+		AccountType: AccountTypeChecking,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+	to := createRandomAccountWithCurrency(t, store, util.EUR, 0)
+	stepUp := &VerifyTOTPTxParams{Username: user.Username, Code: totpCode(t, user.TotpSecret, user.TotpLastStep+1)}
+
+	// a failing transfer does not use the code up
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID: to.ID,
+		Amount: 200,
+		StepUp: stepUp,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	result, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID: to.ID,
+		Amount: 60,
+		StepUp: stepUp,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(40), result.FromAccount.Balance)
+
+	// the code is used up with the transfer, and a transfer with a used code is rolled back
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID: to.ID,
+		Amount: 10,
+		StepUp: stepUp,
+	})
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	account, err := store.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), account.Balance)
+
+	// owners without two-factor authentication cannot authorize transfers with it
+	other := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	_, err = store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID: to.ID,
+		Amount: 10,
+		StepUp: &VerifyTOTPTxParams{Username: other.Owner, Code: "123456"},
+	})
+	require.ErrorIs(t, err, ErrTOTPNotEnrolled)
+}
+
+func testCreateRecoveryCode(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+
+	recoveryCode, err := store.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: hashRecoveryCode("abcd-efgh"),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, recoveryCode.ID)
+	require.Equal(t, user.Username, recoveryCode.Username)
+	require.False(t, recoveryCode.IsUsed)
+	require.NotZero(t, recoveryCode.CreatedAt)
+
+	_, err = store.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: recoveryCode.CodeHash,
+	})
+	requirePQError(t, err, "23505", "recovery_codes_username_code_hash_key")
+
+	_, err = store.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username: "missing",
+		CodeHash: recoveryCode.CodeHash,
+	})
+	requirePQError(t, err, "23503", "recovery_codes_username_fkey")
+
+	require.NoError(t, store.DeleteRecoveryCodes(context.Background(), user.Username))
+	_, err = store.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: recoveryCode.CodeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
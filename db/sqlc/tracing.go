@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/gorkaio/simplebank/db/sqlc"
+
+// database systems reported by the query spans, as named by the OpenTelemetry conventions
+const (
+	postgresSystem = "postgresql"
+	sqliteSystem = "sqlite"
+)
+
+// tracer is looked up on every use so it follows the global tracer provider if it is replaced
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// tracingDBTX starts a span for every query, named after the sqlc query name
+type tracingDBTX struct {
+	DBTX
+	system string
+	// parent is the span query spans are started under, if set, instead of the span in their context.
+	// Transactions use it because the queries run inside them are given the context of the caller.
+	parent trace.Span
+}
+
+// instrument wraps dbtx, a connection to a database of the given system, so its queries are traced and logged
+func instrument(dbtx DBTX, system string, parent trace.Span) DBTX {
+	return tracingDBTX{DBTX: loggingDBTX{dbtx}, system: system, parent: parent}
+}
+
+func (t tracingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.startSpan(ctx, query)
+	result, err := t.DBTX.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t tracingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.startSpan(ctx, query)
+	stmt, err := t.DBTX.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+func (t tracingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.startSpan(ctx, query)
+	rows, err := t.DBTX.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t tracingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.startSpan(ctx, query)
+	row := t.DBTX.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (t tracingDBTX) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if t.parent != nil {
+		ctx = trace.ContextWithSpan(ctx, t.parent)
+	}
+	return tracer().Start(ctx, queryName(query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", t.system),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeDBTX answers every query with err, without a database
+type fakeDBTX struct {
+	err error
+}
+
+func (f fakeDBTX) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return &sql.Row{}
+}
+
+func newTestSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	spans := tracetest.NewSpanRecorder()
+	defaultProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(defaultProvider) })
+	return spans
+}
+
+func TestTracingDBTX(t *testing.T) {
+	testCases := []struct {
+		name string
+		err error
+		checkSpan func(t *testing.T, span sdktrace.ReadOnlySpan)
+	}{
+		{
+			name: "OK",
+			checkSpan: func(t *testing.T, span sdktrace.ReadOnlySpan) {
+				require.Equal(t, codes.Unset, span.Status().Code)
+			},
+		},
+		{
+			name: "NoRows",
+			err: sql.ErrNoRows,
+			checkSpan: func(t *testing.T, span sdktrace.ReadOnlySpan) {
+				require.Equal(t, codes.Unset, span.Status().Code)
+			},
+		},
+		{
+			name: "Error",
+			err: errors.New("connection lost"),
+			checkSpan: func(t *testing.T, span sdktrace.ReadOnlySpan) {
+				require.Equal(t, codes.Error, span.Status().Code)
+				require.Equal(t, "connection lost", span.Status().Description)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			spans := newTestSpanRecorder(t)
+
+			q := New(instrument(fakeDBTX{err: tc.err}, postgresSystem, nil))
+			_, err := q.db.ExecContext(context.Background(), deleteAccount, int64(1))
+			require.ErrorIs(t, err, tc.err)
+
+			ended := spans.Ended()
+			require.Len(t, ended, 1)
+			require.Equal(t, "DeleteAccount", ended[0].Name())
+			require.Equal(t, trace.SpanKindClient, ended[0].SpanKind())
+			require.Subset(t, ended[0].Attributes(), []attribute.KeyValue{
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.statement", deleteAccount),
+			})
+			tc.checkSpan(t, ended[0])
+		})
+	}
+}
+
+func TestTracingDBTXParent(t *testing.T) {
+	spans := newTestSpanRecorder(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "execTx")
+	q := New(instrument(fakeDBTX{}, postgresSystem, parent))
+
+	// the context given to the query carries no span, as happens when fn ignores the context of execTx
+	_, err := q.db.QueryContext(context.Background(), getAccount, int64(1))
+	require.NoError(t, err)
+	parent.End()
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	require.Equal(t, "GetAccount", ended[0].Name())
+	require.Equal(t, trace.SpanContextFromContext(ctx).SpanID(), ended[0].Parent().SpanID())
+}
@@ -12,7 +12,7 @@ INSERT INTO transfers (
     from_account_id, to_account_id, amount 
 ) VALUES (
     $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of
 `
 
 type CreateTransferParams struct {
@@ -30,12 +30,13 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,12 +49,51 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
+WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+const getTransferReversal = `-- name: GetTransferReversal :one
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
+WHERE reversal_of = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferReversal(ctx context.Context, reversalOf int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferReversal, reversalOf)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const listTranfers = `-- name: ListTranfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of FROM transfers
 WHERE
     (CASE WHEN $1::bigint != 0 THEN from_account_id = $1::bigint ELSE TRUE END) AND
     (CASE WHEN $2::bigint != 0 THEN to_account_id = $2::bigint ELSE TRUE END)
@@ -89,6 +129,7 @@ func (q *Queries) ListTranfers(ctx context.Context, arg ListTranfersParams) ([]T
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ReversalOf,
 		); err != nil {
 			return nil, err
 		}
@@ -102,3 +143,29 @@ func (q *Queries) ListTranfers(ctx context.Context, arg ListTranfersParams) ([]T
 	}
 	return items, nil
 }
+
+const setTransferReversalOf = `-- name: SetTransferReversalOf :one
+UPDATE transfers
+SET reversal_of = $1
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of
+`
+
+type SetTransferReversalOfParams struct {
+	ReversalOf int64 `json:"reversal_of"`
+	ID         int64 `json:"id"`
+}
+
+func (q *Queries) SetTransferReversalOf(ctx context.Context, arg SetTransferReversalOfParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, setTransferReversalOf, arg.ReversalOf, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+	)
+	return i, err
+}
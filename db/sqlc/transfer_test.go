@@ -8,21 +8,21 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomTransfer(t *testing.T) Transfer {
-	account_from := createRandomAccount(t)
-	account_to := createRandomAccount(t)
+func createRandomTransfer(t *testing.T, store Store) Transfer {
+	account_from := createRandomAccount(t, store)
+	account_to := createRandomAccount(t, store)
 
-	return createRandomTransferForAccounts(t, account_from, account_to)
+	return createRandomTransferForAccounts(t, store, account_from, account_to)
 }
 
-func createRandomTransferForAccounts(t *testing.T, account_from Account, account_to Account) Transfer {
+func createRandomTransferForAccounts(t *testing.T, store Store, account_from Account, account_to Account) Transfer {
 	arg := CreateTransferParams{
 		FromAccountID: account_from.ID,
 		ToAccountID: account_to.ID,
 		Amount: util.RandomMoney(),
 	}
 
-	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
+	transfer, err := store.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer)
 
@@ -36,14 +36,14 @@ func createRandomTransferForAccounts(t *testing.T, account_from Account, account
 	return transfer
 }
 
-func TestCreateTransfer(t *testing.T) {
-	createRandomTransfer(t)
+func testCreateTransfer(t *testing.T, store Store) {
+	createRandomTransfer(t, store)
 }
 
-func TestGetTransfer(t *testing.T) {
-	transfer1 := createRandomTransfer(t)
+func testGetTransfer(t *testing.T, store Store) {
+	transfer1 := createRandomTransfer(t, store)
 
-	transfer2, err := testQueries.GetTransfer(context.Background(), transfer1.ID)
+	transfer2, err := store.GetTransfer(context.Background(), transfer1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer2)
 
@@ -54,9 +54,9 @@ func TestGetTransfer(t *testing.T) {
 	require.Equal(t, transfer2.CreatedAt, transfer1.CreatedAt)
 }
 
-func TestListTransfers(t *testing.T) {
+func testListTransfers(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		createRandomTransfer(t, store)
 	}
 
 	arg := ListTranfersParams{
@@ -64,7 +64,7 @@ func TestListTransfers(t *testing.T) {
 		Offset: 5,
 	}
 
-	transfers, err := testQueries.ListTranfers(context.Background(), arg)
+	transfers, err := store.ListTranfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
@@ -73,15 +73,15 @@ func TestListTransfers(t *testing.T) {
 	}
 }
 
-func TestListTransfersFromAccount(t *testing.T) {
+func testListTransfersFromAccount(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		createRandomTransfer(t, store)
 	}
 
-	account_from := createRandomAccount(t)
+	account_from := createRandomAccount(t, store)
 	for i := 0; i < 10; i++ {
-		account_to := createRandomAccount(t)
-		createRandomTransferForAccounts(t, account_from, account_to)
+		account_to := createRandomAccount(t, store)
+		createRandomTransferForAccounts(t, store, account_from, account_to)
 	}
 
 	arg := ListTranfersParams{
@@ -90,7 +90,7 @@ func TestListTransfersFromAccount(t *testing.T) {
 		Offset: 5,
 	}
 
-	transfers, err := testQueries.ListTranfers(context.Background(), arg)
+	transfers, err := store.ListTranfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
@@ -100,15 +100,15 @@ func TestListTransfersFromAccount(t *testing.T) {
 	}
 }
 
-func TestListTransfersToAccount(t *testing.T) {
+func testListTransfersToAccount(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		createRandomTransfer(t, store)
 	}
 
-	account_to := createRandomAccount(t)
+	account_to := createRandomAccount(t, store)
 	for i := 0; i < 10; i++ {
-		account_from := createRandomAccount(t)
-		createRandomTransferForAccounts(t, account_from, account_to)
+		account_from := createRandomAccount(t, store)
+		createRandomTransferForAccounts(t, store, account_from, account_to)
 	}
 
 	arg := ListTranfersParams{
@@ -117,7 +117,7 @@ func TestListTransfersToAccount(t *testing.T) {
 		Offset: 5,
 	}
 
-	transfers, err := testQueries.ListTranfers(context.Background(), arg)
+	transfers, err := store.ListTranfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
@@ -127,15 +127,15 @@ func TestListTransfersToAccount(t *testing.T) {
 	}
 }
 
-func TestListTransfersBetweenAccounts(t *testing.T) {
+func testListTransfersBetweenAccounts(t *testing.T, store Store) {
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		createRandomTransfer(t, store)
 	}
 
-	account_to := createRandomAccount(t)
-	account_from := createRandomAccount(t)
+	account_to := createRandomAccount(t, store)
+	account_from := createRandomAccount(t, store)
 	for i := 0; i < 10; i++ {
-		createRandomTransferForAccounts(t, account_from, account_to)
+		createRandomTransferForAccounts(t, store, account_from, account_to)
 	}
 
 	arg := ListTranfersParams{
@@ -145,7 +145,7 @@ func TestListTransfersBetweenAccounts(t *testing.T) {
 		Offset: 5,
 	}
 
-	transfers, err := testQueries.ListTranfers(context.Background(), arg)
+	transfers, err := store.ListTranfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+)
+
+// CreateUserTx creates a user, records EventUserCreated and queues the email verifying its address
+// within a transaction
+func (store *txStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q Querier) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordEvent(ctx, q, AggregateUser, user.Username, EventUserCreated, UserEvent{
+			Username: user.Username,
+			FullName: user.FullName,
+			Email: user.Email,
+			CreatedAt: user.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		return queueVerifyEmail(ctx, q, user)
+	})
+
+	return user, err
+}
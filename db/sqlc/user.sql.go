@@ -12,7 +12,7 @@ INSERT INTO users (
     username, hashed_password, full_name, email 
 ) VALUES (
     $1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, created_at, updated_at
+) RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -37,12 +37,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, created_at, updated_at FROM users
+SELECT username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -56,6 +63,238 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET is_disabled = true, disabled_at = now(), updated_at = now()
+WHERE username = $1 AND NOT is_disabled
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+func (q *Queries) DisableUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $1, password_changed_at = now(), updated_at = now()
+WHERE username = $2
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+type UpdateUserPasswordParams struct {
+	HashedPassword string `json:"hashed_password"`
+	Username       string `json:"username"`
+}
+
+// Revokes the access tokens issued before, by moving password_changed_at past them
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.HashedPassword, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true, updated_at = now()
+WHERE username = $1 AND email = $2
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+type VerifyUserEmailParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Flags the email of the user as verified, if it is still the address that was verified
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $1, updated_at = now()
+WHERE username = $2 AND NOT is_totp_enabled
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret string `json:"totp_secret"`
+	Username   string `json:"username"`
+}
+
+// Replaces the secret of an enrollment not confirmed yet
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserTOTPSecret, arg.TotpSecret, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true, totp_last_step = $1, updated_at = now()
+WHERE username = $2 AND totp_secret = $3 AND NOT is_totp_enabled
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+type EnableUserTOTPParams struct {
+	TotpLastStep int64  `json:"totp_last_step"`
+	Username     string `json:"username"`
+	TotpSecret   string `json:"totp_secret"`
+}
+
+// Confirms the enrollment, if the secret is still the one the code was checked against
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, arg.TotpLastStep, arg.Username, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useUserTOTPStep = `-- name: UseUserTOTPStep :one
+UPDATE users
+SET totp_last_step = $1
+WHERE username = $2 AND is_totp_enabled AND totp_last_step < $1
+RETURNING username, hashed_password, full_name, email, created_at, updated_at, is_disabled, disabled_at, is_email_verified, password_changed_at, totp_secret, is_totp_enabled, totp_last_step
+`
+
+type UseUserTOTPStepParams struct {
+	TotpLastStep int64  `json:"totp_last_step"`
+	Username     string `json:"username"`
+}
+
+// Records the step of an accepted code, unless it or a later one was used already
+func (q *Queries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, useUserTOTPStep, arg.TotpLastStep, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDisabled,
+		&i.DisabledAt,
+		&i.IsEmailVerified,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -9,7 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomUser(t *testing.T) User {
+func createRandomUser(t *testing.T, store Store) User {
 	hashedPassword, err := util.HashPassword("secret")
 	require.NoError(t, err)
 
@@ -20,7 +22,7 @@ func createRandomUser(t *testing.T) User {
 		Email: util.RandomEmail(),
 	}
 
-	user, err := testQueries.CreateUser(context.Background(), arg)
+	user, err := store.CreateUser(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, user)
 
@@ -35,13 +37,13 @@ func createRandomUser(t *testing.T) User {
 	return user
 }
 
-func TestCreateUser(t *testing.T) {
-	createRandomUser(t)
+func testCreateUser(t *testing.T, store Store) {
+	createRandomUser(t, store)
 }
 
-func TestGetUser(t *testing.T) {
-	user1 := createRandomUser(t)
-	user2, err := testQueries.GetUser(context.Background(), user1.Username)
+func testGetUser(t *testing.T, store Store) {
+	user1 := createRandomUser(t, store)
+	user2, err := store.GetUser(context.Background(), user1.Username)
 	require.NoError(t, err)
 	require.NotEmpty(t, user2)
 
@@ -51,4 +53,79 @@ func TestGetUser(t *testing.T) {
 	require.Equal(t, user1.Email, user2.Email)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 	require.WithinDuration(t, user1.UpdatedAt, user2.UpdatedAt, time.Second)
-}
\ No newline at end of file
+}
+
+func testDisableUser(t *testing.T, store Store) {
+	user1 := createRandomUser(t, store)
+	require.False(t, user1.IsDisabled)
+
+	user2, err := store.DisableUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, user2.IsDisabled)
+	require.WithinDuration(t, time.Now(), user2.DisabledAt, time.Second)
+
+	// disabling twice keeps the original time
+	_, err = store.DisableUser(context.Background(), user1.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testGetUserByEmail(t *testing.T, store Store) {
+	user1 := createRandomUser(t, store)
+	user2, err := store.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+
+	_, err = store.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testUpdateUserPassword(t *testing.T, store Store) {
+	user1 := createRandomUser(t, store)
+	require.True(t, user1.PasswordChangedAt.IsZero())
+
+	user2, err := store.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		HashedPassword: "new hashed",
+		Username: user1.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new hashed", user2.HashedPassword)
+	require.WithinDuration(t, time.Now(), user2.PasswordChangedAt, time.Second)
+	require.WithinDuration(t, time.Now(), user2.UpdatedAt, time.Second)
+
+	_, err = store.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		HashedPassword: "new hashed",
+		Username: util.RandomOwner(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testCreateUserTx(t *testing.T, store Store) {
+	arg := CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	}
+
+	user, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+
+	events := unpublishedEvents(t, store, AggregateUser, user.Username)
+	require.Len(t, events, 1)
+	require.Equal(t, EventUserCreated, events[0].EventType)
+	require.NotZero(t, events[0].CreatedAt)
+	require.True(t, events[0].PublishedAt.IsZero())
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, user.Username, payload["username"])
+	require.Equal(t, user.Email, payload["email"])
+	require.NotContains(t, payload, "hashed_password")
+
+	// a user that cannot be created records no event
+	arg.Email = util.RandomEmail()
+	_, err = store.CreateUserTx(context.Background(), arg)
+	requirePQError(t, err, "23505", "users_pkey")
+	require.Len(t, unpublishedEvents(t, store, AggregateUser, user.Username), 1)
+}
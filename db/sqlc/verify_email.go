@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrInvalidVerifyEmail = errors.New("email verification code is invalid, used or expired")
+
+// VerifyEmailDuration is how long the code sent to verify the email of a new user can be used
+const VerifyEmailDuration = 24 * time.Hour
+
+// generateSecretCode returns a random code proving the user got the email it was sent in
+func generateSecretCode() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("cannot generate secret code: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// queueVerifyEmail queues the email verifying the address of user, within the transaction creating the user,
+// so every user gets one
+func queueVerifyEmail(ctx context.Context, q Querier, user User) error {
+	secretCode, err := generateSecretCode()
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+		Username: user.Username,
+		Email: user.Email,
+		SecretCode: secretCode,
+		ExpiredAt: currentTimestamp().Add(VerifyEmailDuration),
+	})
+	return err
+}
+
+type VerifyEmailTxParams struct {
+	EmailID int64 `json:"email_id"`
+	SecretCode string `json:"secret_code"`
+}
+
+type VerifyEmailTxResult struct {
+	User User `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// Verifying an email happens within a transaction:
+//	- mark the verification used, so its code cannot be used again
+//	- flag the email of the user as verified
+// Wrong, used or expired codes, and addresses the user does not have anymore, fail with ErrInvalidVerifyEmail.
+func (store *txStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q Querier) error {
+		var err error
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID: arg.EmailID,
+			SecretCode: arg.SecretCode,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerifyEmail
+		}
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: result.VerifyEmail.Username,
+			Email: result.VerifyEmail.Email,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerifyEmail
+		}
+		return err
+	})
+
+	return result, err
+}
+
+type SendVerifyEmailsTxParams struct {
+	// Limit is the most emails sent
+	Limit int32 `json:"limit"`
+	// Lease is how long the emails are kept from other senders, sending stops halfway through it
+	Lease time.Duration `json:"lease"`
+	// Send delivers the verification email, it is marked sent once Send returns nil
+	Send func(ctx context.Context, verifyEmail VerifyEmail) error `json:"-"`
+}
+
+type SendVerifyEmailsTxResult struct {
+	Sent int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// Sending the verification emails happens in two short transactions around the sending:
+//	- claim the oldest emails not sent yet with a lease, skipping the expired ones and those other senders hold
+//	- send the emails outside of any transaction, until half the lease has run out
+//	- mark every email sent, so it is not sent again, and release the others
+// Emails Send fails on are sent again on the next run, until they expire. They are sent at least once:
+// if marking them fails, they are sent again once the lease runs out.
+func (store *txStore) SendVerifyEmailsTx(ctx context.Context, arg SendVerifyEmailsTxParams) (SendVerifyEmailsTxResult, error) {
+	var result SendVerifyEmailsTxResult
+
+	var verifyEmails []VerifyEmail
+	err := store.execTx(ctx, func(q Querier) error {
+		var err error
+		verifyEmails, err = q.ClaimVerifyEmails(ctx, ClaimVerifyEmailsParams{
+			LeaseSeconds: arg.Lease.Seconds(),
+			Limit: arg.Limit,
+		})
+		return err
+	})
+	if err != nil || len(verifyEmails) == 0 {
+		return result, err
+	}
+	slices.SortFunc(verifyEmails, byVerifyEmailID)
+
+	sendCtx, cancel := context.WithTimeout(ctx, arg.Lease/2)
+	defer cancel()
+
+	var sent, released []int64
+	for _, verifyEmail := range verifyEmails {
+		if sendCtx.Err() != nil {
+			released = append(released, verifyEmail.ID)
+			continue
+		}
+		if err := arg.Send(sendCtx, verifyEmail); err != nil {
+			released = append(released, verifyEmail.ID)
+			continue
+		}
+		sent = append(sent, verifyEmail.ID)
+	}
+
+	err = store.execTx(ctx, func(q Querier) error {
+		for _, id := range sent {
+			if err := q.MarkVerifyEmailSent(ctx, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range released {
+			if err := q.ReleaseVerifyEmail(ctx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return SendVerifyEmailsTxResult{}, err
+	}
+
+	result.Sent = len(sent)
+	result.Failed = len(released)
+	return result, nil
+}
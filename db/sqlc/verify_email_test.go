@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// unsentVerifyEmails returns the verification emails which are neither sent, expired nor leased,
+// claiming them only while they are read
+func unsentVerifyEmails(t *testing.T, store Store) []VerifyEmail {
+	claimed, err := store.ClaimVerifyEmails(context.Background(), ClaimVerifyEmailsParams{LeaseSeconds: 60, Limit: 10000})
+	require.NoError(t, err)
+
+	for _, verifyEmail := range claimed {
+		require.NoError(t, store.ReleaseVerifyEmail(context.Background(), verifyEmail.ID))
+	}
+	return claimed
+}
+
+// unsentVerifyEmail returns the verification email queued for the user, which must not have been sent yet
+func unsentVerifyEmail(t *testing.T, store Store, username string) VerifyEmail {
+	for _, verifyEmail := range unsentVerifyEmails(t, store) {
+		if verifyEmail.Username == username {
+			return verifyEmail
+		}
+	}
+	require.FailNow(t, "no unsent verification email", "user %s", username)
+	return VerifyEmail{}
+}
+
+// drainVerifyEmails marks every unsent verification email sent, so those of a test are the only ones left
+func drainVerifyEmails(t *testing.T, store Store) {
+	for {
+		result, err := store.SendVerifyEmailsTx(context.Background(), SendVerifyEmailsTxParams{
+			Limit: 1000,
+			Lease: time.Minute,
+			Send: func(context.Context, VerifyEmail) error { return nil },
+		})
+		require.NoError(t, err)
+		if result.Sent == 0 {
+			return
+		}
+	}
+}
+
+func createRandomUserTx(t *testing.T, store Store) User {
+	user, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+	return user
+}
+
+func testCreateUserTxVerifyEmail(t *testing.T, store Store) {
+	user := createRandomUserTx(t, store)
+
+	verifyEmail := unsentVerifyEmail(t, store, user.Username)
+	require.Equal(t, user.Email, verifyEmail.Email)
+	require.Len(t, verifyEmail.SecretCode, 64)
+	require.False(t, verifyEmail.IsUsed)
+	require.NotZero(t, verifyEmail.CreatedAt)
+	require.WithinDuration(t, time.Now().Add(VerifyEmailDuration), verifyEmail.ExpiredAt, time.Minute)
+	require.True(t, verifyEmail.SentAt.IsZero())
+
+	_, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username: util.RandomOwner(),
+		Email: util.RandomEmail(),
+		SecretCode: "code",
+		ExpiredAt: time.Now().Add(time.Hour),
+	})
+	requirePQError(t, err, "23503", "verify_emails_username_fkey")
+}
+
+func testVerifyEmailTx(t *testing.T, store Store) {
+	user := createRandomUserTx(t, store)
+	verifyEmail := unsentVerifyEmail(t, store, user.Username)
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: "wrong",
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+
+	result, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.VerifyEmail.IsUsed)
+
+	got, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, got.IsEmailVerified)
+
+	// codes can be used only once
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+}
+
+func testVerifyEmailTxExpired(t *testing.T, store Store) {
+	user := createRandomUser(t, store)
+	verifyEmail, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username: user.Username,
+		Email: user.Email,
+		SecretCode: util.RandomString(32),
+		ExpiredAt: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+
+	got, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, got.IsEmailVerified)
+
+	// expired emails are not sent either
+	for _, other := range unsentVerifyEmails(t, store) {
+		require.NotEqual(t, verifyEmail.ID, other.ID)
+	}
+}
+
+func testSendVerifyEmailsTx(t *testing.T, store Store) {
+	drainVerifyEmails(t, store)
+	user1 := createRandomUserTx(t, store)
+	user2 := createRandomUserTx(t, store)
+	failing := unsentVerifyEmail(t, store, user1.Username)
+
+	var sent []string
+	result, err := store.SendVerifyEmailsTx(context.Background(), SendVerifyEmailsTxParams{
+		Limit: 10,
+		Lease: time.Minute,
+		Send: func(ctx context.Context, verifyEmail VerifyEmail) error {
+			if verifyEmail.ID == failing.ID {
+				return errors.New("unavailable")
+			}
+			sent = append(sent, verifyEmail.Username)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, SendVerifyEmailsTxResult{Sent: 1, Failed: 1}, result)
+	require.Equal(t, []string{user2.Username}, sent)
+
+	// the failed email is sent on the next run
+	sent = nil
+	result, err = store.SendVerifyEmailsTx(context.Background(), SendVerifyEmailsTxParams{
+		Limit: 10,
+		Lease: time.Minute,
+		Send: func(ctx context.Context, verifyEmail VerifyEmail) error {
+			sent = append(sent, verifyEmail.Username)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, SendVerifyEmailsTxResult{Sent: 1}, result)
+	require.Equal(t, []string{user1.Username}, sent)
+
+	require.Empty(t, unsentVerifyEmails(t, store))
+}
+
+func testSendVerifyEmailsTxLease(t *testing.T, store Store) {
+	drainVerifyEmails(t, store)
+	user1 := createRandomUserTx(t, store)
+
+	send := func(send func(ctx context.Context, verifyEmail VerifyEmail) error) SendVerifyEmailsTxResult {
+		result, err := store.SendVerifyEmailsTx(context.Background(), SendVerifyEmailsTxParams{
+			Limit: 10,
+			Lease: time.Minute,
+			Send: send,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	var user2 User
+	result := send(func(ctx context.Context, verifyEmail VerifyEmail) error {
+		require.Equal(t, user1.Username, verifyEmail.Username)
+
+		// emails are sent outside of any transaction, so the store can be written meanwhile
+		user2 = createRandomUserTx(t, store)
+
+		// the email is leased, other senders skip it but send the later ones
+		var sent []string
+		other := send(func(ctx context.Context, verifyEmail VerifyEmail) error {
+			sent = append(sent, verifyEmail.Username)
+			return nil
+		})
+		require.Equal(t, SendVerifyEmailsTxResult{Sent: 1}, other)
+		require.Equal(t, []string{user2.Username}, sent)
+		return nil
+	})
+	require.Equal(t, SendVerifyEmailsTxResult{Sent: 1}, result)
+
+	// the lease of a sender stopping halfway runs out
+	user3 := createRandomUserTx(t, store)
+	claimed, err := store.ClaimVerifyEmails(context.Background(), ClaimVerifyEmailsParams{LeaseSeconds: 0.1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, user3.Username, claimed[0].Username)
+	require.Equal(t, SendVerifyEmailsTxResult{}, send(func(context.Context, VerifyEmail) error { return nil }))
+
+	time.Sleep(200 * time.Millisecond)
+	var sent []string
+	result = send(func(ctx context.Context, verifyEmail VerifyEmail) error {
+		sent = append(sent, verifyEmail.Username)
+		return nil
+	})
+	require.Equal(t, SendVerifyEmailsTxResult{Sent: 1}, result)
+	require.Equal(t, []string{user3.Username}, sent)
+}
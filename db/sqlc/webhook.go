@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+)
+
+// Event types webhooks subscribe to, about the account they are registered for
+const (
+	WebhookEventTransferReceived = "transfer.received"
+	WebhookEventTransferSent = "transfer.sent"
+)
+
+var WebhookEventTypes = []string{WebhookEventTransferReceived, WebhookEventTransferSent}
+
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed = "failed"
+)
+
+// queueWebhookDeliveries creates a pending delivery of event, as eventType, for every webhook of the account
+// subscribed to it. Deliveries are queued within the transaction recording the event, so none is lost or duplicated.
+func queueWebhookDeliveries(ctx context.Context, q Querier, accountID int64, eventType string, event OutboxEvent) error {
+	webhooks, err := q.ListWebhooksForEvent(ctx, ListWebhooksForEventParams{
+		AccountID: accountID,
+		EventType: eventType,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		_, err := q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID: event.ID,
+			EventType: eventType,
+			Payload: event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, store Store, accountID int64, eventTypes ...string) Webhook {
+	arg := CreateWebhookParams{
+		AccountID: accountID,
+		Url: "https://example.com/" + util.RandomString(8),
+		Secret: util.RandomString(32),
+		EventTypes: eventTypes,
+	}
+
+	webhook, err := store.CreateWebhook(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, webhook.ID)
+	require.Equal(t, arg.AccountID, webhook.AccountID)
+	require.Equal(t, arg.Url, webhook.Url)
+	require.Equal(t, arg.Secret, webhook.Secret)
+	require.ElementsMatch(t, arg.EventTypes, webhook.EventTypes)
+	require.NotZero(t, webhook.CreatedAt)
+	return webhook
+}
+
+// createRandomWebhookDelivery creates a delivery of a fake event, which webhook deliveries do not reference
+func createRandomWebhookDelivery(t *testing.T, store Store, webhookID int64) WebhookDelivery {
+	arg := CreateWebhookDeliveryParams{
+		WebhookID: webhookID,
+		EventID: util.RandomInt(1, 1000000),
+		EventType: WebhookEventTransferReceived,
+		Payload: json.RawMessage(`{"amount": 10}`),
+	}
+
+	delivery, err := store.CreateWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.WebhookID, delivery.WebhookID)
+	require.Equal(t, arg.EventID, delivery.EventID)
+	require.Equal(t, arg.EventType, delivery.EventType)
+	require.JSONEq(t, string(arg.Payload), string(delivery.Payload))
+	require.Equal(t, WebhookDeliveryPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+	require.WithinDuration(t, delivery.CreatedAt, delivery.NextAttemptAt, time.Second)
+	require.True(t, delivery.DeliveredAt.IsZero())
+	return delivery
+}
+
+func testCreateWebhook(t *testing.T, store Store) {
+	account := createRandomAccount(t, store)
+	webhook := createRandomWebhook(t, store, account.ID, WebhookEventTypes...)
+
+	got, err := store.GetWebhook(context.Background(), webhook.ID)
+	require.NoError(t, err)
+	require.Equal(t, webhook, got)
+
+	_, err = store.CreateWebhook(context.Background(), CreateWebhookParams{
+		AccountID: account.ID + 1000000,
+		Url: "https://example.com",
+		Secret: "secret",
+		EventTypes: WebhookEventTypes,
+	})
+	requirePQError(t, err, "23503", "webhooks_account_id_fkey")
+
+	// a webhook keeps its account from being deleted
+	err = store.DeleteAccount(context.Background(), account.ID)
+	requirePQError(t, err, "23503", "webhooks_account_id_fkey")
+}
+
+func testListWebhooksForEvent(t *testing.T, store Store) {
+	account := createRandomAccount(t, store)
+	received := createRandomWebhook(t, store, account.ID, WebhookEventTransferReceived)
+	both := createRandomWebhook(t, store, account.ID, WebhookEventTransferSent, WebhookEventTransferReceived)
+	createRandomWebhook(t, store, createRandomAccount(t, store).ID, WebhookEventTransferReceived)
+
+	webhooks, err := store.ListWebhooks(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Webhook{received, both}, webhooks)
+
+	webhooks, err = store.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		AccountID: account.ID,
+		EventType: WebhookEventTransferReceived,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Webhook{received, both}, webhooks)
+
+	webhooks, err = store.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		AccountID: account.ID,
+		EventType: WebhookEventTransferSent,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Webhook{both}, webhooks)
+}
+
+func testDeleteWebhook(t *testing.T, store Store) {
+	account := createRandomAccount(t, store)
+	webhook := createRandomWebhook(t, store, account.ID, WebhookEventTransferReceived)
+	delivery := createRandomWebhookDelivery(t, store, webhook.ID)
+
+	err := store.DeleteWebhook(context.Background(), webhook.ID)
+	require.NoError(t, err)
+
+	_, err = store.GetWebhook(context.Background(), webhook.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// its deliveries are deleted with it
+	_, err = store.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = store.DeleteAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+}
+
+func testCreateWebhookDelivery(t *testing.T, store Store) {
+	webhook := createRandomWebhook(t, store, createRandomAccount(t, store).ID, WebhookEventTransferReceived)
+	delivery1 := createRandomWebhookDelivery(t, store, webhook.ID)
+	delivery2 := createRandomWebhookDelivery(t, store, webhook.ID)
+
+	got, err := store.GetWebhookDelivery(context.Background(), delivery1.ID)
+	require.NoError(t, err)
+	require.Equal(t, delivery1.ID, got.ID)
+	require.JSONEq(t, string(delivery1.Payload), string(got.Payload))
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit: 1,
+		Offset: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, delivery2.ID, deliveries[0].ID)
+
+	_, err = store.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID + 1000000,
+		EventID: 1,
+		EventType: WebhookEventTransferReceived,
+		Payload: json.RawMessage(`{}`),
+	})
+	requirePQError(t, err, "23503", "webhook_deliveries_webhook_id_fkey")
+
+	_, err = store.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		EventID: 1,
+		EventType: WebhookEventTransferReceived,
+		Payload: json.RawMessage(`{`),
+	})
+	requirePQError(t, err, "22P02", "")
+}
+
+// claimDeliveriesOf claims every due delivery, returning those of webhook
+func claimDeliveriesOf(t *testing.T, store Store, webhook Webhook, leaseSeconds float64) []WebhookDelivery {
+	claimed, err := store.ClaimWebhookDeliveries(context.Background(), ClaimWebhookDeliveriesParams{
+		LeaseSeconds: leaseSeconds,
+		Limit: 10000,
+	})
+	require.NoError(t, err)
+
+	deliveries := []WebhookDelivery{}
+	for _, delivery := range claimed {
+		if delivery.WebhookID == webhook.ID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries
+}
+
+func testWebhookDeliveryAttempts(t *testing.T, store Store) {
+	webhook := createRandomWebhook(t, store, createRandomAccount(t, store).ID, WebhookEventTransferReceived)
+	delivery := createRandomWebhookDelivery(t, store, webhook.ID)
+
+	// a claimed delivery is not claimed again until its lease runs out
+	claimed := claimDeliveriesOf(t, store, webhook, 60)
+	require.Len(t, claimed, 1)
+	require.Equal(t, delivery.ID, claimed[0].ID)
+	require.WithinDuration(t, time.Now().Add(time.Minute), claimed[0].NextAttemptAt, 5*time.Second)
+	require.Empty(t, claimDeliveriesOf(t, store, webhook, 60))
+
+	failed, err := store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		Status: WebhookDeliveryPending,
+		LastStatusCode: 500,
+		LastError: "500 Internal Server Error",
+		NextAttemptAt: time.Now().Add(-time.Second),
+		ID: delivery.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.Attempts)
+	require.Equal(t, int32(500), failed.LastStatusCode)
+	require.Equal(t, "500 Internal Server Error", failed.LastError)
+
+	// due again
+	require.Len(t, claimDeliveriesOf(t, store, webhook, 60), 1)
+
+	deliveredAt := time.Now().UTC().Truncate(time.Second)
+	succeeded, err := store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		Status: WebhookDeliverySucceeded,
+		LastStatusCode: 204,
+		NextAttemptAt: failed.NextAttemptAt,
+		DeliveredAt: deliveredAt,
+		ID: delivery.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliverySucceeded, succeeded.Status)
+	require.Equal(t, int32(2), succeeded.Attempts)
+	require.Empty(t, succeeded.LastError)
+	require.True(t, deliveredAt.Equal(succeeded.DeliveredAt))
+	require.Empty(t, claimDeliveriesOf(t, store, webhook, 60))
+
+	// a replayed delivery is due now, with every attempt available
+	replayed, err := store.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, replayed.Status)
+	require.Zero(t, replayed.Attempts)
+	require.Len(t, claimDeliveriesOf(t, store, webhook, 60), 1)
+
+	_, err = store.ReplayWebhookDelivery(context.Background(), delivery.ID+1000000)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		Status: "lost",
+		ID: delivery.ID,
+	})
+	requirePQError(t, err, "23514", "webhook_delivery_status_check")
+}
+
+func testTransferTxWebhookDeliveries(t *testing.T, store Store) {
+	account1 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	account2 := createRandomAccountWithCurrency(t, store, util.EUR, 100)
+	sent := createRandomWebhook(t, store, account1.ID, WebhookEventTransferSent)
+	received := createRandomWebhook(t, store, account2.ID, WebhookEventTransferReceived)
+	// subscribed to the other side of the transfer only
+	ignored := createRandomWebhook(t, store, account2.ID, WebhookEventTransferSent)
+
+	result, err := store.CreateTransferTx(context.Background(), CreateTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID: account2.ID,
+		Amount: 10,
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		webhook Webhook
+		eventType string
+	}{
+		{sent, WebhookEventTransferSent},
+		{received, WebhookEventTransferReceived},
+	} {
+		deliveries, err := store.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+			WebhookID: tc.webhook.ID,
+			Limit: 10,
+		})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, tc.eventType, deliveries[0].EventType)
+		require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+
+		var payload Transfer
+		require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+		require.Equal(t, result.Transfer, payload)
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: ignored.ID,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
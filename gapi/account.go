@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	accountType := req.GetAccountType()
+	if accountType == "" {
+		accountType = db.AccountTypeChecking
+	}
+	if err := validateAccountType(accountType); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+		Owner: authPayload(ctx).Username,
+		Currency: req.GetCurrency(),
+		AccountType: accountType,
+		Balance: 0,
+		IBANCountryCode: server.config.IBANCountryCode,
+		IBANBankCode: server.config.IBANBankCode,
+	})
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	accounts, err := server.store.ListAccountsByOwner(ctx, db.ListAccountsByOwnerParams{
+		Owner: authPayload(ctx).Username,
+		Limit: req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.ListAccountsResponse{Accounts: convertAccounts(accounts)}, nil
+}
+
+// ownedAccount fetches an account that must belong to the authenticated user
+func (server *Server) ownedAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	if accountID < 1 {
+		return db.Account{}, invalidArgumentError("invalid account id: %d", accountID)
+	}
+
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, storeError(ctx, err)
+	}
+
+	if account.Owner != authPayload(ctx).Username {
+		return account, permissionDeniedError("account [%d] does not belong to the authenticated user", accountID)
+	}
+
+	return account, nil
+}
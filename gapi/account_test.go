@@ -0,0 +1,227 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateAccountRPC(t *testing.T) {
+	account := randomAccount(util.RandomOwner(), util.EUR)
+
+	testCases := []struct {
+		name string
+		req *pb.CreateAccountRequest
+		authenticated bool
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateAccountRequest{Currency: account.Currency},
+			authenticated: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAccountTxParams) (db.Account, error) {
+						require.Equal(t, account.Owner, arg.Owner)
+						require.Equal(t, account.Currency, arg.Currency)
+						require.Equal(t, db.AccountTypeChecking, arg.AccountType)
+						require.Equal(t, "ES", arg.IBANCountryCode)
+						require.Equal(t, "9999", arg.IBANBankCode)
+						return account, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, account.AccountNumber, res.GetAccount().GetAccountNumber())
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.CreateAccountRequest{Currency: account.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			req: &pb.CreateAccountRequest{Currency: "XYZ"},
+			authenticated: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := context.Background()
+			if tc.authenticated {
+				ctx = newContextWithToken(t, server, account.Owner)
+			}
+
+			res, err := pb.NewAccountServiceClient(conn).CreateAccount(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestGetAccountRPC(t *testing.T) {
+	account := randomAccount(util.RandomOwner(), util.EUR)
+
+	testCases := []struct {
+		name string
+		username string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.GetAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, account.Balance, res.GetAccount().GetBalance())
+			},
+		},
+		{
+			name: "NotFound",
+			username: account.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "OtherOwner",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, tc.username)
+
+			res, err := pb.NewAccountServiceClient(conn).GetAccount(ctx, &pb.GetAccountRequest{Id: account.ID})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestListAccountsRPC(t *testing.T) {
+	owner := util.RandomOwner()
+	accounts := []db.Account{randomAccount(owner, util.EUR), randomAccount(owner, util.USD)}
+
+	testCases := []struct {
+		name string
+		req *pb.ListAccountsRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListAccountsRequest{PageId: 2, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsByOwner(gomock.Any(), gomock.Eq(db.ListAccountsByOwnerParams{Owner: owner, Limit: 5, Offset: 5})).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), len(accounts))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListAccountsRequest{PageId: 1, PageSize: 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountsByOwner(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, owner)
+
+			res, err := pb.NewAccountServiceClient(conn).ListAccounts(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func randomAccount(owner string, currency string) db.Account {
+	return db.Account{
+		ID: util.RandomInt(1, 1000),
+		Owner: owner,
+		Balance: util.RandomMoney(),
+		Currency: currency,
+		Status: db.AccountStatusActive,
+		AccountType: db.AccountTypeChecking,
+		AccruedInterest: "0",
+		AccountNumber: util.RandomAccountNumber(),
+	}
+}
@@ -0,0 +1,117 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+)
+
+// Methods that can be called without an access token
+var publicMethods = map[string]bool{
+	pb.UserService_CreateUser_FullMethodName: true,
+	pb.UserService_LoginUser_FullMethodName: true,
+	// authenticated by the pre-auth token in the request instead
+	pb.UserService_LoginUserTOTP_FullMethodName: true,
+}
+
+type authPayloadKey struct{}
+
+func (server *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(context.WithValue(ctx, authPayloadKey{}, payload), req)
+}
+
+// contextServerStream is a server stream whose context has been replaced by an interceptor
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *contextServerStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (server *Server) streamAuthInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if publicMethods[info.FullMethod] {
+		return handler(srv, stream)
+	}
+
+	payload, err := server.authorizeUser(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(stream.Context(), authPayloadKey{}, payload)
+	return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorizeUser verifies the bearer access token in the authorization metadata, that it has not been revoked
+// by a password change and that its user is not disabled. It fails with an Unauthenticated status, unless the store fails.
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	if strings.ToLower(fields[0]) != authorizationBearer {
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported authorization type %s", fields[0])
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1], token.TokenTypeAccess)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid access token: %s", err)
+	}
+
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.Unauthenticated, "unknown user %s", payload.Username)
+	}
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	if user.IsDisabled {
+		return nil, status.Error(codes.Unauthenticated, "user is disabled")
+	}
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		return nil, status.Error(codes.Unauthenticated, "access token has been revoked")
+	}
+
+	return payload, nil
+}
+
+// authPayload returns the payload of the access token verified by the interceptors
+func authPayload(ctx context.Context) *token.Payload {
+	payload, _ := ctx.Value(authPayloadKey{}).(*token.Payload)
+	return payload
+}
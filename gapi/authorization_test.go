@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorizationRevokedToken(t *testing.T) {
+	account := randomAccount(util.RandomOwner(), util.EUR)
+
+	testCases := []struct {
+		name string
+		passwordChangedAt time.Time
+		isDisabled bool
+		code codes.Code
+	}{
+		{
+			name: "PasswordNeverChanged",
+			code: codes.OK,
+		},
+		{
+			name: "PasswordChangedBeforeLogin",
+			passwordChangedAt: time.Now().Add(-time.Minute),
+			code: codes.OK,
+		},
+		{
+			name: "PasswordChangedAfterLogin",
+			passwordChangedAt: time.Now().Add(time.Minute),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "UserDisabledAfterLogin",
+			isDisabled: true,
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			calls := 0
+			if tc.code == codes.OK {
+				calls = 1
+			}
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), account.Owner).
+				Times(1).
+				Return(db.User{Username: account.Owner, PasswordChangedAt: tc.passwordChangedAt, IsDisabled: tc.isDisabled}, nil)
+			store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(calls).Return(account, nil)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, account.Owner)
+
+			_, err := pb.NewAccountServiceClient(conn).GetAccount(ctx, &pb.GetAccountRequest{Id: account.ID})
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package gapi
+
+import (
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username: user.Username,
+		FullName: user.FullName,
+		Email: user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id: account.ID,
+		Owner: account.Owner,
+		Balance: account.Balance,
+		Currency: account.Currency,
+		CreatedAt: timestamppb.New(account.CreatedAt),
+		Status: account.Status,
+		AccountType: account.AccountType,
+		AccountNumber: account.AccountNumber,
+	}
+}
+
+func convertAccounts(accounts []db.Account) []*pb.Account {
+	result := make([]*pb.Account, len(accounts))
+	for i, account := range accounts {
+		result[i] = convertAccount(account)
+	}
+	return result
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id: transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId: transfer.ToAccountID,
+		Amount: transfer.Amount,
+		CreatedAt: timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+func convertTransfers(transfers []db.Transfer) []*pb.Transfer {
+	result := make([]*pb.Transfer, len(transfers))
+	for i, transfer := range transfers {
+		result[i] = convertTransfer(transfer)
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// constraintMessages describe the unique constraints a request can violate, so clients know what is taken already
+var constraintMessages = map[string]string{
+	"users_pkey": "username already in use",
+	"users_email_key": "email already in use",
+	"owner_currency_key": "owner already has an account in this currency",
+}
+
+// storeError maps an error returned by the store to a gRPC status.
+// Database errors are never sent to the client, they are logged instead.
+func storeError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "resource not found")
+	case errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed), errors.Is(err, db.ErrInsufficientFunds):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, db.ErrTOTPAlreadyEnabled), errors.Is(err, db.ErrTOTPNotEnrolled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if message, ok := constraintMessages[pqErr.Constraint]; ok {
+			return status.Error(codes.AlreadyExists, message)
+		}
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return status.Error(codes.AlreadyExists, "resource already exists")
+		case "foreign_key_violation":
+			return status.Error(codes.FailedPrecondition, "referenced resource does not exist")
+		}
+	}
+
+	logger.FromContext(ctx).Error("internal error", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func invalidArgumentError(format string, args ...interface{}) error {
+	return status.Error(codes.InvalidArgument, fmt.Sprintf(format, args...))
+}
+
+func permissionDeniedError(format string, args ...interface{}) error {
+	return status.Error(codes.PermissionDenied, fmt.Sprintf(format, args...))
+}
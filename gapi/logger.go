@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gorkaio/simplebank/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDHeader = "x-request-id"
+
+// unaryLoggerInterceptor carries the request ID from the x-request-id metadata, or a new one,
+// in the context of the call and logs the call once it has been served
+func unaryLoggerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func streamLoggerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(stream.Context())
+
+	err := handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+
+	requestID = logger.RequestIDOrNew(requestID)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+	return logger.WithRequestID(ctx, requestID)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelWarn
+	switch code {
+	case codes.OK:
+		level = slog.LevelInfo
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	}
+
+	logger.FromContext(ctx).Log(ctx, level, "grpc call",
+		"method", method,
+		"code", code.String(),
+		"duration", time.Since(start),
+	)
+}
@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	account := randomAccount(util.RandomOwner(), util.EUR)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var storeRequestID string
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), account.ID).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			storeRequestID = logger.RequestID(ctx)
+			return account, nil
+		})
+	allowAccessTokens(store)
+
+	conn, server := newTestClientConn(t, store)
+	client := pb.NewAccountServiceClient(conn)
+
+	ctx := newContextWithToken(t, server, account.Owner)
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDHeader, "client-request-1")
+
+	var header metadata.MD
+	_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: account.ID}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"client-request-1"}, header.Get(requestIDHeader))
+	require.Equal(t, "client-request-1", storeRequestID)
+}
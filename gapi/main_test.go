@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/metrics"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// newTestClientConn serves the gRPC services on an in-memory listener and returns a client connection to it
+func newTestClientConn(t *testing.T, store db.Store) (*grpc.ClientConn, *Server) {
+	config := util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		PreAuthTokenDuration: time.Minute,
+		TOTPIssuer: "SimpleBank",
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	}
+
+	server, err := NewServer(config, store, metrics.New(), nil)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(bufSize)
+	grpcServer := server.NewGRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, server
+}
+
+// newContextWithToken returns a context carrying an access token of the given user
+func newContextWithToken(t *testing.T, server *Server, username string) context.Context {
+	accessToken, err := server.tokenMaker.CreateToken(username, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	header := fmt.Sprintf("%s %s", authorizationBearer, accessToken)
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationHeader, header)
+}
+
+// allowAccessTokens lets the access tokens of every user through the revocation check of the interceptors,
+// as users who never changed their password
+func allowAccessTokens(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(ctx context.Context, username string) (db.User, error) {
+			return db.User{Username: username}, nil
+		})
+}
@@ -0,0 +1,61 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gorkaio/simplebank/logger"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const retryAfterHeader = "retry-after"
+
+// unaryRateLimitInterceptor rejects calls with ResourceExhausted and a retry-after header once the client
+// runs out of calls for the method. Authenticated calls are limited by username, so users behind the same
+// address do not share their limit, and public ones by peer IP. It runs after the auth interceptor so
+// the username is known. Calls are let through if the limiter store fails.
+func (server *Server) unaryRateLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := rateLimitMethod(info.FullMethod)
+
+	var client string
+	if payload := authPayload(ctx); payload != nil {
+		client = ratelimit.UserClient(payload.Username)
+	} else if p, ok := peer.FromContext(ctx); ok {
+		client = ratelimit.IPClient(peerIP(p.Addr))
+	}
+
+	allowed, retryAfter, err := server.limiter.Allow(ctx, method, client)
+	if err != nil {
+		logger.FromContext(ctx).Error("rate limit failed", "error", err)
+		return handler(ctx, req)
+	}
+
+	if !allowed {
+		server.metrics.RateLimited(info.FullMethod)
+		seconds := strconv.FormatInt(ratelimit.RetryAfterSeconds(retryAfter), 10)
+		grpc.SetHeader(ctx, metadata.Pairs(retryAfterHeader, seconds))
+		return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %s seconds", seconds)
+	}
+	return handler(ctx, req)
+}
+
+// rateLimitMethod drops the package from a full method name, "/pb.UserService/LoginUser" being limited
+// as "UserService/LoginUser", since configuration keys cannot hold dots
+func rateLimitMethod(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, ".")+1:]
+}
+
+func peerIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,84 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLimiter(t *testing.T, method string) *ratelimit.Limiter {
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]util.RateLimit{
+		method: {Requests: 1, Period: time.Minute},
+	})
+	require.NoError(t, err)
+	return limiter
+}
+
+func TestRateLimitLoginUserRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+	conn, server := newTestClientConn(t, store)
+	server.limiter = newTestLimiter(t, "userservice/loginuser")
+	client := pb.NewUserServiceClient(conn)
+	req := &pb.LoginUserRequest{Username: util.RandomOwner(), Password: util.RandomString(6)}
+
+	_, err := client.LoginUser(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	var header metadata.MD
+	_, err = client.LoginUser(context.Background(), req, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"60"}, header.Get(retryAfterHeader))
+}
+
+func TestRateLimitAuthenticatedUserRPC(t *testing.T) {
+	transfer := db.Transfer{ID: 1}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+	allowAccessTokens(store)
+
+	conn, server := newTestClientConn(t, store)
+	server.limiter = newTestLimiter(t, "TransferService/GetTransfer")
+	client := pb.NewTransferServiceClient(conn)
+
+	// the user ran out of calls from another address
+	username := util.RandomOwner()
+	allowed, _, err := server.limiter.Allow(context.Background(), "TransferService/GetTransfer", ratelimit.UserClient(username))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	ctx := newContextWithToken(t, server, username)
+	_, err = client.GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// other users on the same address are not limited
+	ctx = newContextWithToken(t, server, util.RandomOwner())
+	_, err = client.GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRateLimitMethod(t *testing.T) {
+	require.Equal(t, "UserService/LoginUser", rateLimitMethod(pb.UserService_LoginUser_FullMethodName))
+	require.Equal(t, "TransferService/CreateTransfer", rateLimitMethod(pb.TransferService_CreateTransfer_FullMethodName))
+}
@@ -0,0 +1,56 @@
+package gapi
+
+import (
+	"fmt"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/metrics"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/ratelimit"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"google.golang.org/grpc"
+)
+
+// Server serves gRPC requests for the user, account and transfer services
+type Server struct {
+	pb.UnimplementedUserServiceServer
+	pb.UnimplementedAccountServiceServer
+	pb.UnimplementedTransferServiceServer
+	config util.Config
+	store db.Store
+	tokenMaker token.Maker
+	metrics *metrics.Metrics
+	limiter *ratelimit.Limiter
+}
+
+// NewServer creates the gRPC server. A nil limiter disables rate limiting.
+func NewServer(config util.Config, store db.Store, metrics *metrics.Metrics, limiter *ratelimit.Limiter) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config: config,
+		store: store,
+		tokenMaker: tokenMaker,
+		metrics: metrics,
+		limiter: limiter,
+	}
+	return server, nil
+}
+
+// NewGRPCServer creates a gRPC server with the logging, authentication and rate limiting interceptors and every
+// service registered
+func (server *Server) NewGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryLoggerInterceptor, server.unaryAuthInterceptor, server.unaryRateLimitInterceptor),
+		grpc.ChainStreamInterceptor(streamLoggerInterceptor, server.streamAuthInterceptor),
+	)
+
+	pb.RegisterUserServiceServer(grpcServer, server)
+	pb.RegisterAccountServiceServer(grpcServer, server)
+	pb.RegisterTransferServiceServer(grpcServer, server)
+	return grpcServer
+}
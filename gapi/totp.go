@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/totp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EnrollTOTP gives the authenticated user a new TOTP secret, replacing the one of an enrollment not confirmed yet.
+// Two-factor authentication is enabled once ConfirmTOTP gets a code of the secret.
+func (server *Server) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	payload := authPayload(ctx)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot generate secret: %s", err)
+	}
+
+	user, err := server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		TotpSecret: secret,
+		Username: payload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storeError(ctx, db.ErrTOTPAlreadyEnabled)
+		}
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret: secret,
+		ProvisioningUri: totp.ProvisioningURI(server.config.TOTPIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication for the authenticated user, given a code of the enrolled secret,
+// and returns the recovery codes of the user
+func (server *Server) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	if req.GetCode() == "" {
+		return nil, invalidArgumentError("code is required")
+	}
+
+	result, err := server.store.ConfirmTOTPTx(ctx, db.ConfirmTOTPTxParams{
+		Username: authPayload(ctx).Username,
+		Code: req.GetCode(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidTOTPCode) {
+			return nil, invalidArgumentError("invalid two-factor code")
+		}
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: result.RecoveryCodes}, nil
+}
+
+// stepUp returns the second factor of the owner of the source account which the transfers needing step-up
+// authentication are made with, nil for the others. CreateTransferTx checks the code and uses it up along with
+// the transfer, so a transfer failing does not use it up.
+func (server *Server) stepUp(account db.Account, amount int64, code string) (*db.VerifyTOTPTxParams, error) {
+	if server.config.StepUpTransferAmount <= 0 || amount < server.config.StepUpTransferAmount {
+		return nil, nil
+	}
+
+	if code == "" {
+		return nil, permissionDeniedError("totp_code is required for transfers of at least %d", server.config.StepUpTransferAmount)
+	}
+	return &db.VerifyTOTPTxParams{Username: account.Owner, Code: code}, nil
+}
+
+// stepUpError maps the errors of a transfer made with a second factor, those of the code included
+func (server *Server) stepUpError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, db.ErrTOTPNotEnrolled):
+		return status.Errorf(codes.FailedPrecondition, "two-factor authentication is required for transfers of at least %d", server.config.StepUpTransferAmount)
+	case errors.Is(err, db.ErrInvalidTOTPCode):
+		return permissionDeniedError("invalid two-factor code")
+	}
+	return storeError(ctx, err)
+}
@@ -0,0 +1,301 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUserTOTPRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsTotpEnabled = true
+
+	testCases := []struct {
+		name string
+		tokenType token.TokenType
+		code string
+		recoveryCode string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			tokenType: token.TokenTypePreAuth,
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().
+					VerifyTOTPTx(gomock.Any(), gomock.Eq(db.VerifyTOTPTxParams{Username: user.Username, Code: "123456"})).
+					Times(1).
+					Return(db.VerifyTOTPTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+				require.Empty(t, res.GetPreAuthToken())
+
+				payload, err := server.tokenMaker.VerifyToken(res.GetAccessToken(), token.TokenTypeAccess)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+			},
+		},
+		{
+			name: "OKRecoveryCode",
+			tokenType: token.TokenTypePreAuth,
+			recoveryCode: "abcd-efgh-ijkl-mnop",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().
+					VerifyTOTPTx(gomock.Any(), gomock.Eq(db.VerifyTOTPTxParams{Username: user.Username, RecoveryCode: "abcd-efgh-ijkl-mnop"})).
+					Times(1).
+					Return(db.VerifyTOTPTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "InvalidCode",
+			tokenType: token.TokenTypePreAuth,
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyTOTPTxResult{}, db.ErrInvalidTOTPCode)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				requireFailedLogins(t, server, 1)
+			},
+		},
+		{
+			name: "NoCode",
+			tokenType: token.TokenTypePreAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "BothCodes",
+			tokenType: token.TokenTypePreAuth,
+			code: "123456",
+			recoveryCode: "abcd-efgh-ijkl-mnop",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "AccessToken",
+			tokenType: token.TokenTypeAccess,
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "Disabled",
+			tokenType: token.TokenTypePreAuth,
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				disabled := user
+				disabled.IsDisabled = true
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(disabled, nil)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "PasswordChanged",
+			tokenType: token.TokenTypePreAuth,
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Minute)
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(changed, nil)
+				store.EXPECT().VerifyTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			conn, server := newTestClientConn(t, store)
+			preAuthToken, err := server.tokenMaker.CreateToken(user.Username, tc.tokenType, time.Minute)
+			require.NoError(t, err)
+
+			req := &pb.LoginUserTOTPRequest{PreAuthToken: preAuthToken, Code: tc.code, RecoveryCode: tc.recoveryCode}
+			res, err := pb.NewUserServiceClient(conn).LoginUserTOTP(context.Background(), req)
+			tc.checkResponse(t, server, res, err)
+		})
+	}
+}
+
+func TestEnrollTOTPRPC(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.EnrollTOTPResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.SetUserTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						enrolled := user
+						enrolled.TotpSecret = arg.TotpSecret
+						return enrolled, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.EnrollTOTPResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetSecret(), 32)
+				require.True(t, strings.HasPrefix(res.GetProvisioningUri(), "otpauth://totp/SimpleBank:"+user.Username+"?"))
+				require.Contains(t, res.GetProvisioningUri(), "secret="+res.GetSecret())
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.EnrollTOTPResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, user.Username)
+
+			res, err := pb.NewUserServiceClient(conn).EnrollTOTP(ctx, &pb.EnrollTOTPRequest{})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestConfirmTOTPRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	recoveryCodes := []string{"abcd-efgh-ijkl-mnop", "qrst-uvwx-yz23-4567"}
+
+	testCases := []struct {
+		name string
+		code string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ConfirmTOTPResponse, err error)
+	}{
+		{
+			name: "OK",
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConfirmTOTPTx(gomock.Any(), gomock.Eq(db.ConfirmTOTPTxParams{Username: user.Username, Code: "123456"})).
+					Times(1).
+					Return(db.ConfirmTOTPTxResult{User: user, RecoveryCodes: recoveryCodes}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTOTPResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, recoveryCodes, res.GetRecoveryCodes())
+			},
+		},
+		{
+			name: "NoCode",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTOTPResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ConfirmTOTPTxResult{}, db.ErrInvalidTOTPCode)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTOTPResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NotEnrolled",
+			code: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConfirmTOTPTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ConfirmTOTPTxResult{}, db.ErrTOTPNotEnrolled)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTOTPResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, user.Username)
+
+			res, err := pb.NewUserServiceClient(conn).ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Code: tc.code})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if req.GetAmount() <= 0 {
+		return nil, invalidArgumentError("amount must be greater than 0")
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.ownedAccount(ctx, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if err := validAccount(fromAccount, req.GetCurrency()); err != nil {
+		return nil, err
+	}
+	if err := server.verifiedOwner(ctx, fromAccount); err != nil {
+		return nil, err
+	}
+
+	if req.GetToAccountId() < 1 {
+		return nil, invalidArgumentError("invalid account id: %d", req.GetToAccountId())
+	}
+	toAccount, err := server.store.GetAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	if err := validAccount(toAccount, req.GetCurrency()); err != nil {
+		return nil, err
+	}
+	stepUp, err := server.stepUp(fromAccount, req.GetAmount(), req.GetTotpCode())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.CreateTransferTx(ctx, db.CreateTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID: toAccount.ID,
+		Amount: req.GetAmount(),
+		StepUp: stepUp,
+	})
+	if err != nil {
+		return nil, server.stepUpError(ctx, err)
+	}
+
+	return &pb.CreateTransferResponse{Transfer: convertTransfer(result.Transfer)}, nil
+}
+
+// GetTransfer returns a transfer from or to an account of the authenticated user
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	if _, err := server.ownedAccount(ctx, transfer.FromAccountID); err != nil {
+		if _, err := server.ownedAccount(ctx, transfer.ToAccountID); err != nil {
+			return nil, permissionDeniedError("transfer [%d] does not involve accounts of the authenticated user", transfer.ID)
+		}
+	}
+
+	return &pb.GetTransferResponse{Transfer: convertTransfer(transfer)}, nil
+}
+
+func (server *Server) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+	if req.GetFromAccountId() == 0 && req.GetToAccountId() == 0 {
+		return nil, invalidArgumentError("from_account_id or to_account_id is required")
+	}
+
+	for _, accountID := range []int64{req.GetFromAccountId(), req.GetToAccountId()} {
+		if accountID == 0 {
+			continue
+		}
+		if _, err := server.ownedAccount(ctx, accountID); err != nil {
+			return nil, err
+		}
+	}
+
+	transfers, err := server.store.ListTranfers(ctx, db.ListTranfersParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID: req.GetToAccountId(),
+		Limit: req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.ListTransfersResponse{Transfers: convertTransfers(transfers)}, nil
+}
+
+// validAccount checks an account can take part in a transfer in the given currency
+func validAccount(account db.Account, currency string) error {
+	if err := db.CheckAccountActive(account); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "account [%d]: %v", account.ID, err)
+	}
+
+	if account.Currency != currency {
+		return invalidArgumentError("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	return nil
+}
+
+// verifiedOwner checks the owner of the source account verified their email, when the server requires it
+func (server *Server) verifiedOwner(ctx context.Context, account db.Account) error {
+	if !server.config.RequireVerifiedEmail {
+		return nil
+	}
+
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return storeError(ctx, err)
+	}
+	if !owner.IsEmailVerified {
+		return status.Error(codes.FailedPrecondition, "owner of the source account must verify their email first")
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package gapi
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransferRPC(t *testing.T) {
+	fromAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount.ID = fromAccount.ID + 1
+	usdAccount := randomAccount(util.RandomOwner(), util.USD)
+	usdAccount.ID = fromAccount.ID + 2
+	frozenAccount := randomAccount(util.RandomOwner(), util.EUR)
+	frozenAccount.ID = fromAccount.ID + 3
+	frozenAccount.Status = db.AccountStatusFrozen
+	transfer := db.Transfer{ID: 1, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}
+
+	testCases := []struct {
+		name string
+		username string
+		req *pb.CreateTransferRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			username: fromAccount.Owner,
+			req: &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: 10, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.GetTransfer().GetId())
+				require.Equal(t, transfer.Amount, res.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "FromAccountOfOtherOwner",
+			username: toAccount.Owner,
+			req: &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: 10, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			username: fromAccount.Owner,
+			req: &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: usdAccount.ID, Amount: 10, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), usdAccount.ID).Times(1).Return(usdAccount, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "FrozenAccount",
+			username: fromAccount.Owner,
+			req: &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: frozenAccount.ID, Amount: 10, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), frozenAccount.ID).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidAmount",
+			username: fromAccount.Owner,
+			req: &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: -10, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, tc.username)
+
+			res, err := pb.NewTransferServiceClient(conn).CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestCreateTransferRPCRequiresVerifiedEmail(t *testing.T) {
+	fromAccount := randomAccount(util.RandomOwner(), util.EUR)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+	// read by the interceptor, then to check the email of the owner
+	store.EXPECT().GetUser(gomock.Any(), fromAccount.Owner).Times(2).Return(db.User{Username: fromAccount.Owner}, nil)
+	store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	conn, server := newTestClientConn(t, store)
+	server.config.RequireVerifiedEmail = true
+	ctx := newContextWithToken(t, server, fromAccount.Owner)
+
+	req := &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: fromAccount.ID + 1, Amount: 10, Currency: util.EUR}
+	_, err := pb.NewTransferServiceClient(conn).CreateTransfer(ctx, req)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCreateTransferRPCStepUp(t *testing.T) {
+	fromAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount.ID = fromAccount.ID + 1
+	transfer := db.Transfer{ID: 1, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 1000}
+	stepUp := db.CreateTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID: toAccount.ID,
+		Amount: 1000,
+		StepUp: &db.VerifyTOTPTxParams{Username: fromAccount.Owner, Code: "123456"},
+	}
+
+	testCases := []struct {
+		name string
+		amount int64
+		totpCode string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.GetTransfer().GetId())
+			},
+		},
+		{
+			name: "BelowThreshold",
+			amount: 999,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 999})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "MissingCode",
+			amount: 1000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidCode",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{}, db.ErrInvalidTOTPCode)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "TOTPNotEnabled",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{}, db.ErrTOTPNotEnrolled)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			amount: 1000,
+			totpCode: "123456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Eq(stepUp)).Times(1).Return(db.CreateTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+			store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			server.config.StepUpTransferAmount = 1000
+			ctx := newContextWithToken(t, server, fromAccount.Owner)
+
+			req := &pb.CreateTransferRequest{
+				FromAccountId: fromAccount.ID,
+				ToAccountId: toAccount.ID,
+				Amount: tc.amount,
+				Currency: util.EUR,
+				TotpCode: tc.totpCode,
+			}
+			res, err := pb.NewTransferServiceClient(conn).CreateTransfer(ctx, req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestGetTransferRPC(t *testing.T) {
+	fromAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount := randomAccount(util.RandomOwner(), util.EUR)
+	toAccount.ID = fromAccount.ID + 1
+	transfer := db.Transfer{ID: 1, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}
+
+	testCases := []struct {
+		name string
+		username string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.GetTransferResponse, err error)
+	}{
+		{
+			name: "OKRecipient",
+			username: toAccount.Owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.GetTransfer().GetId())
+			},
+		},
+		{
+			name: "OtherOwner",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, tc.username)
+
+			res, err := pb.NewTransferServiceClient(conn).GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestListTransfersRPC(t *testing.T) {
+	account := randomAccount(util.RandomOwner(), util.EUR)
+	transfers := []db.Transfer{{ID: 1, FromAccountID: account.ID, ToAccountID: account.ID + 1, Amount: 10}}
+
+	testCases := []struct {
+		name string
+		req *pb.ListTransfersRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ListTransfersResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListTransfersRequest{FromAccountId: account.ID, PageId: 1, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListTranfers(gomock.Any(), gomock.Eq(db.ListTranfersParams{FromAccountID: account.ID, Limit: 5, Offset: 0})).
+					Times(1).
+					Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetTransfers(), 1)
+			},
+		},
+		{
+			name: "MissingAccount",
+			req: &pb.ListTransfersRequest{PageId: 1, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListTranfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListTransfersResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAccessTokens(store)
+
+			conn, server := newTestClientConn(t, store)
+			ctx := newContextWithToken(t, server, account.Owner)
+
+			res, err := pb.NewTransferServiceClient(conn).ListTransfers(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, err
+	}
+	if req.GetFullName() == "" {
+		return nil, invalidArgumentError("full_name is required")
+	}
+	if err := validateEmail(req.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot hash password: %s", err)
+	}
+
+	user, err := server.store.CreateUserTx(ctx, db.CreateUserParams{
+		Username: req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName: req.GetFullName(),
+		Email: req.GetEmail(),
+	})
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			server.metrics.LoginFailed()
+			return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+		}
+		return nil, storeError(ctx, err)
+	}
+
+	if err := util.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		server.metrics.LoginFailed()
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	// checked after the password so disabled accounts cannot be told apart without it
+	if user.IsDisabled {
+		server.metrics.LoginFailed()
+		return nil, status.Error(codes.PermissionDenied, "user is disabled")
+	}
+
+	// the access token is only given once the second factor is verified by LoginUserTOTP
+	if user.IsTotpEnabled {
+		preAuthToken, payload, err := server.createToken(user, token.TokenTypePreAuth, server.config.PreAuthTokenDuration)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.LoginUserResponse{
+			User: convertUser(user),
+			PreAuthToken: preAuthToken,
+			PreAuthTokenExpiresAt: timestamppb.New(payload.ExpiredAt),
+		}, nil
+	}
+
+	return server.loginResponse(user)
+}
+
+// LoginUserTOTP exchanges the pre-auth token of a user with two-factor authentication, along with a TOTP or
+// recovery code, for an access token
+func (server *Server) LoginUserTOTP(ctx context.Context, req *pb.LoginUserTOTPRequest) (*pb.LoginUserResponse, error) {
+	if (req.GetCode() == "") == (req.GetRecoveryCode() == "") {
+		return nil, invalidArgumentError("either code or recovery_code is required")
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.GetPreAuthToken(), token.TokenTypePreAuth)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid pre-auth token: %s", err)
+	}
+
+	// checked before the code is used, so it is not spent on a login that cannot succeed
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.Unauthenticated, "unknown user %s", payload.Username)
+		}
+		return nil, storeError(ctx, err)
+	}
+	if user.IsDisabled {
+		return nil, status.Error(codes.PermissionDenied, "user is disabled")
+	}
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		return nil, status.Error(codes.Unauthenticated, "pre-auth token has been revoked")
+	}
+
+	result, err := server.store.VerifyTOTPTx(ctx, db.VerifyTOTPTxParams{
+		Username: user.Username,
+		Code: req.GetCode(),
+		RecoveryCode: req.GetRecoveryCode(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidTOTPCode) {
+			server.metrics.LoginFailed()
+			return nil, status.Error(codes.Unauthenticated, "invalid two-factor code")
+		}
+		return nil, storeError(ctx, err)
+	}
+
+	return server.loginResponse(result.User)
+}
+
+// loginResponse returns a new access token for the user
+func (server *Server) loginResponse(user db.User) (*pb.LoginUserResponse, error) {
+	accessToken, payload, err := server.createToken(user, token.TokenTypeAccess, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LoginUserResponse{
+		User: convertUser(user),
+		AccessToken: accessToken,
+		AccessTokenExpiresAt: timestamppb.New(payload.ExpiredAt),
+	}, nil
+}
+
+// createToken returns a token of the given type for the user, along with its payload
+func (server *Server) createToken(user db.User, tokenType token.TokenType, duration time.Duration) (string, *token.Payload, error) {
+	created, err := server.tokenMaker.CreateToken(user.Username, tokenType, duration)
+	if err != nil {
+		return "", nil, status.Errorf(codes.Internal, "cannot create %s token: %s", tokenType, err)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(created, tokenType)
+	if err != nil {
+		return "", nil, status.Errorf(codes.Internal, "cannot verify %s token: %s", tokenType, err)
+	}
+	return created, payload, nil
+}
@@ -0,0 +1,248 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/pb"
+	"github.com/gorkaio/simplebank/token"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name string
+		req *pb.CreateUserRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email: user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+				require.Equal(t, user.Email, res.GetUser().GetEmail())
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email: user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: "users_pkey"})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email: "invalid",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "ShortPassword",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: "123",
+				FullName: user.FullName,
+				Email: user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			conn, _ := newTestClientConn(t, store)
+			res, err := pb.NewUserServiceClient(conn).CreateUser(context.Background(), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestLoginUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name string
+		req *pb.LoginUserRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+
+				payload, err := server.tokenMaker.VerifyToken(res.GetAccessToken(), token.TokenTypeAccess)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				requireFailedLogins(t, server, 0)
+			},
+		},
+		{
+			name: "WrongPassword",
+			req: &pb.LoginUserRequest{Username: user.Username, Password: "wrong password"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				requireFailedLogins(t, server, 1)
+			},
+		},
+		{
+			name: "Disabled",
+			req: &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				disabled := user
+				disabled.IsDisabled = true
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(disabled, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				requireFailedLogins(t, server, 1)
+			},
+		},
+		{
+			name: "TOTPEnabled",
+			req: &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := user
+				enabled.IsTotpEnabled = true
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(enabled, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.GetAccessToken())
+				require.Nil(t, res.GetAccessTokenExpiresAt())
+
+				payload, err := server.tokenMaker.VerifyToken(res.GetPreAuthToken(), token.TokenTypePreAuth)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, payload.ExpiredAt.Unix(), res.GetPreAuthTokenExpiresAt().AsTime().Unix())
+
+				// the pre-auth token is no access token
+				_, err = server.tokenMaker.VerifyToken(res.GetPreAuthToken(), token.TokenTypeAccess)
+				require.ErrorIs(t, err, token.ErrInvalidToken)
+				requireFailedLogins(t, server, 0)
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.LoginUserRequest{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				requireFailedLogins(t, server, 1)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			conn, server := newTestClientConn(t, store)
+			res, err := pb.NewUserServiceClient(conn).LoginUser(context.Background(), tc.req)
+			tc.checkResponse(t, server, res, err)
+		})
+	}
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username: util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	}
+	return
+}
+
+func requireFailedLogins(t *testing.T, server *Server, count int) {
+	expected := fmt.Sprintf(`
+# HELP simplebank_failed_logins_total Login attempts rejected because of an unknown user, a wrong password or a wrong two-factor code.
+# TYPE simplebank_failed_logins_total counter
+simplebank_failed_logins_total %d
+`, count)
+	err := testutil.GatherAndCompare(server.metrics.Registry, strings.NewReader(expected), "simplebank_failed_logins_total")
+	require.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"net/mail"
+	"regexp"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+)
+
+// Same constraints as the binding tags of the HTTP API requests
+var isAlphanumeric = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+
+const (
+	minPasswordLength = 6
+	minPageSize = 5
+	maxPageSize = 10
+)
+
+func validateUsername(username string) error {
+	if !isAlphanumeric(username) {
+		return invalidArgumentError("username must contain only letters and digits")
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return invalidArgumentError("password must contain at least %d characters", minPasswordLength)
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return invalidArgumentError("invalid email address: %s", email)
+	}
+	return nil
+}
+
+func validateCurrency(currency string) error {
+	if !util.IsCurrencySupported(currency) {
+		return invalidArgumentError("unsupported currency: %s", currency)
+	}
+	return nil
+}
+
+func validateAccountType(accountType string) error {
+	for _, t := range db.AccountTypes {
+		if accountType == t {
+			return nil
+		}
+	}
+	return invalidArgumentError("invalid account type: %s", accountType)
+}
+
+func validatePage(pageID int32, pageSize int32) error {
+	if pageID < 1 {
+		return invalidArgumentError("page_id must be at least 1")
+	}
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return invalidArgumentError("page_size must be between %d and %d", minPageSize, maxPageSize)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// Accruer accrues interest daily on end of day balances and posts it monthly
+// from the bank interest expense account of the same currency.
+type Accruer struct {
+	store db.Store
+	// owner of the interest expense accounts, one per currency
+	expenseOwner string
+}
+
+func NewAccruer(store db.Store, expenseOwner string) *Accruer {
+	return &Accruer{store: store, expenseOwner: expenseOwner}
+}
+
+// AccrueDay accrues the interest of the given day for every open account with a rate schedule.
+// Accruing a day twice is a no-op, so the job can safely be re-run.
+func (accruer *Accruer) AccrueDay(ctx context.Context, date time.Time) error {
+	date = truncateDay(date)
+	endOfDay := date.AddDate(0, 0, 1)
+
+	for _, accountType := range db.AccountTypes {
+		rates, err := accruer.store.ListInterestRates(ctx, accountType)
+		if err != nil {
+			return err
+		}
+
+		schedule, err := NewSchedule(rates)
+		if err != nil {
+			return err
+		}
+
+		rate := schedule.RateAt(date)
+		if rate.IsZero() {
+			continue
+		}
+
+		accounts, err := accruer.store.ListAccountsByType(ctx, accountType)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			if !account.CreatedAt.Before(endOfDay) {
+				continue
+			}
+
+			err = accruer.accrueAccount(ctx, account, rate, date, endOfDay)
+			if err != nil {
+				return fmt.Errorf("cannot accrue interest for account [%d]: %w", account.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CatchUp accrues, for every open account with a rate schedule, every day before until which was not accrued yet,
+// from the day after its last accrual or, if it has none, from the day it was created. Days missed while the job
+// was not running are accrued this way on its next run.
+func (accruer *Accruer) CatchUp(ctx context.Context, until time.Time) error {
+	until = truncateDay(until)
+
+	for _, accountType := range db.AccountTypes {
+		rates, err := accruer.store.ListInterestRates(ctx, accountType)
+		if err != nil {
+			return err
+		}
+		if len(rates) == 0 {
+			continue
+		}
+
+		schedule, err := NewSchedule(rates)
+		if err != nil {
+			return err
+		}
+
+		accounts, err := accruer.store.ListAccountsByType(ctx, accountType)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			err = accruer.catchUpAccount(ctx, account, schedule, until)
+			if err != nil {
+				return fmt.Errorf("cannot accrue interest for account [%d]: %w", account.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (accruer *Accruer) catchUpAccount(ctx context.Context, account db.Account, schedule Schedule, until time.Time) error {
+	date := truncateDay(account.CreatedAt)
+	last, err := accruer.store.GetLastInterestAccrual(ctx, account.ID)
+	switch {
+	case err == nil:
+		date = truncateDay(last.AccrualDate).AddDate(0, 0, 1)
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	for ; date.Before(until); date = date.AddDate(0, 0, 1) {
+		rate := schedule.RateAt(date)
+		if rate.IsZero() {
+			continue
+		}
+
+		err = accruer.accrueAccount(ctx, account, rate, date, date.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accrueAccount records the interest of the account for the day, even if it is zero,
+// so its last accrual tells CatchUp which days are done
+func (accruer *Accruer) accrueAccount(ctx context.Context, account db.Account, rate decimal.Decimal, date time.Time, endOfDay time.Time) error {
+	balance, err := accruer.store.GetAccountEndOfDayBalance(ctx, db.GetAccountEndOfDayBalanceParams{
+		ID: account.ID,
+		EndOfDay: endOfDay,
+	})
+	if err != nil {
+		return err
+	}
+
+	amount := DailyInterest(balance, rate, date)
+	_, err = accruer.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{
+		AccountID: account.ID,
+		AccrualDate: date,
+		Balance: balance,
+		AnnualRate: rate.String(),
+		Amount: amount.StringFixed(accrualPrecision),
+	})
+	if errors.Is(err, db.ErrInterestAlreadyAccrued) {
+		return nil
+	}
+	return err
+}
+
+// PostInterest posts the whole cents of accrued interest of every open account for the month of period as a transfer
+// from the interest expense account. Fractional cents remain accrued until the next posting.
+// Accounts that cannot receive the interest, e.g. frozen ones, are skipped and keep their accrued interest.
+// Posting a month twice is a no-op, so the job can safely be re-run, or run by several replicas at once.
+func (accruer *Accruer) PostInterest(ctx context.Context, period time.Time) error {
+	for _, accountType := range db.AccountTypes {
+		accounts, err := accruer.store.ListAccountsByType(ctx, accountType)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			err = accruer.postAccount(ctx, account, period)
+			if err != nil {
+				if errors.Is(err, db.ErrAccountFrozen) {
+					slog.WarnContext(ctx, "interest not posted", "account_id", account.ID, "error", err)
+					continue
+				}
+				return fmt.Errorf("cannot post interest for account [%d]: %w", account.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (accruer *Accruer) postAccount(ctx context.Context, account db.Account, period time.Time) error {
+	accrued, err := decimal.NewFromString(account.AccruedInterest)
+	if err != nil {
+		return err
+	}
+
+	// the amount posted is read again within the transaction, this only skips the accounts with nothing to post
+	cents, _ := SplitCents(accrued)
+	if cents <= 0 {
+		return nil
+	}
+
+	expenseAccount, err := accruer.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner: accruer.expenseOwner,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot find %s interest expense account: %w", account.Currency, err)
+	}
+
+	_, err = accruer.store.PostInterestTx(ctx, db.PostInterestTxParams{
+		AccountID: account.ID,
+		ExpenseAccountID: expenseAccount.ID,
+		Period: period,
+	})
+	if errors.Is(err, db.ErrInterestAlreadyPosted) || errors.Is(err, db.ErrNoInterestToPost) {
+		return nil
+	}
+	return err
+}
+
+// Run catches up the accruals of the days ended so far right after every midnight (UTC) and posts the interest
+// of the previous month on the first day of every month, until the context is done. The month is posted even if
+// some accruals failed, those are accrued on the next run and posted with the next month.
+func (accruer *Accruer) Run(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		nextRun := truncateDay(now).AddDate(0, 0, 1)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(nextRun.Sub(now)):
+		}
+
+		if err := accruer.CatchUp(ctx, nextRun); err != nil {
+			slog.ErrorContext(ctx, "interest accrual failed", "error", err)
+		}
+
+		if nextRun.Day() == 1 {
+			if err := accruer.PostInterest(ctx, nextRun.AddDate(0, -1, 0)); err != nil {
+				slog.ErrorContext(ctx, "interest posting failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueDay(t *testing.T) {
+	day := date(2024, time.February, 29)
+	savings := db.Account{ID: 1, Currency: util.EUR, AccountType: db.AccountTypeSavings, CreatedAt: day.AddDate(0, -1, 0)}
+	newSavings := db.Account{ID: 2, Currency: util.EUR, AccountType: db.AccountTypeSavings, CreatedAt: day.AddDate(0, 0, 1)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListInterestRates(gomock.Any(), db.AccountTypeChecking).
+		Times(1).
+		Return([]db.InterestRate{}, nil)
+	store.EXPECT().
+		ListInterestRates(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.InterestRate{{AccountType: db.AccountTypeSavings, AnnualRate: "0.0366", EffectiveFrom: date(2024, time.January, 1)}}, nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.Account{savings, newSavings}, nil)
+	store.EXPECT().
+		GetAccountEndOfDayBalance(gomock.Any(), gomock.Eq(db.GetAccountEndOfDayBalanceParams{ID: savings.ID, EndOfDay: date(2024, time.March, 1)})).
+		Times(1).
+		Return(int64(100000), nil)
+	store.EXPECT().
+		AccrueInterestTx(gomock.Any(), gomock.Eq(db.AccrueInterestTxParams{
+			AccountID: savings.ID,
+			AccrualDate: day,
+			Balance: 100000,
+			AnnualRate: "0.0366",
+			Amount: "10.0000000000",
+		})).
+		Times(1).
+		Return(db.AccrueInterestTxResult{}, nil)
+
+	err := NewAccruer(store, "simplebank").AccrueDay(context.Background(), day.Add(13*time.Hour))
+	require.NoError(t, err)
+}
+
+func TestAccrueDayTwice(t *testing.T) {
+	day := date(2023, time.June, 1)
+	savings := db.Account{ID: 1, Currency: util.EUR, AccountType: db.AccountTypeSavings, CreatedAt: day.AddDate(0, -1, 0)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListInterestRates(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return([]db.InterestRate{{AnnualRate: "0.0365", EffectiveFrom: date(2023, time.January, 1)}}, nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeChecking).
+		Times(1).
+		Return([]db.Account{}, nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.Account{savings}, nil)
+	store.EXPECT().
+		GetAccountEndOfDayBalance(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(100000), nil)
+	store.EXPECT().
+		AccrueInterestTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.AccrueInterestTxResult{}, db.ErrInterestAlreadyAccrued)
+
+	err := NewAccruer(store, "simplebank").AccrueDay(context.Background(), day)
+	require.NoError(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	until := date(2024, time.March, 1)
+	// last accrued two days ago, the days missed since are accrued, zero interest included
+	savings := db.Account{ID: 1, Currency: util.EUR, AccountType: db.AccountTypeSavings, CreatedAt: date(2024, time.January, 10)}
+	// never accrued, it is accrued from the day it was created
+	newSavings := db.Account{ID: 2, Currency: util.EUR, AccountType: db.AccountTypeSavings, CreatedAt: date(2024, time.February, 29).Add(13 * time.Hour)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListInterestRates(gomock.Any(), db.AccountTypeChecking).
+		Times(1).
+		Return([]db.InterestRate{}, nil)
+	store.EXPECT().
+		ListInterestRates(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.InterestRate{{AccountType: db.AccountTypeSavings, AnnualRate: "0.0366", EffectiveFrom: date(2024, time.January, 1)}}, nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.Account{savings, newSavings}, nil)
+	store.EXPECT().
+		GetLastInterestAccrual(gomock.Any(), savings.ID).
+		Times(1).
+		Return(db.InterestAccrual{AccountID: savings.ID, AccrualDate: date(2024, time.February, 27)}, nil)
+	store.EXPECT().
+		GetLastInterestAccrual(gomock.Any(), newSavings.ID).
+		Times(1).
+		Return(db.InterestAccrual{}, sql.ErrNoRows)
+
+	expectAccrual := func(account db.Account, day time.Time, balance int64, amount string) {
+		store.EXPECT().
+			GetAccountEndOfDayBalance(gomock.Any(), gomock.Eq(db.GetAccountEndOfDayBalanceParams{ID: account.ID, EndOfDay: day.AddDate(0, 0, 1)})).
+			Times(1).
+			Return(balance, nil)
+		store.EXPECT().
+			AccrueInterestTx(gomock.Any(), gomock.Eq(db.AccrueInterestTxParams{
+				AccountID: account.ID,
+				AccrualDate: day,
+				Balance: balance,
+				AnnualRate: "0.0366",
+				Amount: amount,
+			})).
+			Times(1).
+			Return(db.AccrueInterestTxResult{}, nil)
+	}
+	expectAccrual(savings, date(2024, time.February, 28), 0, "0.0000000000")
+	expectAccrual(savings, date(2024, time.February, 29), 100000, "10.0000000000")
+	expectAccrual(newSavings, date(2024, time.February, 29), 100000, "10.0000000000")
+
+	err := NewAccruer(store, "simplebank").CatchUp(context.Background(), until.Add(time.Minute))
+	require.NoError(t, err)
+}
+
+func TestPostInterest(t *testing.T) {
+	savings := db.Account{ID: 1, Currency: util.EUR, AccountType: db.AccountTypeSavings, AccruedInterest: "290.4567890000"}
+	small := db.Account{ID: 2, Currency: util.EUR, AccountType: db.AccountTypeSavings, AccruedInterest: "0.9900000000"}
+	frozen := db.Account{ID: 3, Currency: util.USD, AccountType: db.AccountTypeSavings, AccruedInterest: "12.5000000000"}
+	posted := db.Account{ID: 4, Currency: util.EUR, AccountType: db.AccountTypeSavings, AccruedInterest: "5.0000000000"}
+	period := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	expenseEUR := db.Account{ID: 100, Owner: "simplebank", Currency: util.EUR}
+	expenseUSD := db.Account{ID: 101, Owner: "simplebank", Currency: util.USD}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeChecking).
+		Times(1).
+		Return([]db.Account{}, nil)
+	store.EXPECT().
+		ListAccountsByType(gomock.Any(), db.AccountTypeSavings).
+		Times(1).
+		Return([]db.Account{savings, small, frozen, posted}, nil)
+	store.EXPECT().
+		GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{Owner: "simplebank", Currency: util.EUR})).
+		Times(2).
+		Return(expenseEUR, nil)
+	store.EXPECT().
+		GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{Owner: "simplebank", Currency: util.USD})).
+		Times(1).
+		Return(expenseUSD, nil)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: savings.ID, ExpenseAccountID: expenseEUR.ID, Period: period})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, nil)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: frozen.ID, ExpenseAccountID: expenseUSD.ID, Period: period})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, db.ErrAccountFrozen)
+	// already posted by another replica
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: posted.ID, ExpenseAccountID: expenseEUR.ID, Period: period})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, db.ErrInterestAlreadyPosted)
+
+	err := NewAccruer(store, "simplebank").PostInterest(context.Background(), period)
+	require.NoError(t, err)
+}
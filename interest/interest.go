@@ -0,0 +1,81 @@
+package interest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// Accrued amounts are kept with the same precision as the interest_accruals.amount column
+const accrualPrecision = 10
+
+// Rate is an annual interest rate, as a fraction, effective from a given date
+type Rate struct {
+	AnnualRate decimal.Decimal
+	EffectiveFrom time.Time
+}
+
+// Schedule is a list of rates sorted by effective date
+type Schedule []Rate
+
+func NewSchedule(rates []db.InterestRate) (Schedule, error) {
+	schedule := make(Schedule, 0, len(rates))
+	for _, rate := range rates {
+		annualRate, err := decimal.NewFromString(rate.AnnualRate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annual rate %q: %w", rate.AnnualRate, err)
+		}
+		schedule = append(schedule, Rate{AnnualRate: annualRate, EffectiveFrom: truncateDay(rate.EffectiveFrom)})
+	}
+
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].EffectiveFrom.Before(schedule[j].EffectiveFrom)
+	})
+	return schedule, nil
+}
+
+// RateAt returns the rate in effect on the given date, zero if the schedule has not started yet
+func (schedule Schedule) RateAt(date time.Time) decimal.Decimal {
+	date = truncateDay(date)
+	rate := decimal.Zero
+	for _, r := range schedule {
+		if r.EffectiveFrom.After(date) {
+			break
+		}
+		rate = r.AnnualRate
+	}
+	return rate
+}
+
+func DaysInYear(year int) int {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
+
+// DailyInterest computes the interest, in fractional cents, earned by an end of day balance on the given date.
+// It uses the actual/actual day count convention, so a day in a leap year earns 1/366 of the annual rate.
+// Negative balances do not earn interest.
+func DailyInterest(balance int64, annualRate decimal.Decimal, date time.Time) decimal.Decimal {
+	if balance <= 0 {
+		return decimal.Zero
+	}
+
+	days := decimal.NewFromInt(int64(DaysInYear(date.Year())))
+	return decimal.NewFromInt(balance).Mul(annualRate).DivRound(days, accrualPrecision)
+}
+
+// SplitCents splits accrued interest into the whole cents that can be posted and the fractional remainder
+func SplitCents(accrued decimal.Decimal) (int64, decimal.Decimal) {
+	cents := accrued.Floor()
+	return cents.IntPart(), accrued.Sub(cents)
+}
+
+func truncateDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,98 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// accrueMonth sums the daily interest of a constant balance over a whole month
+func accrueMonth(balance int64, schedule Schedule, year int, month time.Month) decimal.Decimal {
+	total := decimal.Zero
+	for day := date(year, month, 1); day.Month() == month; day = day.AddDate(0, 0, 1) {
+		total = total.Add(DailyInterest(balance, schedule.RateAt(day), day))
+	}
+	return total
+}
+
+func TestDaysInYear(t *testing.T) {
+	require.Equal(t, 365, DaysInYear(2023))
+	require.Equal(t, 366, DaysInYear(2024))
+	require.Equal(t, 365, DaysInYear(2100))
+	require.Equal(t, 366, DaysInYear(2000))
+}
+
+func TestDailyInterest(t *testing.T) {
+	rate := decimal.RequireFromString("0.0365")
+
+	// 1000.00 at 3.65% earns exactly 10 cents a day in a regular year
+	require.True(t, decimal.NewFromInt(10).Equal(DailyInterest(100000, rate, date(2023, time.June, 1))))
+
+	// but 1/366 of the yearly interest in a leap year
+	leap := DailyInterest(100000, rate, date(2024, time.February, 29))
+	require.Equal(t, "9.9726775956", leap.StringFixed(accrualPrecision))
+
+	require.True(t, DailyInterest(0, rate, date(2023, time.June, 1)).IsZero())
+	require.True(t, DailyInterest(-100000, rate, date(2023, time.June, 1)).IsZero())
+}
+
+func TestLeapYearFebruary(t *testing.T) {
+	schedule := Schedule{{AnnualRate: decimal.RequireFromString("0.0366"), EffectiveFrom: date(2020, time.January, 1)}}
+
+	// 29 days at 1/366 of the yearly interest
+	leap := accrueMonth(100000, schedule, 2024, time.February)
+	require.Equal(t, "290.0000000000", leap.StringFixed(accrualPrecision))
+
+	// 28 days at 1/365 of the yearly interest: 100000 * 0.0366 * 28 / 365 = 280.767...
+	regular := accrueMonth(100000, schedule, 2023, time.February)
+	require.Equal(t, "280.77", regular.StringFixed(2))
+}
+
+func TestRateChangeMidMonth(t *testing.T) {
+	rates := []db.InterestRate{
+		{AnnualRate: "0.0730", EffectiveFrom: date(2023, time.March, 16)},
+		{AnnualRate: "0.0365", EffectiveFrom: date(2023, time.January, 1)},
+	}
+	schedule, err := NewSchedule(rates)
+	require.NoError(t, err)
+
+	require.Equal(t, "0.0365", schedule.RateAt(date(2023, time.March, 15)).String())
+	require.Equal(t, "0.073", schedule.RateAt(date(2023, time.March, 16)).String())
+	require.True(t, schedule.RateAt(date(2022, time.December, 31)).IsZero())
+
+	// 15 days earning 10 cents and 16 days earning 20 cents
+	total := accrueMonth(100000, schedule, 2023, time.March)
+	require.True(t, decimal.NewFromInt(15*10+16*20).Equal(total), total.String())
+}
+
+func TestFractionalCentsAccumulate(t *testing.T) {
+	schedule := Schedule{{AnnualRate: decimal.RequireFromString("0.01"), EffectiveFrom: date(2023, time.January, 1)}}
+
+	// 10.00 at 1% earns 0.0273... cents a day, less than a cent in a month
+	january := accrueMonth(1000, schedule, 2023, time.January)
+	cents, remainder := SplitCents(january)
+	require.Zero(t, cents)
+	require.True(t, remainder.Equal(january))
+
+	// the remainder is carried over until it adds up to whole cents
+	accrued := remainder
+	for month := time.February; month <= time.December; month++ {
+		accrued = accrued.Add(accrueMonth(1000, schedule, 2023, month))
+	}
+	cents, remainder = SplitCents(accrued)
+	require.Equal(t, int64(10), cents)
+	require.True(t, remainder.LessThan(decimal.NewFromInt(1)))
+	require.True(t, remainder.GreaterThanOrEqual(decimal.Zero))
+}
+
+func TestInvalidSchedule(t *testing.T) {
+	_, err := NewSchedule([]db.InterestRate{{AnnualRate: "abc"}})
+	require.Error(t, err)
+}
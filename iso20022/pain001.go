@@ -0,0 +1,200 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidNumberOfTransactions = errors.New("number of transactions does not match")
+	ErrInvalidControlSum = errors.New("control sum does not match")
+)
+
+// Document is the root of a pain.001 (CustomerCreditTransferInitiation) message.
+// Only the elements needed to execute the batch are mapped, any other element is ignored.
+type Document struct {
+	XMLName xml.Name `xml:"Document"`
+	CustomerCreditTransferInitiation CustomerCreditTransferInitiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type CustomerCreditTransferInitiation struct {
+	GroupHeader GroupHeader `xml:"GrpHdr"`
+	PaymentInformation []PaymentInformation `xml:"PmtInf"`
+}
+
+type GroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreationDateTime string `xml:"CreDtTm"`
+	NumberOfTransactions string `xml:"NbOfTxs"`
+	ControlSum string `xml:"CtrlSum"`
+	InitiatingParty Party `xml:"InitgPty"`
+}
+
+type PaymentInformation struct {
+	PaymentInformationID string `xml:"PmtInfId"`
+	PaymentMethod string `xml:"PmtMtd"`
+	NumberOfTransactions string `xml:"NbOfTxs"`
+	ControlSum string `xml:"CtrlSum"`
+	Debtor Party `xml:"Dbtr"`
+	DebtorAccount CashAccount `xml:"DbtrAcct"`
+	CreditTransferTransactions []CreditTransferTransaction `xml:"CdtTrfTxInf"`
+}
+
+type Party struct {
+	Name string `xml:"Nm"`
+}
+
+type CashAccount struct {
+	ID AccountIdentification `xml:"Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type AccountIdentification struct {
+	IBAN string `xml:"IBAN"`
+	Other GenericAccountIdentification `xml:"Othr"`
+}
+
+type GenericAccountIdentification struct {
+	ID string `xml:"Id"`
+}
+
+type CreditTransferTransaction struct {
+	PaymentID PaymentIdentification `xml:"PmtId"`
+	Amount AmountType `xml:"Amt"`
+	Creditor Party `xml:"Cdtr"`
+	CreditorAccount CashAccount `xml:"CdtrAcct"`
+}
+
+type PaymentIdentification struct {
+	InstructionID string `xml:"InstrId"`
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+type AmountType struct {
+	InstructedAmount InstructedAmount `xml:"InstdAmt"`
+}
+
+type InstructedAmount struct {
+	Value string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// namespacePrefix is followed by the message name and version in the namespace of ISO 20022 documents
+const namespacePrefix = "urn:iso:std:iso:20022:tech:xsd:"
+
+// defaultMessageNameID is the version documents without namespace are taken to be, the one Document was mapped from
+const defaultMessageNameID = "pain.001.001.03"
+
+// MessageNameID returns the name and version of the message, such as pain.001.001.09, as given by its namespace
+func (doc *Document) MessageNameID() string {
+	name, ok := strings.CutPrefix(doc.XMLName.Space, namespacePrefix)
+	if !ok || name == "" {
+		return defaultMessageNameID
+	}
+	return name
+}
+
+// ParsePain001 decodes a pain.001 document. Any version of the message is accepted,
+// as long as it carries the elements mapped by Document.
+func ParsePain001(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("cannot decode pain.001 document: %w", err)
+	}
+
+	if len(doc.CustomerCreditTransferInitiation.PaymentInformation) == 0 {
+		return nil, errors.New("cannot decode pain.001 document: no payment information found")
+	}
+
+	return &doc, nil
+}
+
+// Validate checks the number of transactions and control sums declared in the group header
+// and in every payment information block against the transactions in the document.
+func (doc *Document) Validate() error {
+	initiation := doc.CustomerCreditTransferInitiation
+
+	var count int
+	var sum int64
+	for _, pmtInf := range initiation.PaymentInformation {
+		pmtCount, pmtSum, err := pmtInf.totals()
+		if err != nil {
+			return err
+		}
+
+		err = checkTotals(pmtInf.NumberOfTransactions, pmtInf.ControlSum, pmtCount, pmtSum)
+		if err != nil {
+			return fmt.Errorf("payment information %s: %w", pmtInf.PaymentInformationID, err)
+		}
+
+		count += pmtCount
+		sum += pmtSum
+	}
+
+	err := checkTotals(initiation.GroupHeader.NumberOfTransactions, initiation.GroupHeader.ControlSum, count, sum)
+	if err != nil {
+		return fmt.Errorf("group header: %w", err)
+	}
+
+	return nil
+}
+
+func (pmtInf PaymentInformation) totals() (count int, sum int64, err error) {
+	for _, tx := range pmtInf.CreditTransferTransactions {
+		amount, err := ParseAmount(tx.Amount.InstructedAmount.Value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("transaction %s: %w", tx.PaymentID.EndToEndID, err)
+		}
+		count++
+		sum += amount
+	}
+	return
+}
+
+// checkTotals compares declared totals against the computed ones.
+// NbOfTxs is optional in payment information blocks and CtrlSum is always optional.
+func checkTotals(numberOfTransactions string, controlSum string, count int, sum int64) error {
+	if numberOfTransactions != "" {
+		declared, err := strconv.Atoi(strings.TrimSpace(numberOfTransactions))
+		if err != nil || declared != count {
+			return ErrInvalidNumberOfTransactions
+		}
+	}
+
+	if controlSum != "" {
+		declared, err := ParseAmount(controlSum)
+		if err != nil || declared != sum {
+			return ErrInvalidControlSum
+		}
+	}
+
+	return nil
+}
+
+// ParseAmount converts a decimal amount such as "1234.5" into minor units (123450).
+// Amounts with more than two decimal digits are rejected, as they cannot be represented
+// in account balances.
+func ParseAmount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	units, cents, found := strings.Cut(value, ".")
+	if units == "" || len(cents) > 2 || (found && cents == "") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+
+	digits := units + cents
+	if strings.ContainsAny(digits, "+-") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	return amount, nil
+}
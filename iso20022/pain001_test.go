@@ -0,0 +1,164 @@
+package iso20022
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>MSG-1</MsgId>
+      <CreDtTm>2023-07-01T10:00:00</CreDtTm>
+      <NbOfTxs>%s</NbOfTxs>
+      <CtrlSum>%s</CtrlSum>
+      <InitgPty><Nm>ACME</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <NbOfTxs>2</NbOfTxs>
+      <Dbtr><Nm>ACME</Nm></Dbtr>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-1</InstrId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <Cdtr><Nm>Supplier 1</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><InstrId>I-2</InstrId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <Cdtr><Nm>Supplier 2</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+type testTransaction struct {
+	currency string
+	amount string
+	creditorAccountID int64
+}
+
+func buildPain001(numberOfTransactions string, controlSum string, debtorAccountID int64, tx1 testTransaction, tx2 testTransaction) string {
+	return fmt.Sprintf(testPain001,
+		numberOfTransactions, controlSum, debtorAccountID,
+		tx1.currency, tx1.amount, tx1.creditorAccountID,
+		tx2.currency, tx2.amount, tx2.creditorAccountID,
+	)
+}
+
+func TestParsePain001(t *testing.T) {
+	data := buildPain001("2", "30.75", 1, testTransaction{"EUR", "10.5", 2}, testTransaction{"EUR", "20.25", 3})
+
+	doc, err := ParsePain001(strings.NewReader(data))
+	require.NoError(t, err)
+
+	initiation := doc.CustomerCreditTransferInitiation
+	require.Equal(t, "MSG-1", initiation.GroupHeader.MessageID)
+	require.Len(t, initiation.PaymentInformation, 1)
+
+	pmtInf := initiation.PaymentInformation[0]
+	require.Equal(t, "PMT-1", pmtInf.PaymentInformationID)
+	require.Equal(t, "1", pmtInf.DebtorAccount.ID.Other.ID)
+	require.Len(t, pmtInf.CreditTransferTransactions, 2)
+
+	tx := pmtInf.CreditTransferTransactions[0]
+	require.Equal(t, "E2E-1", tx.PaymentID.EndToEndID)
+	require.Equal(t, "EUR", tx.Amount.InstructedAmount.Currency)
+	require.Equal(t, "10.5", tx.Amount.InstructedAmount.Value)
+	require.Equal(t, "2", tx.CreditorAccount.ID.Other.ID)
+
+	require.NoError(t, doc.Validate())
+}
+
+func TestMessageNameID(t *testing.T) {
+	data := buildPain001("2", "30.75", 1, testTransaction{"EUR", "10.5", 2}, testTransaction{"EUR", "20.25", 3})
+
+	testCases := []struct {
+		name string
+		namespace string
+		messageNameID string
+	}{
+		{"V03", `xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"`, "pain.001.001.03"},
+		{"V09", `xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"`, "pain.001.001.09"},
+		{"NoNamespace", ``, "pain.001.001.03"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParsePain001(strings.NewReader(strings.Replace(data, `xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"`, tc.namespace, 1)))
+			require.NoError(t, err)
+			require.Equal(t, tc.messageNameID, doc.MessageNameID())
+
+			report := newStatusReport("MSG-2", doc)
+			require.Equal(t, tc.messageNameID, report.CustomerPaymentStatusReport.OriginalGroupInformation.OriginalMessageNameID)
+		})
+	}
+}
+
+func TestParsePain001Invalid(t *testing.T) {
+	_, err := ParsePain001(strings.NewReader("not xml"))
+	require.Error(t, err)
+
+	_, err = ParsePain001(strings.NewReader("<Document><CstmrCdtTrfInitn></CstmrCdtTrfInitn></Document>"))
+	require.Error(t, err)
+}
+
+func TestValidatePain001(t *testing.T) {
+	testCases := []struct {
+		name string
+		numberOfTransactions string
+		controlSum string
+		amount string
+		err error
+	}{
+		{name: "OK", numberOfTransactions: "2", controlSum: "30.00", amount: "10"},
+		{name: "WithoutControlSum", numberOfTransactions: "2", controlSum: "", amount: "10"},
+		{name: "InvalidControlSum", numberOfTransactions: "2", controlSum: "30.01", amount: "10", err: ErrInvalidControlSum},
+		{name: "InvalidNumberOfTransactions", numberOfTransactions: "3", controlSum: "30.00", amount: "10", err: ErrInvalidNumberOfTransactions},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			data := buildPain001(tc.numberOfTransactions, tc.controlSum, 1, testTransaction{"EUR", tc.amount, 2}, testTransaction{"EUR", "20", 3})
+			doc, err := ParsePain001(strings.NewReader(data))
+			require.NoError(t, err)
+
+			err = doc.Validate()
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	valid := map[string]int64{
+		"0": 0,
+		"10": 1000,
+		"10.5": 1050,
+		"10.05": 1005,
+		" 1234.56 ": 123456,
+	}
+	for value, expected := range valid {
+		amount, err := ParseAmount(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, amount, value)
+	}
+
+	for _, value := range []string{"", ".5", "10.", "10.123", "-10", "+10", "1,5", "abc"} {
+		_, err := ParseAmount(value)
+		require.Error(t, err, value)
+	}
+}
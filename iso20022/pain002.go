@@ -0,0 +1,121 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Transaction and group status codes (ExternalPaymentTransactionStatus1Code)
+const (
+	StatusAcceptedSettlementCompleted = "ACSC"
+	StatusPartiallyAccepted = "PART"
+	StatusRejected = "RJCT"
+)
+
+// Status reason codes (ExternalStatusReason1Code)
+const (
+	ReasonInvalidDebtorAccountNumber = "AC02"
+	ReasonInvalidCreditorAccountNumber = "AC03"
+	ReasonClosedAccountNumber = "AC04"
+	ReasonBlockedAccount = "AC06"
+	ReasonInvalidAccountCurrency = "AC09"
+	ReasonTransactionForbidden = "AG01"
+	ReasonNotAllowedAmount = "AM02"
+	ReasonNotAllowedCurrency = "AM03"
+	ReasonInsufficientFunds = "AM04"
+	ReasonInvalidControlSum = "AM10"
+	ReasonInvalidAmount = "AM12"
+	ReasonInvalidNumberOfTransactions = "AM18"
+	ReasonNotSpecified = "MS03"
+	ReasonSyntaxError = "FF01"
+)
+
+// StatusReport is the root of a pain.002 (CustomerPaymentStatusReport) message
+type StatusReport struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.002.001.03 Document"`
+	CustomerPaymentStatusReport CustomerPaymentStatusReport `xml:"CstmrPmtStsRpt"`
+}
+
+type CustomerPaymentStatusReport struct {
+	GroupHeader ReportGroupHeader `xml:"GrpHdr"`
+	OriginalGroupInformation OriginalGroupInformation `xml:"OrgnlGrpInfAndSts"`
+	OriginalPaymentInformation []OriginalPaymentInformation `xml:"OrgnlPmtInfAndSts,omitempty"`
+}
+
+type ReportGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreationDateTime string `xml:"CreDtTm"`
+}
+
+type OriginalGroupInformation struct {
+	OriginalMessageID string `xml:"OrgnlMsgId"`
+	OriginalMessageNameID string `xml:"OrgnlMsgNmId"`
+	OriginalNumberOfTransactions string `xml:"OrgnlNbOfTxs,omitempty"`
+	OriginalControlSum string `xml:"OrgnlCtrlSum,omitempty"`
+	GroupStatus string `xml:"GrpSts"`
+	StatusReason *StatusReason `xml:"StsRsnInf,omitempty"`
+}
+
+type OriginalPaymentInformation struct {
+	OriginalPaymentInformationID string `xml:"OrgnlPmtInfId"`
+	PaymentInformationStatus string `xml:"PmtInfSts"`
+	Transactions []TransactionStatus `xml:"TxInfAndSts"`
+}
+
+type TransactionStatus struct {
+	OriginalInstructionID string `xml:"OrgnlInstrId,omitempty"`
+	OriginalEndToEndID string `xml:"OrgnlEndToEndId"`
+	Status string `xml:"TxSts"`
+	StatusReason *StatusReason `xml:"StsRsnInf,omitempty"`
+	TransferID int64 `xml:"AcctSvcrRef,omitempty"`
+}
+
+type StatusReason struct {
+	Code string `xml:"Rsn>Cd"`
+	AdditionalInformation string `xml:"AddtlInf,omitempty"`
+}
+
+func newStatusReport(messageID string, doc *Document) *StatusReport {
+	header := doc.CustomerCreditTransferInitiation.GroupHeader
+	return &StatusReport{
+		CustomerPaymentStatusReport: CustomerPaymentStatusReport{
+			GroupHeader: ReportGroupHeader{
+				MessageID: messageID,
+				CreationDateTime: time.Now().UTC().Format("2006-01-02T15:04:05"),
+			},
+			OriginalGroupInformation: OriginalGroupInformation{
+				OriginalMessageID: header.MessageID,
+				OriginalMessageNameID: doc.MessageNameID(),
+				OriginalNumberOfTransactions: header.NumberOfTransactions,
+				OriginalControlSum: header.ControlSum,
+			},
+		},
+	}
+}
+
+// Marshal encodes the report as an XML document, including the XML declaration
+func (report *StatusReport) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// aggregateStatus computes the status of a group from the status of its members
+func aggregateStatus(statuses []string) string {
+	accepted := 0
+	for _, status := range statuses {
+		if status == StatusAcceptedSettlementCompleted {
+			accepted++
+		}
+	}
+
+	switch {
+	case len(statuses) > 0 && accepted == len(statuses):
+		return StatusAcceptedSettlementCompleted
+	case accepted == 0:
+		return StatusRejected
+	}
+	return StatusPartiallyAccepted
+}
@@ -0,0 +1,199 @@
+package iso20022
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+)
+
+var errUnknownAccount = errors.New("unknown account")
+
+// Processor executes pain.001 batches against the store
+type Processor struct {
+	store db.Store
+	// requireVerifiedEmail rejects the transfers from accounts whose owner has not verified their email
+	requireVerifiedEmail bool
+	// stepUpTransferAmount, if positive, rejects the transfers of at least that amount, which require
+	// a second factor pain.001 documents cannot carry
+	stepUpTransferAmount int64
+}
+
+func NewProcessor(store db.Store, requireVerifiedEmail bool, stepUpTransferAmount int64) *Processor {
+	return &Processor{store: store, requireVerifiedEmail: requireVerifiedEmail, stepUpTransferAmount: stepUpTransferAmount}
+}
+
+// Process validates the document and executes every credit transfer through CreateTransferTx.
+// Transfers are executed independently: a rejected instruction does not roll back the rest of the batch.
+// If the group totals do not match, the whole group is rejected and nothing is executed.
+func (processor *Processor) Process(ctx context.Context, doc *Document) (*StatusReport, error) {
+	messageID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	report := newStatusReport(messageID.String(), doc)
+	group := &report.CustomerPaymentStatusReport.OriginalGroupInformation
+
+	if err := doc.Validate(); err != nil {
+		group.GroupStatus = StatusRejected
+		group.StatusReason = &StatusReason{Code: validationReason(err), AdditionalInformation: err.Error()}
+		return report, nil
+	}
+
+	var groupStatuses []string
+	for _, pmtInf := range doc.CustomerCreditTransferInitiation.PaymentInformation {
+		pmtReport := OriginalPaymentInformation{OriginalPaymentInformationID: pmtInf.PaymentInformationID}
+
+		var pmtStatuses []string
+		for _, tx := range pmtInf.CreditTransferTransactions {
+			txStatus := processor.execute(ctx, pmtInf.DebtorAccount, tx)
+			pmtReport.Transactions = append(pmtReport.Transactions, txStatus)
+			pmtStatuses = append(pmtStatuses, txStatus.Status)
+		}
+
+		pmtReport.PaymentInformationStatus = aggregateStatus(pmtStatuses)
+		report.CustomerPaymentStatusReport.OriginalPaymentInformation = append(report.CustomerPaymentStatusReport.OriginalPaymentInformation, pmtReport)
+		groupStatuses = append(groupStatuses, pmtStatuses...)
+	}
+	group.GroupStatus = aggregateStatus(groupStatuses)
+
+	return report, nil
+}
+
+func (processor *Processor) execute(ctx context.Context, debtorAccount CashAccount, tx CreditTransferTransaction) TransactionStatus {
+	status := TransactionStatus{
+		OriginalInstructionID: tx.PaymentID.InstructionID,
+		OriginalEndToEndID: tx.PaymentID.EndToEndID,
+		Status: StatusRejected,
+	}
+
+	currency := tx.Amount.InstructedAmount.Currency
+	if !util.IsCurrencySupported(currency) {
+		status.StatusReason = &StatusReason{Code: ReasonNotAllowedCurrency}
+		return status
+	}
+
+	amount, err := ParseAmount(tx.Amount.InstructedAmount.Value)
+	if err != nil || amount <= 0 {
+		status.StatusReason = &StatusReason{Code: ReasonInvalidAmount}
+		return status
+	}
+	if processor.stepUpTransferAmount > 0 && amount >= processor.stepUpTransferAmount {
+		status.StatusReason = &StatusReason{Code: ReasonNotAllowedAmount}
+		return status
+	}
+
+	from, reason := processor.validAccount(ctx, debtorAccount, currency, ReasonInvalidDebtorAccountNumber)
+	if reason == "" {
+		reason = processor.verifiedOwner(ctx, from)
+	}
+	if reason != "" {
+		status.StatusReason = &StatusReason{Code: reason}
+		return status
+	}
+
+	to, reason := processor.validAccount(ctx, tx.CreditorAccount, currency, ReasonInvalidCreditorAccountNumber)
+	if reason != "" {
+		status.StatusReason = &StatusReason{Code: reason}
+		return status
+	}
+
+	result, err := processor.store.CreateTransferTx(ctx, db.CreateTransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID: to.ID,
+		Amount: amount,
+	})
+	if err != nil {
+		status.StatusReason = &StatusReason{Code: ReasonNotSpecified}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			status.StatusReason.Code = ReasonInsufficientFunds
+		}
+		return status
+	}
+
+	status.Status = StatusAcceptedSettlementCompleted
+	status.TransferID = result.Transfer.ID
+	return status
+}
+
+// validAccount resolves a pain.001 account into a simplebank account with the given currency.
+// It returns a non empty reason code when the account cannot be used.
+func (processor *Processor) validAccount(ctx context.Context, cashAccount CashAccount, currency string, unknownReason string) (db.Account, string) {
+	account, err := processor.resolveAccount(ctx, cashAccount.ID)
+	if err != nil {
+		if err == errUnknownAccount {
+			return account, unknownReason
+		}
+		return account, ReasonNotSpecified
+	}
+
+	switch db.CheckAccountActive(account) {
+	case db.ErrAccountFrozen:
+		return account, ReasonBlockedAccount
+	case db.ErrAccountClosed:
+		return account, ReasonClosedAccountNumber
+	}
+
+	if account.Currency != currency {
+		return account, ReasonInvalidAccountCurrency
+	}
+
+	return account, ""
+}
+
+// verifiedOwner returns a non empty reason code when the owner of the debtor account has to verify their email first
+func (processor *Processor) verifiedOwner(ctx context.Context, account db.Account) string {
+	if !processor.requireVerifiedEmail {
+		return ""
+	}
+
+	owner, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return ReasonNotSpecified
+	}
+	if !owner.IsEmailVerified {
+		return ReasonTransactionForbidden
+	}
+	return ""
+}
+
+// resolveAccount maps a pain.001 account identification into a simplebank account.
+// Accounts are identified by their account number in the IBAN identification
+// or by their simplebank ID in the proprietary (Othr) identification.
+func (processor *Processor) resolveAccount(ctx context.Context, id AccountIdentification) (db.Account, error) {
+	var account db.Account
+	var err error
+	if id.IBAN != "" {
+		iban := util.NormalizeIBAN(id.IBAN)
+		if util.ValidateIBAN(iban) != nil {
+			return account, errUnknownAccount
+		}
+		account, err = processor.store.GetAccountByNumber(ctx, iban)
+	} else {
+		accountID, parseErr := strconv.ParseInt(id.Other.ID, 10, 64)
+		if parseErr != nil || accountID < 1 {
+			return account, errUnknownAccount
+		}
+		account, err = processor.store.GetAccount(ctx, accountID)
+	}
+
+	if err == sql.ErrNoRows {
+		return account, errUnknownAccount
+	}
+	return account, err
+}
+
+func validationReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidControlSum):
+		return ReasonInvalidControlSum
+	case errors.Is(err, ErrInvalidNumberOfTransactions):
+		return ReasonInvalidNumberOfTransactions
+	}
+	return ReasonInvalidAmount
+}
@@ -0,0 +1,283 @@
+package iso20022
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPain001(t *testing.T) {
+	debtor := db.Account{ID: 1, Owner: "acme", Balance: 10000, Currency: "EUR", Status: db.AccountStatusActive}
+	creditor1 := db.Account{ID: 2, Owner: "supplier1", Currency: "EUR", Status: db.AccountStatusActive}
+	creditor2 := db.Account{ID: 3, Owner: "supplier2", Currency: "USD", Status: db.AccountStatusActive}
+	frozen := db.Account{ID: 4, Owner: "supplier3", Currency: "EUR", Status: db.AccountStatusFrozen}
+	creditor1.AccountNumber = "ES2999990000000000001234"
+
+	testCases := []struct {
+		name string
+		data string
+		requireVerifiedEmail bool
+		stepUpTransferAmount int64
+		buildStubs func(store *mockdb.MockStore)
+		checkReport func(t *testing.T, report *StatusReport)
+	}{
+		{
+			name: "OK",
+			data: buildPain001("2", "30.75", debtor.ID, testTransaction{"EUR", "10.5", creditor1.ID}, testTransaction{"EUR", "20.25", creditor1.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(2).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: debtor.ID, ToAccountID: creditor1.ID, Amount: 1050})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 100}}, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: debtor.ID, ToAccountID: creditor1.ID, Amount: 2025})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 101}}, nil)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusAcceptedSettlementCompleted, status.OriginalGroupInformation.GroupStatus)
+				require.Len(t, status.OriginalPaymentInformation, 1)
+
+				txs := status.OriginalPaymentInformation[0].Transactions
+				require.Len(t, txs, 2)
+				require.Equal(t, "E2E-1", txs[0].OriginalEndToEndID)
+				require.Equal(t, StatusAcceptedSettlementCompleted, txs[0].Status)
+				require.Equal(t, int64(100), txs[0].TransferID)
+				require.Equal(t, int64(101), txs[1].TransferID)
+			},
+		},
+		{
+			name: "PartiallyAccepted",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor2.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(1).Return(creditor1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor2.ID).Times(1).Return(creditor2, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateTransferTxResult{}, nil)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusPartiallyAccepted, status.OriginalGroupInformation.GroupStatus)
+
+				txs := status.OriginalPaymentInformation[0].Transactions
+				require.Equal(t, StatusAcceptedSettlementCompleted, txs[0].Status)
+				require.Equal(t, StatusRejected, txs[1].Status)
+				require.Equal(t, ReasonInvalidAccountCurrency, txs[1].StatusReason.Code)
+			},
+		},
+		{
+			name: "Rejected",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"GBP", "10", creditor1.ID}, testTransaction{"EUR", "20", 999}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(1).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), int64(999)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+
+				txs := status.OriginalPaymentInformation[0].Transactions
+				require.Equal(t, ReasonNotAllowedCurrency, txs[0].StatusReason.Code)
+				require.Equal(t, ReasonInvalidCreditorAccountNumber, txs[1].StatusReason.Code)
+			},
+		},
+		{
+			name: "CreditorByIBAN",
+			data: strings.Replace(
+				buildPain001("2", "30", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+				"<CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct>",
+				"<CdtrAcct><Id><IBAN>ES29 9999 0000 0000 0000 1234</IBAN></Id></CdtrAcct>",
+				-1,
+			),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), creditor1.AccountNumber).Times(2).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: debtor.ID, ToAccountID: creditor1.ID, Amount: 1000})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 100}}, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: debtor.ID, ToAccountID: creditor1.ID, Amount: 2000})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 101}}, nil)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				require.Equal(t, StatusAcceptedSettlementCompleted, report.CustomerPaymentStatusReport.OriginalGroupInformation.GroupStatus)
+			},
+		},
+		{
+			name: "UnknownIBAN",
+			data: strings.Replace(
+				buildPain001("2", "30", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+				"<CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct>",
+				"<CdtrAcct><Id><IBAN>ES0099990000000000001234</IBAN></Id></CdtrAcct>",
+				-1,
+			),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+				tx := status.OriginalPaymentInformation[0].Transactions[0]
+				require.Equal(t, StatusRejected, tx.Status)
+				require.Equal(t, ReasonInvalidCreditorAccountNumber, tx.StatusReason.Code)
+			},
+		},
+		{
+			name: "FrozenAccount",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", frozen.ID}, testTransaction{"EUR", "20", frozen.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), frozen.ID).Times(2).Return(frozen, nil)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+				require.Equal(t, ReasonBlockedAccount, status.OriginalPaymentInformation[0].Transactions[0].StatusReason.Code)
+			},
+		},
+		{
+			name: "TransferError",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(2).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.CreateTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+				require.Equal(t, ReasonNotSpecified, status.OriginalPaymentInformation[0].Transactions[0].StatusReason.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(2).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.CreateTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+				require.Equal(t, ReasonInsufficientFunds, status.OriginalPaymentInformation[0].Transactions[0].StatusReason.Code)
+			},
+		},
+		{
+			name: "VerifiedEmail",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			requireVerifiedEmail: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetUser(gomock.Any(), debtor.Owner).Times(2).Return(db.User{Username: debtor.Owner, IsEmailVerified: true}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(2).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 100}}, nil)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				require.Equal(t, StatusAcceptedSettlementCompleted, report.CustomerPaymentStatusReport.OriginalGroupInformation.GroupStatus)
+			},
+		},
+		{
+			name: "EmailNotVerified",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			requireVerifiedEmail: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(2).Return(debtor, nil)
+				store.EXPECT().GetUser(gomock.Any(), debtor.Owner).Times(2).Return(db.User{Username: debtor.Owner}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusRejected, status.OriginalGroupInformation.GroupStatus)
+				require.Equal(t, ReasonTransactionForbidden, status.OriginalPaymentInformation[0].Transactions[0].StatusReason.Code)
+			},
+		},
+		{
+			name: "StepUpAmount",
+			data: buildPain001("2", "30.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			stepUpTransferAmount: 2000,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), debtor.ID).Times(1).Return(debtor, nil)
+				store.EXPECT().GetAccount(gomock.Any(), creditor1.ID).Times(1).Return(creditor1, nil)
+				store.EXPECT().
+					CreateTransferTx(gomock.Any(), gomock.Eq(db.CreateTransferTxParams{FromAccountID: debtor.ID, ToAccountID: creditor1.ID, Amount: 1000})).
+					Times(1).
+					Return(db.CreateTransferTxResult{Transfer: db.Transfer{ID: 100}}, nil)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				status := report.CustomerPaymentStatusReport
+				require.Equal(t, StatusPartiallyAccepted, status.OriginalGroupInformation.GroupStatus)
+
+				txs := status.OriginalPaymentInformation[0].Transactions
+				require.Equal(t, StatusAcceptedSettlementCompleted, txs[0].Status)
+				require.Equal(t, StatusRejected, txs[1].Status)
+				require.Equal(t, ReasonNotAllowedAmount, txs[1].StatusReason.Code)
+			},
+		},
+		{
+			name: "InvalidControlSum",
+			data: buildPain001("2", "31.00", debtor.ID, testTransaction{"EUR", "10", creditor1.ID}, testTransaction{"EUR", "20", creditor1.ID}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReport: func(t *testing.T, report *StatusReport) {
+				group := report.CustomerPaymentStatusReport.OriginalGroupInformation
+				require.Equal(t, StatusRejected, group.GroupStatus)
+				require.Equal(t, ReasonInvalidControlSum, group.StatusReason.Code)
+				require.Empty(t, report.CustomerPaymentStatusReport.OriginalPaymentInformation)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			doc, err := ParsePain001(strings.NewReader(tc.data))
+			require.NoError(t, err)
+
+			report, err := NewProcessor(store, tc.requireVerifiedEmail, tc.stepUpTransferAmount).Process(context.Background(), doc)
+			require.NoError(t, err)
+			require.Equal(t, "MSG-1", report.CustomerPaymentStatusReport.OriginalGroupInformation.OriginalMessageID)
+			tc.checkReport(t, report)
+
+			data, err := report.Marshal()
+			require.NoError(t, err)
+			require.Contains(t, string(data), "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	redacted = "[REDACTED]"
+	maxRequestIDLength = 128
+)
+
+// sensitiveKeys are attribute keys whose values are never written to the logs
+var sensitiveKeys = map[string]bool{
+	"password": true,
+	"hashed_password": true,
+	"token": true,
+	"access_token": true,
+	"refresh_token": true,
+	"authorization": true,
+	"email": true,
+}
+
+type requestIDKey struct{}
+
+// New creates a logger writing to w in the given format ("json" or "text") and minimum level
+func New(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	options := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", format)
+}
+
+// redact hides the value of sensitive attributes, whatever group they are in
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// RequestIDOrNew returns the request ID provided by a client, or a new one if it is missing or invalid.
+// Only printable ASCII characters are accepted, so client provided IDs cannot inject anything into the logs.
+func RequestIDOrNew(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+	return requestID
+}
+
+// WithRequestID returns a copy of ctx carrying the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the default logger, annotated with the request ID and trace ID carried by ctx
+func FromContext(ctx context.Context) *slog.Logger {
+	log := slog.Default()
+	if requestID := RequestID(ctx); requestID != "" {
+		log = log.With("request_id", requestID)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		log = log.With("trace_id", spanContext.TraceID().String())
+	}
+	return log
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "json")
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	require.Empty(t, buf.String())
+
+	logger.Warn("shown", "account_id", 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "shown", entry["msg"])
+	require.Equal(t, float64(1), entry["account_id"])
+
+	buf.Reset()
+	logger, err = New(&buf, "debug", "text")
+	require.NoError(t, err)
+	logger.Debug("shown")
+	require.Contains(t, buf.String(), "level=DEBUG msg=shown")
+
+	_, err = New(&buf, "verbose", "json")
+	require.Error(t, err)
+
+	_, err = New(&buf, "info", "xml")
+	require.Error(t, err)
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	logger.Info("login",
+		"username", "johndoe",
+		"password", "secret",
+		"Email", "john@example.com",
+		slog.Group("request", "authorization", "Bearer v2.local.token", "path", "/users"),
+	)
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "john@example.com")
+	require.NotContains(t, buf.String(), "v2.local.token")
+
+	var entry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Email string `json:"Email"`
+		Request struct {
+			Authorization string `json:"authorization"`
+			Path string `json:"path"`
+		} `json:"request"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "johndoe", entry.Username)
+	require.Equal(t, redacted, entry.Password)
+	require.Equal(t, redacted, entry.Email)
+	require.Equal(t, redacted, entry.Request.Authorization)
+	require.Equal(t, "/users", entry.Request.Path)
+}
+
+func TestRequestIDOrNew(t *testing.T) {
+	require.Equal(t, "abc-123", RequestIDOrNew("abc-123"))
+
+	for _, requestID := range []string{"", "abc 123", "abc\n123", strings.Repeat("a", maxRequestIDLength+1)} {
+		newRequestID := RequestIDOrNew(requestID)
+		require.NotEqual(t, requestID, newRequestID)
+		_, err := uuid.Parse(newRequestID)
+		require.NoError(t, err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, RequestID(ctx))
+
+	ctx = WithRequestID(ctx, "abc")
+	require.Equal(t, "abc", RequestID(ctx))
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(defaultLogger)
+
+	FromContext(ctx).Info("with request")
+	require.Contains(t, buf.String(), `"request_id":"abc"`)
+}
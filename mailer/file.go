@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every email to a .eml file in a directory, for development
+type FileMailer struct {
+	dir string
+	from string
+	mu sync.Mutex
+	sent int
+}
+
+// NewFileMailer creates a mailer writing emails from the given address to dir, which is created if missing
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (mailer *FileMailer) Send(ctx context.Context, msg Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	if err := os.MkdirAll(mailer.dir, 0o700); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mailer.sent++
+	name := fmt.Sprintf("%s-%d.eml", now.UTC().Format("20060102T150405.000000000"), mailer.sent)
+	return os.WriteFile(filepath.Join(mailer.dir, name), msg.bytes(mailer.from, now), 0o600)
+}
@@ -0,0 +1,66 @@
+// Package mailer sends the emails of the bank to its users, through SMTP or, for development and tests,
+// to files or memory.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+)
+
+const (
+	MailerSMTP = "smtp"
+	MailerFile = "file"
+	MailerMemory = "memory"
+)
+
+// Message is a plain text email
+type Message struct {
+	To string
+	Subject string
+	Body string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer named by the configuration, or nil if none is set
+func New(config util.Config) (Mailer, error) {
+	switch config.Mailer {
+	case "":
+		return nil, nil
+	case MailerSMTP:
+		return NewSMTPMailer(config.SMTPAddress, config.SMTPUsername, config.SMTPPassword, config.MailFrom, smtpTimeout), nil
+	case MailerFile:
+		return NewFileMailer(config.MailDir, config.MailFrom), nil
+	case MailerMemory:
+		return NewMemoryMailer(), nil
+	}
+	return nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+}
+
+// headerValue keeps values from adding headers of their own
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// bytes formats the message as an RFC 5322 email from the given address
+func (msg Message) bytes(from string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{
+		To: "user@example.com\r\nBcc: other@example.com",
+		Subject: "Verify your email",
+		Body: "Hello\nWorld\n",
+	}
+
+	data := string(msg.bytes("SimpleBank <no-reply@example.com>", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	headers, body, ok := strings.Cut(data, "\r\n\r\n")
+	require.True(t, ok)
+	require.Contains(t, headers, "From: SimpleBank <no-reply@example.com>\r\n")
+	require.Contains(t, headers, "To: user@example.comBcc: other@example.com\r\n")
+	require.Contains(t, headers, "Subject: Verify your email\r\n")
+	require.Contains(t, headers, "Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n")
+	require.Contains(t, headers, "Content-Type: text/plain; charset=utf-8")
+	require.NotContains(t, headers, "\r\nBcc:")
+	require.Equal(t, "Hello\r\nWorld\r\n", body)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "no-reply@example.com")
+
+	for i := 0; i < 2; i++ {
+		err := mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "World"})
+		require.NoError(t, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "To: user@example.com\r\n")
+	require.True(t, strings.HasSuffix(string(data), "\r\n\r\nWorld"))
+}
+
+func TestNew(t *testing.T) {
+	mailer, err := New(util.Config{})
+	require.NoError(t, err)
+	require.Nil(t, mailer)
+
+	mailer, err = New(util.Config{Mailer: MailerSMTP, SMTPAddress: "localhost:25"})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, mailer)
+
+	mailer, err = New(util.Config{Mailer: MailerFile, MailDir: t.TempDir()})
+	require.NoError(t, err)
+	require.IsType(t, &FileMailer{}, mailer)
+
+	_, err = New(util.Config{Mailer: "pigeon"})
+	require.Error(t, err)
+}
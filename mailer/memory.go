@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryMailer keeps the emails it is given, for tests
+type MemoryMailer struct {
+	mu sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mailer *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	mailer.messages = append(mailer.messages, msg)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	return slices.Clone(mailer.messages)
+}
@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+// PasswordResetMessage is the email sending the user the link to reset their password, resetURL with the token
+// as query parameter
+func PasswordResetMessage(user db.User, resetURL string, reset db.CreatePasswordResetTxResult) Message {
+	query := url.Values{}
+	query.Set("token", reset.Token)
+	link := resetURL + "?" + query.Encode()
+
+	return Message{
+		To: user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nYou can choose a new password by following this link before %s:\n\n%s\n\n"+
+			"If you did not ask to reset your password, you can ignore this email.\n",
+			user.Username, reset.PasswordReset.ExpiredAt.Format(time.RFC1123), link),
+	}
+}
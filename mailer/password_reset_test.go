@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+var resetLink = regexp.MustCompile(`https://bank.example.com/reset\?\S+`)
+
+func TestPasswordResetMessage(t *testing.T) {
+	store := db.NewMemoryStore()
+	user := createUser(t, store)
+
+	reset, err := store.CreatePasswordResetTx(context.Background(), db.CreatePasswordResetTxParams{Username: user.Username})
+	require.NoError(t, err)
+
+	msg := PasswordResetMessage(user, "https://bank.example.com/reset", reset)
+	require.Equal(t, user.Email, msg.To)
+	require.Contains(t, msg.Body, user.Username)
+	require.NotContains(t, msg.Body, reset.PasswordReset.TokenHash)
+
+	link, err := url.Parse(resetLink.FindString(msg.Body))
+	require.NoError(t, err)
+
+	// the link carries the token resetting the password
+	result, err := store.ResetPasswordTx(context.Background(), db.ResetPasswordTxParams{
+		Token: link.Query().Get("token"),
+		HashedPassword: "new hashed",
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+}
@@ -0,0 +1,91 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout bounds the time taken to send an email when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection with STARTTLS when the server offers it
+type SMTPMailer struct {
+	address string
+	username string
+	password string
+	from string
+	timeout time.Duration
+}
+
+// NewSMTPMailer creates a mailer sending emails from the given address through the server at address (host:port).
+// It authenticates with username and password if username is set.
+func NewSMTPMailer(address string, username string, password string, from string, timeout time.Duration) *SMTPMailer {
+	return &SMTPMailer{address: address, username: username, password: password, from: from, timeout: timeout}
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	host, _, err := net.SplitHostPort(mailer.address)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: mailer.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.address)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(mailer.timeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if mailer.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", mailer.username, mailer.password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(mailer.from)); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.bytes(mailer.from, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// envelopeAddress returns the bare address of from, which may carry a display name
+func envelopeAddress(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return from
+	}
+	return addr.Address
+}
@@ -0,0 +1,98 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+const (
+	// batchSize is the most verification emails claimed at once
+	batchSize = 20
+	// batchLease is how long the claimed emails are kept from other senders while they are sent
+	batchLease = 2 * time.Minute
+)
+
+// VerifyEmailSender sends the verification emails the store queues for new users
+type VerifyEmailSender struct {
+	store db.Store
+	mailer Mailer
+	// verifyURL is the page the emails link to, with the email id and secret code as query parameters
+	verifyURL string
+	// interval is how long the sender waits before looking for new emails
+	interval time.Duration
+}
+
+func NewVerifyEmailSender(store db.Store, mailer Mailer, verifyURL string, interval time.Duration) *VerifyEmailSender {
+	return &VerifyEmailSender{store: store, mailer: mailer, verifyURL: verifyURL, interval: interval}
+}
+
+// SendPending sends the verification emails not sent yet, batch after batch,
+// until none is left or the mailer fails on some
+func (sender *VerifyEmailSender) SendPending(ctx context.Context) error {
+	for {
+		result, err := sender.store.SendVerifyEmailsTx(ctx, db.SendVerifyEmailsTxParams{
+			Limit: batchSize,
+			Lease: batchLease,
+			Send: sender.send,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the failed emails would be the first of the next batch, they are sent again on the next run
+		if result.Failed > 0 || result.Sent < batchSize {
+			return nil
+		}
+	}
+}
+
+func (sender *VerifyEmailSender) send(ctx context.Context, verifyEmail db.VerifyEmail) error {
+	err := sender.mailer.Send(ctx, sender.message(verifyEmail))
+	if err != nil {
+		slog.WarnContext(ctx, "verification email not sent",
+			"verify_email_id", verifyEmail.ID,
+			"username", verifyEmail.Username,
+			"error", err,
+		)
+	}
+	return err
+}
+
+// message is the email asking the user to follow the verification link
+func (sender *VerifyEmailSender) message(verifyEmail db.VerifyEmail) Message {
+	query := url.Values{}
+	query.Set("email_id", strconv.FormatInt(verifyEmail.ID, 10))
+	query.Set("secret_code", verifyEmail.SecretCode)
+	link := sender.verifyURL + "?" + query.Encode()
+
+	return Message{
+		To: verifyEmail.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease verify your email address by following this link before %s:\n\n%s\n",
+			verifyEmail.Username, verifyEmail.ExpiredAt.Format(time.RFC1123), link),
+	}
+}
+
+// Run sends the pending verification emails every interval until the context is done
+func (sender *VerifyEmailSender) Run(ctx context.Context) {
+	ticker := time.NewTicker(sender.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sender.SendPending(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "verification email sender failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
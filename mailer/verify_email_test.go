@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// failingMailer fails to send every email
+type failingMailer struct{}
+
+func (failingMailer) Send(context.Context, Message) error {
+	return errors.New("unavailable")
+}
+
+var verifyLink = regexp.MustCompile(`https://bank.example.com/verify\?\S+`)
+
+func createUser(t *testing.T, store db.Store) db.User {
+	user, err := store.CreateUserTx(context.Background(), db.CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestVerifyEmailSender(t *testing.T) {
+	store := db.NewMemoryStore()
+	user := createUser(t, store)
+
+	// emails the mailer fails on are sent on the next run
+	err := NewVerifyEmailSender(store, failingMailer{}, "https://bank.example.com/verify", time.Second).SendPending(context.Background())
+	require.NoError(t, err)
+
+	mailer := NewMemoryMailer()
+	sender := NewVerifyEmailSender(store, mailer, "https://bank.example.com/verify", time.Second)
+	require.NoError(t, sender.SendPending(context.Background()))
+	require.NoError(t, sender.SendPending(context.Background()))
+
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, user.Email, messages[0].To)
+	require.Contains(t, messages[0].Body, user.Username)
+
+	// the link verifies the email
+	link, err := url.Parse(verifyLink.FindString(messages[0].Body))
+	require.NoError(t, err)
+	emailID, err := strconv.ParseInt(link.Query().Get("email_id"), 10, 64)
+	require.NoError(t, err)
+
+	result, err := store.VerifyEmailTx(context.Background(), db.VerifyEmailTxParams{
+		EmailID: emailID,
+		SecretCode: link.Query().Get("secret_code"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.True(t, result.User.IsEmailVerified)
+}
+
+func TestVerifyEmailSenderBatches(t *testing.T) {
+	store := db.NewMemoryStore()
+	for i := 0; i < batchSize+5; i++ {
+		createUser(t, store)
+	}
+
+	mailer := NewMemoryMailer()
+	err := NewVerifyEmailSender(store, mailer, "https://bank.example.com/verify", time.Second).SendPending(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mailer.Messages(), batchSize+5)
+}
@@ -1,31 +1,13 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"os"
 
-	"github.com/gorkaio/simplebank/api"
-	db "github.com/gorkaio/simplebank/db/sqlc"
-	"github.com/gorkaio/simplebank/util"
-	_ "github.com/lib/pq"
+	"github.com/gorkaio/simplebank/cmd"
 )
 
 func main() {
-	config, err := util.LoadConfig(".")
-	if err != nil {
-		log.Fatal("cannot load configuration:", err)
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
 	}
-
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
-	if err != nil {
-		log.Fatal("Cannot connect to db: ", err)
-	}
-
-	store := db.NewStore(conn)
-	server := api.NewServer(store)
-
-	err = server.Start(config.ServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server:", err)
-	}
-}
\ No newline at end of file
+}
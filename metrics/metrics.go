@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simplebank"
+
+// Metrics holds every metric of the service in its own registry,
+// so tests can create as many instances as they need and inspect them in process
+type Metrics struct {
+	Registry *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	dbTxs *prometheus.CounterVec
+	dbTxDuration prometheus.Histogram
+	dbTxRetries *prometheus.CounterVec
+	transfers *prometheus.CounterVec
+	transferredAmount *prometheus.CounterVec
+	failedLogins prometheus.Counter
+	rateLimited *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	metrics := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name: "http_request_duration_seconds",
+			Help: "HTTP request latency, by route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbTxs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "db_transactions_total",
+			Help: "Store transactions, by outcome (commit or rollback).",
+		}, []string{"outcome"}),
+		dbTxDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name: "db_transaction_duration_seconds",
+			Help: "Store transaction duration.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		dbTxRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "db_transaction_retries_total",
+			Help: "Store transactions run again, by the error that made them fail (serialization_failure or deadlock_detected).",
+		}, []string{"reason"}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "transfers_created_total",
+			Help: "Transfers created, by currency.",
+		}, []string{"currency"}),
+		transferredAmount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "transferred_amount_cents_total",
+			Help: "Amount moved by transfers in cents, by currency.",
+		}, []string{"currency"}),
+		failedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "failed_logins_total",
+			Help: "Login attempts rejected because of an unknown user, a wrong password or a wrong two-factor code.",
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "rate_limited_requests_total",
+			Help: "Requests rejected by the rate limiter, by HTTP route or gRPC method.",
+		}, []string{"route"}),
+	}
+
+	metrics.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.httpRequests,
+		metrics.httpRequestDuration,
+		metrics.dbTxs,
+		metrics.dbTxDuration,
+		metrics.dbTxRetries,
+		metrics.transfers,
+		metrics.transferredAmount,
+		metrics.failedLogins,
+		metrics.rateLimited,
+	)
+	return metrics
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func (metrics *Metrics) RegisterDB(db *sql.DB) {
+	metrics.Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{Registry: metrics.Registry})
+}
+
+// ObserveHTTPRequest records a served HTTP request. Route must be the route pattern, not the actual path,
+// to keep the number of series bounded.
+func (metrics *Metrics) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	metrics.httpRequests.With(labels).Inc()
+	metrics.httpRequestDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObserveTx records the outcome and duration of a store transaction
+func (metrics *Metrics) ObserveTx(duration time.Duration, committed bool) {
+	outcome := "rollback"
+	if committed {
+		outcome = "commit"
+	}
+	metrics.dbTxs.WithLabelValues(outcome).Inc()
+	metrics.dbTxDuration.Observe(duration.Seconds())
+}
+
+// TxRetried records a store transaction run again after failing with the Postgres error named reason
+func (metrics *Metrics) TxRetried(reason string) {
+	metrics.dbTxRetries.WithLabelValues(reason).Inc()
+}
+
+// TransferCreated records a committed transfer of amount cents
+func (metrics *Metrics) TransferCreated(currency string, amount int64) {
+	metrics.transfers.WithLabelValues(currency).Inc()
+	metrics.transferredAmount.WithLabelValues(currency).Add(float64(amount))
+}
+
+// LoginFailed records a rejected login attempt
+func (metrics *Metrics) LoginFailed() {
+	metrics.failedLogins.Inc()
+}
+
+// RateLimited records a request rejected by the rate limiter. Route must be a route pattern or a gRPC method.
+func (metrics *Metrics) RateLimited(route string) {
+	metrics.rateLimited.WithLabelValues(route).Inc()
+}
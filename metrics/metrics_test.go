@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	metrics := New()
+	metrics.ObserveHTTPRequest(http.MethodGet, "/accounts/:id", http.StatusOK, 10*time.Millisecond)
+	metrics.ObserveHTTPRequest(http.MethodGet, "/accounts/:id", http.StatusOK, 20*time.Millisecond)
+	metrics.ObserveHTTPRequest(http.MethodGet, "/accounts/:id", http.StatusNotFound, 5*time.Millisecond)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.httpRequests.WithLabelValues(http.MethodGet, "/accounts/:id", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.httpRequests.WithLabelValues(http.MethodGet, "/accounts/:id", "404")))
+	require.Equal(t, 2, testutil.CollectAndCount(metrics.httpRequestDuration))
+}
+
+func TestObserveTx(t *testing.T) {
+	metrics := New()
+	metrics.ObserveTx(time.Millisecond, true)
+	metrics.ObserveTx(time.Millisecond, true)
+	metrics.ObserveTx(time.Millisecond, false)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.dbTxs.WithLabelValues("commit")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.dbTxs.WithLabelValues("rollback")))
+
+	expected := `
+# HELP simplebank_db_transaction_duration_seconds Store transaction duration.
+# TYPE simplebank_db_transaction_duration_seconds histogram
+simplebank_db_transaction_duration_seconds_bucket{le="0.005"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.01"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.025"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.05"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.1"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.25"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="0.5"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="1"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="2.5"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="5"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="10"} 3
+simplebank_db_transaction_duration_seconds_bucket{le="+Inf"} 3
+simplebank_db_transaction_duration_seconds_sum 0.003
+simplebank_db_transaction_duration_seconds_count 3
+`
+	err := testutil.GatherAndCompare(metrics.Registry, strings.NewReader(expected), "simplebank_db_transaction_duration_seconds")
+	require.NoError(t, err)
+}
+
+func TestTxRetried(t *testing.T) {
+	metrics := New()
+	metrics.TxRetried("deadlock_detected")
+	metrics.TxRetried("serialization_failure")
+	metrics.TxRetried("serialization_failure")
+
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.dbTxRetries.WithLabelValues("deadlock_detected")))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.dbTxRetries.WithLabelValues("serialization_failure")))
+}
+
+func TestBusinessEvents(t *testing.T) {
+	metrics := New()
+	metrics.TransferCreated("EUR", 1000)
+	metrics.TransferCreated("EUR", 250)
+	metrics.TransferCreated("USD", 10)
+	metrics.LoginFailed()
+	metrics.RateLimited("POST /users")
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.transfers.WithLabelValues("EUR")))
+	require.Equal(t, float64(1250), testutil.ToFloat64(metrics.transferredAmount.WithLabelValues("EUR")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.transfers.WithLabelValues("USD")))
+	require.Equal(t, float64(10), testutil.ToFloat64(metrics.transferredAmount.WithLabelValues("USD")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.failedLogins))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.rateLimited.WithLabelValues("POST /users")))
+}
+
+func TestHandler(t *testing.T) {
+	metrics := New()
+
+	// opening a database does not connect to it, which is enough to read its pool stats
+	conn, err := sql.Open("postgres", "postgresql://localhost/simple_bank?sslmode=disable")
+	require.NoError(t, err)
+	defer conn.Close()
+	metrics.RegisterDB(conn)
+
+	metrics.TransferCreated("EUR", 1000)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	metrics.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `simplebank_transfers_created_total{currency="EUR"} 1`)
+	require.Contains(t, string(body), `simplebank_transferred_amount_cents_total{currency="EUR"} 1000`)
+	require.Contains(t, string(body), `go_sql_max_open_connections{db_name="simplebank"} 0`)
+	require.Contains(t, string(body), "go_goroutines")
+}
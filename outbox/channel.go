@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+var ErrChannelFull = errors.New("outbox channel is full")
+
+// ChannelPublisher publishes the events on a channel, for consumers within the same process
+type ChannelPublisher struct {
+	events chan db.OutboxEvent
+}
+
+// NewChannelPublisher creates a publisher buffering up to size events
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{events: make(chan db.OutboxEvent, size)}
+}
+
+// Events returns the channel the events are published on
+func (publisher *ChannelPublisher) Events() <-chan db.OutboxEvent {
+	return publisher.events
+}
+
+// Publish fails with ErrChannelFull rather than waiting for the consumer,
+// as the events being relayed are locked meanwhile
+func (publisher *ChannelPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	select {
+	case publisher.events <- event:
+		return nil
+	default:
+		return ErrChannelFull
+	}
+}
@@ -0,0 +1,88 @@
+// Package outbox relays the domain events the store records in its outbox, within the transactions
+// that change the data they are about, to the systems downstream.
+// Events are delivered at least once, and those of the same aggregate in the order they were recorded,
+// so consumers must tell duplicates apart by the event id.
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+const (
+	// batchSize is the most events claimed, and published, at once
+	batchSize = 100
+	// batchLease is how long the events claimed are kept from other relays, publishing stops halfway through it
+	batchLease = 2 * time.Minute
+)
+
+// Publisher delivers events downstream
+type Publisher interface {
+	// Publish delivers event, it is published again later if Publish fails
+	Publish(ctx context.Context, event db.OutboxEvent) error
+}
+
+// Relay publishes the events of the store outbox
+type Relay struct {
+	store db.Store
+	publisher Publisher
+	// interval is how long the relay waits before looking for new events
+	interval time.Duration
+}
+
+func NewRelay(store db.Store, publisher Publisher, interval time.Duration) *Relay {
+	return &Relay{store: store, publisher: publisher, interval: interval}
+}
+
+// RelayPending publishes the events pending in the outbox, batch after batch,
+// until none is left or the publisher fails on some
+func (relay *Relay) RelayPending(ctx context.Context) error {
+	for {
+		result, err := relay.store.RelayOutboxTx(ctx, db.RelayOutboxTxParams{
+			Limit: batchSize,
+			Lease: batchLease,
+			Publish: relay.publish,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the failed events would be the first of the next batch, they are retried on the next run
+		if result.Failed > 0 || result.Published < batchSize {
+			return nil
+		}
+	}
+}
+
+func (relay *Relay) publish(ctx context.Context, event db.OutboxEvent) error {
+	err := relay.publisher.Publish(ctx, event)
+	if err != nil {
+		slog.WarnContext(ctx, "outbox event not published",
+			"event_id", event.ID,
+			"event_type", event.EventType,
+			"error", err,
+		)
+	}
+	return err
+}
+
+// Run relays the pending events every interval until the context is done
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := relay.RelayPending(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "outbox relay failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPublisher fails on the events of the aggregates it is given, and publishes the others on a channel
+type failingPublisher struct {
+	*ChannelPublisher
+	failing map[string]bool
+}
+
+func (publisher *failingPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	if publisher.failing[event.AggregateID] {
+		return errors.New("unavailable")
+	}
+	return publisher.ChannelPublisher.Publish(ctx, event)
+}
+
+func createUser(t *testing.T, store db.Store) db.User {
+	user, err := store.CreateUserTx(context.Background(), db.CreateUserParams{
+		Username: util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName: util.RandomOwner(),
+		Email: util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func receive(t *testing.T, publisher *ChannelPublisher, n int) []db.OutboxEvent {
+	events := []db.OutboxEvent{}
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-publisher.Events():
+			events = append(events, event)
+		case <-time.After(time.Second):
+			require.FailNow(t, "event not published")
+		}
+	}
+	return events
+}
+
+func TestRelayPending(t *testing.T) {
+	store := db.NewMemoryStore()
+	publisher := NewChannelPublisher(2 * batchSize)
+
+	users := []db.User{}
+	for i := 0; i < batchSize + 1; i++ {
+		users = append(users, createUser(t, store))
+	}
+
+	relay := NewRelay(store, publisher, time.Minute)
+	require.NoError(t, relay.RelayPending(context.Background()))
+
+	events := receive(t, publisher, len(users))
+	for i, event := range events {
+		require.Equal(t, db.AggregateUser, event.AggregateType)
+		require.Equal(t, users[i].Username, event.AggregateID)
+		require.Equal(t, db.EventUserCreated, event.EventType)
+	}
+
+	// published events are not published again
+	require.NoError(t, relay.RelayPending(context.Background()))
+	require.Empty(t, publisher.Events())
+}
+
+func TestRelayPendingFailure(t *testing.T) {
+	store := db.NewMemoryStore()
+	user := createUser(t, store)
+	account, err := store.CreateAccountTx(context.Background(), db.CreateAccountTxParams{
+		Owner: user.Username,
+		Currency: util.EUR,
+		AccountType: db.AccountTypeChecking,
+		IBANCountryCode: "ES",
+		IBANBankCode: "9999",
+	})
+	require.NoError(t, err)
+
+	publisher := &failingPublisher{ChannelPublisher: NewChannelPublisher(10), failing: map[string]bool{user.Username: true}}
+	relay := NewRelay(store, publisher, time.Minute)
+
+	require.NoError(t, relay.RelayPending(context.Background()))
+	events := receive(t, publisher.ChannelPublisher, 1)
+	require.Equal(t, strconv.FormatInt(account.ID, 10), events[0].AggregateID)
+
+	// the failed event is published once the publisher recovers
+	delete(publisher.failing, user.Username)
+	require.NoError(t, relay.RelayPending(context.Background()))
+	events = receive(t, publisher.ChannelPublisher, 1)
+	require.Equal(t, user.Username, events[0].AggregateID)
+}
+
+func TestRelayRun(t *testing.T) {
+	store := db.NewMemoryStore()
+	publisher := NewChannelPublisher(10)
+	relay := NewRelay(store, publisher, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	user := createUser(t, store)
+	events := receive(t, publisher, 1)
+	require.Equal(t, user.Username, events[0].AggregateID)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "relay did not stop")
+	}
+}
+
+func TestChannelPublisherFull(t *testing.T) {
+	publisher := NewChannelPublisher(1)
+	require.NoError(t, publisher.Publish(context.Background(), db.OutboxEvent{ID: 1}))
+	require.ErrorIs(t, publisher.Publish(context.Background(), db.OutboxEvent{ID: 2}), ErrChannelFull)
+
+	event := <-publisher.Events()
+	require.Equal(t, int64(1), event.ID)
+}
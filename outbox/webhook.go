@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+// webhookEvent is the body of the webhook requests
+type webhookEvent struct {
+	ID int64 `json:"id"`
+	AggregateType string `json:"aggregate_type"`
+	AggregateID string `json:"aggregate_id"`
+	EventType string `json:"event_type"`
+	Payload json.RawMessage `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookPublisher posts every event as JSON to a URL. The event id is sent in the Event-ID header too,
+// so the receiver can discard the events it got already.
+type WebhookPublisher struct {
+	url string
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a publisher posting to url, giving up on requests taking longer than timeout
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Publish succeeds once the receiver answers with a 2xx status
+func (publisher *WebhookPublisher) Publish(ctx context.Context, event db.OutboxEvent) error {
+	body, err := json.Marshal(webhookEvent{
+		ID: event.ID,
+		AggregateType: event.AggregateType,
+		AggregateID: event.AggregateID,
+		EventType: event.EventType,
+		Payload: event.Payload,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, publisher.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("Event-Type", event.EventType)
+
+	res, err := publisher.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// drain the body so the connection is reused
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return nil
+}
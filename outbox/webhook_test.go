@@ -0,0 +1,84 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	event := db.OutboxEvent{
+		ID: 42,
+		AggregateType: db.AggregateAccount,
+		AggregateID: "7",
+		EventType: db.EventAccountCreated,
+		Payload: json.RawMessage(`{"id":7}`),
+		CreatedAt: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name string
+		status int
+		checkResult func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			status: http.StatusNoContent,
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "ErrorStatus",
+			status: http.StatusServiceUnavailable,
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "503")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			defer receiver.Close()
+
+			err := NewWebhookPublisher(receiver.URL, time.Second).Publish(context.Background(), event)
+			tc.checkResult(t, err)
+
+			require.Equal(t, http.MethodPost, received.Method)
+			require.Equal(t, "application/json", received.Header.Get("Content-Type"))
+			require.Equal(t, "42", received.Header.Get("Event-ID"))
+			require.Equal(t, db.EventAccountCreated, received.Header.Get("Event-Type"))
+			require.JSONEq(t, `{
+				"id": 42,
+				"aggregate_type": "account",
+				"aggregate_id": "7",
+				"event_type": "account.created",
+				"payload": {"id": 7},
+				"created_at": "2024-03-01T10:00:00Z"
+			}`, string(body))
+		})
+	}
+}
+
+func TestWebhookPublisherUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	receiver.Close()
+
+	err := NewWebhookPublisher(receiver.URL, time.Second).Publish(context.Background(), db.OutboxEvent{ID: 1, Payload: json.RawMessage(`{}`)})
+	require.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often the memory store forgets the buckets that refilled
+const pruneInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	updatedAt time.Time
+	// fullAt is when the bucket refills, from then on it is the same as a missing bucket
+	fullAt time.Time
+}
+
+// MemoryStore keeps the buckets in process, so every replica applies the limits on its own
+type MemoryStore struct {
+	mu sync.Mutex
+	buckets map[string]*bucket
+	lastPrune time.Time
+	now func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (store *MemoryStore) Take(ctx context.Context, key string, rate float64, burst float64) (bool, time.Duration, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	store.prune(now)
+
+	b, ok := store.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updatedAt: now}
+		store.buckets[key] = b
+	}
+
+	elapsed := max(0, now.Sub(b.updatedAt).Seconds())
+	b.tokens = min(burst, b.tokens+elapsed*rate)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return false, waitFor(b.tokens, rate), nil
+	}
+
+	b.tokens--
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+	return true, 0, nil
+}
+
+// prune drops the buckets that refilled, at most once every pruneInterval
+func (store *MemoryStore) prune(now time.Time) {
+	if now.Sub(store.lastPrune) < pruneInterval {
+		return
+	}
+	store.lastPrune = now
+
+	for key, b := range store.buckets {
+		if !b.fullAt.After(now) {
+			delete(store.buckets, key)
+		}
+	}
+}
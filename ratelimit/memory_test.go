@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMemoryStore returns a memory store whose clock is moved by advancing the returned time
+func newTestMemoryStore() (*MemoryStore, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+
+	// 1 token per second, bursts of 3
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Take(ctx, "key", 1, 3)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := store.Take(ctx, "key", 1, 3)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, time.Second, retryAfter)
+
+	// other keys have their own bucket
+	allowed, _, err = store.Take(ctx, "other", 1, 3)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	*now = now.Add(500 * time.Millisecond)
+	allowed, retryAfter, err = store.Take(ctx, "key", 1, 3)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	*now = now.Add(500 * time.Millisecond)
+	allowed, _, err = store.Take(ctx, "key", 1, 3)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// buckets do not refill beyond the burst
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		allowed, _, err = store.Take(ctx, "key", 1, 3)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+	allowed, _, err = store.Take(ctx, "key", 1, 3)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+
+	_, _, err := store.Take(ctx, "slow", 0.01, 1)
+	require.NoError(t, err)
+	_, _, err = store.Take(ctx, "fast", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 2)
+
+	// fast refilled, slow needs 100 seconds
+	*now = now.Add(pruneInterval)
+	_, _, err = store.Take(ctx, "other", 1, 1)
+	require.NoError(t, err)
+	require.Contains(t, store.buckets, "slow")
+	require.NotContains(t, store.buckets, "fast")
+
+	*now = now.Add(pruneInterval)
+	_, _, err = store.Take(ctx, "other", 1, 1)
+	require.NoError(t, err)
+	require.NotContains(t, store.buckets, "slow")
+
+	// a pruned bucket is the same as a full one
+	allowed, _, err := store.Take(ctx, "slow", 0.01, 1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
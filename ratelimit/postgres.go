@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/logger"
+)
+
+// Buckets idle for longer than bucketTTL are deleted, every pruneInterval. Limits must refill within
+// bucketTTL, or deleting their buckets would refill them early.
+const bucketTTL = 24 * time.Hour
+
+// PostgresStore keeps the buckets in the database, so the limits are shared by every replica.
+// Buckets are refilled using the database clock, the clocks of the replicas do not matter.
+type PostgresStore struct {
+	store db.Store
+	mu sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgresStore(store db.Store) *PostgresStore {
+	return &PostgresStore{store: store}
+}
+
+func (store *PostgresStore) Take(ctx context.Context, key string, rate float64, burst float64) (bool, time.Duration, error) {
+	store.prune(ctx)
+
+	_, err := store.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key: key,
+		Burst: burst,
+		Rate: rate,
+	})
+	if err == nil {
+		return true, 0, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, 0, err
+	}
+
+	// the bucket is empty
+	tokens, err := store.store.GetRateLimitTokens(ctx, db.GetRateLimitTokensParams{
+		Burst: burst,
+		Rate: rate,
+		Key: key,
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return false, waitFor(tokens, rate), nil
+}
+
+// prune deletes the idle buckets, at most once every pruneInterval per replica
+func (store *PostgresStore) prune(ctx context.Context) {
+	store.mu.Lock()
+	if time.Since(store.lastPrune) < pruneInterval {
+		store.mu.Unlock()
+		return
+	}
+	store.lastPrune = time.Now()
+	store.mu.Unlock()
+
+	if _, err := store.store.DeleteIdleRateLimitBuckets(ctx, bucketTTL.Seconds()); err != nil {
+		logger.FromContext(ctx).Error("cannot delete idle rate limit buckets", "error", err)
+	}
+}
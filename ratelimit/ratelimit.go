@@ -0,0 +1,117 @@
+// Package ratelimit limits how often clients can call a route with token buckets: every client has a bucket
+// per route, refilled at the rate of the route limit up to its burst, and each call takes a token from it.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+)
+
+const (
+	StoreMemory = "memory"
+	StorePostgres = "postgres"
+)
+
+// Store keeps the token buckets
+type Store interface {
+	// Take takes a token from the bucket of key, refilled at rate tokens per second up to burst.
+	// If the bucket is empty it returns false and how long until a token is available.
+	Take(ctx context.Context, key string, rate float64, burst float64) (bool, time.Duration, error)
+}
+
+// NewStore returns the store named by the configuration, memory if none is set
+func NewStore(config util.Config, store db.Store) (Store, error) {
+	switch config.RateLimitStore {
+	case "", StoreMemory:
+		return NewMemoryStore(), nil
+	case StorePostgres:
+		return NewPostgresStore(store), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit store %q", config.RateLimitStore)
+}
+
+type limit struct {
+	rate float64
+	burst float64
+}
+
+// Limiter applies the limits of the routes to their clients
+type Limiter struct {
+	store Store
+	limits map[string]limit
+}
+
+// NewLimiter limits the calls to the routes of limits, other routes are not limited.
+// Routes are matched ignoring case, as configuration keys are lower cased.
+func NewLimiter(store Store, limits map[string]util.RateLimit) (*Limiter, error) {
+	limiter := &Limiter{store: store, limits: make(map[string]limit, len(limits))}
+	for route, rateLimit := range limits {
+		if rateLimit.Requests <= 0 || rateLimit.Period <= 0 {
+			return nil, fmt.Errorf("invalid rate limit for %s: requests and period must be positive", route)
+		}
+
+		burst := rateLimit.Burst
+		if burst == 0 {
+			burst = rateLimit.Requests
+		}
+		if burst < 0 {
+			return nil, fmt.Errorf("invalid rate limit for %s: burst cannot be negative", route)
+		}
+
+		limit := limit{
+			rate: float64(rateLimit.Requests) / rateLimit.Period.Seconds(),
+			burst: float64(burst),
+		}
+		if limit.burst/limit.rate > bucketTTL.Seconds() {
+			return nil, fmt.Errorf("invalid rate limit for %s: buckets must refill within %s", route, bucketTTL)
+		}
+		limiter.limits[strings.ToLower(route)] = limit
+	}
+	return limiter, nil
+}
+
+// Allow takes a token from the bucket of the client for the route, the client being a client IP or
+// a username. It returns false and how long to wait before retrying if the bucket ran out of tokens.
+// A nil limiter allows everything.
+func (limiter *Limiter) Allow(ctx context.Context, route string, client string) (bool, time.Duration, error) {
+	if limiter == nil {
+		return true, 0, nil
+	}
+
+	route = strings.ToLower(route)
+	limit, ok := limiter.limits[route]
+	if !ok {
+		return true, 0, nil
+	}
+
+	allowed, retryAfter, err := limiter.store.Take(ctx, route+" "+client, limit.rate, limit.burst)
+	if err != nil {
+		return false, 0, fmt.Errorf("cannot take rate limit token: %w", err)
+	}
+	return allowed, retryAfter, nil
+}
+
+// IPClient and UserClient name the clients passed to Allow, so a username cannot take the bucket of an IP
+func IPClient(ip string) string {
+	return "ip:" + ip
+}
+
+func UserClient(username string) string {
+	return "user:" + username
+}
+
+// RetryAfterSeconds rounds retryAfter up to whole seconds, as sent in Retry-After headers
+func RetryAfterSeconds(retryAfter time.Duration) int64 {
+	return max(1, int64(math.Ceil(retryAfter.Seconds())))
+}
+
+// waitFor returns how long a bucket with tokens takes to refill a whole token at rate tokens per second
+func waitFor(tokens float64, rate float64) time.Duration {
+	return time.Duration((1 - tokens) / rate * float64(time.Second))
+}
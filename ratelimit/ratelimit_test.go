@@ -0,0 +1,215 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/gorkaio/simplebank/db/mock"
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimiter(t *testing.T) {
+	testCases := []struct {
+		name string
+		limit util.RateLimit
+		checkResult func(t *testing.T, limiter *Limiter, err error)
+	}{
+		{
+			name: "DefaultBurst",
+			limit: util.RateLimit{Requests: 30, Period: time.Minute},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, limit{rate: 0.5, burst: 30}, limiter.limits["post /transfers"])
+			},
+		},
+		{
+			name: "Burst",
+			limit: util.RateLimit{Requests: 30, Period: time.Minute, Burst: 5},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, limit{rate: 0.5, burst: 5}, limiter.limits["post /transfers"])
+			},
+		},
+		{
+			name: "NoRequests",
+			limit: util.RateLimit{Period: time.Minute},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.ErrorContains(t, err, "must be positive")
+			},
+		},
+		{
+			name: "NoPeriod",
+			limit: util.RateLimit{Requests: 30},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.ErrorContains(t, err, "must be positive")
+			},
+		},
+		{
+			name: "NegativeBurst",
+			limit: util.RateLimit{Requests: 30, Period: time.Minute, Burst: -1},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.ErrorContains(t, err, "burst cannot be negative")
+			},
+		},
+		{
+			name: "SlowRefill",
+			limit: util.RateLimit{Requests: 1, Period: 48 * time.Hour},
+			checkResult: func(t *testing.T, limiter *Limiter, err error) {
+				require.ErrorContains(t, err, "must refill within")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			limiter, err := NewLimiter(NewMemoryStore(), map[string]util.RateLimit{"POST /transfers": tc.limit})
+			tc.checkResult(t, limiter, err)
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	store, now := newTestMemoryStore()
+	limiter, err := NewLimiter(store, map[string]util.RateLimit{
+		"post /users": {Requests: 1, Period: time.Minute},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// routes are matched ignoring case
+	allowed, _, err := limiter.Allow(ctx, "POST /users", IPClient("10.0.0.1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, retryAfter, err := limiter.Allow(ctx, "POST /users", IPClient("10.0.0.1"))
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, time.Minute, retryAfter)
+
+	*now = now.Add(20 * time.Second)
+	allowed, retryAfter, err = limiter.Allow(ctx, "POST /users", IPClient("10.0.0.1"))
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 40*time.Second, retryAfter)
+
+	// every client has its own bucket
+	allowed, _, err = limiter.Allow(ctx, "POST /users", IPClient("10.0.0.2"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, _, err = limiter.Allow(ctx, "POST /users", UserClient("10.0.0.1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// other routes are not limited
+	for i := 0; i < 3; i++ {
+		allowed, _, err = limiter.Allow(ctx, "GET /accounts/:id", IPClient("10.0.0.1"))
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	// a nil limiter allows everything
+	var nilLimiter *Limiter
+	allowed, _, err = nilLimiter.Allow(ctx, "POST /users", IPClient("10.0.0.1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	require.Equal(t, int64(1), RetryAfterSeconds(0))
+	require.Equal(t, int64(1), RetryAfterSeconds(10*time.Millisecond))
+	require.Equal(t, int64(2), RetryAfterSeconds(1100*time.Millisecond))
+	require.Equal(t, int64(60), RetryAfterSeconds(time.Minute))
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore(util.Config{}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore(util.Config{RateLimitStore: StorePostgres}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &PostgresStore{}, store)
+
+	_, err = NewStore(util.Config{RateLimitStore: "redis"}, nil)
+	require.ErrorContains(t, err, "unknown rate limit store")
+}
+
+func TestPostgresStoreTake(t *testing.T) {
+	errStore := errors.New("connection refused")
+
+	testCases := []struct {
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, allowed bool, retryAfter time.Duration, err error)
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					TakeRateLimitToken(gomock.Any(), gomock.Eq(db.TakeRateLimitTokenParams{Key: "key", Burst: 3, Rate: 0.5})).
+					Times(1).
+					Return(db.RateLimitBucket{Key: "key", Tokens: 2}, nil)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, allowed bool, retryAfter time.Duration, err error) {
+				require.NoError(t, err)
+				require.True(t, allowed)
+			},
+		},
+		{
+			name: "Empty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					TakeRateLimitToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateLimitBucket{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetRateLimitTokens(gomock.Any(), gomock.Eq(db.GetRateLimitTokensParams{Burst: 3, Rate: 0.5, Key: "key"})).
+					Times(1).
+					Return(0.5, nil)
+			},
+			checkResult: func(t *testing.T, allowed bool, retryAfter time.Duration, err error) {
+				require.NoError(t, err)
+				require.False(t, allowed)
+				require.Equal(t, time.Second, retryAfter)
+			},
+		},
+		{
+			name: "StoreError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					TakeRateLimitToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateLimitBucket{}, errStore)
+			},
+			checkResult: func(t *testing.T, allowed bool, retryAfter time.Duration, err error) {
+				require.ErrorIs(t, err, errStore)
+				require.False(t, allowed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			// the first call prunes the idle buckets
+			store.EXPECT().DeleteIdleRateLimitBuckets(gomock.Any(), bucketTTL.Seconds()).Times(1).Return(int64(0), nil)
+			tc.buildStubs(store)
+
+			allowed, retryAfter, err := NewPostgresStore(store).Take(context.Background(), "key", 0.5, 3)
+			tc.checkResult(t, allowed, retryAfter, err)
+		})
+	}
+}
@@ -22,8 +22,8 @@ func NewJwtMaker(secretKey string) (Maker, error) {
 	return &JwtMaker{secretKey: secretKey}, nil
 }
 
-func (maker *JwtMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *JwtMaker) CreateToken(username string, tokenType TokenType, duration time.Duration) (string, error) {
+	payload, err := NewPayload(username, tokenType, duration)
 	if err != nil {
 		return "", err
 	}
@@ -32,7 +32,7 @@ func (maker *JwtMaker) CreateToken(username string, duration time.Duration) (str
 	return jwtToken.SignedString([]byte(maker.secretKey))
 }
 
-func (maker *JwtMaker) VerifyToken(token string) (*Payload, error) {
+func (maker *JwtMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
@@ -51,7 +51,7 @@ func (maker *JwtMaker) VerifyToken(token string) (*Payload, error) {
 	}
 
 	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
+	if !ok || payload.Type != tokenType {
 		return nil, ErrInvalidToken
 	}
 
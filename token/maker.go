@@ -3,6 +3,7 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
-	VerifyToken(token string) (*Payload, error)
+	CreateToken(username string, tokenType TokenType, duration time.Duration) (string, error)
+	// VerifyToken fails with ErrInvalidToken for tokens of another type
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
 }
\ No newline at end of file
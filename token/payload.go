@@ -12,14 +12,26 @@ var (
 	ErrInvalidToken = errors.New("token is invalid")
 )
 
+// TokenType tells what a token can be used for, so tokens of one type are never accepted as another
+type TokenType string
+
+const (
+	// TokenTypeAccess authorizes the calls of a user
+	TokenTypeAccess TokenType = "access"
+	// TokenTypePreAuth proves the password of a user who must still give their second factor
+	// to get an access token
+	TokenTypePreAuth TokenType = "pre_auth"
+)
+
 type Payload struct {
 	ID uuid.UUID `json:"id"`
+	Type TokenType `json:"type"`
 	Username string `json:"username"`
 	IssuedAt time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, tokenType TokenType, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -27,6 +39,7 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 
 	payload := &Payload{
 		ID: tokenID,
+		Type: tokenType,
 		Username: username,
 		IssuedAt: time.Now(),
 		ExpiredAt: time.Now().Add(duration),
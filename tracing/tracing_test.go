@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupNoop(t *testing.T) {
+	defaultProvider := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), util.Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	require.Equal(t, defaultProvider, otel.GetTracerProvider())
+}
+
+func TestSetupStdout(t *testing.T) {
+	defaultProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(defaultProvider)
+
+	shutdown, err := Setup(context.Background(), util.Config{TracingStdout: true})
+	require.NoError(t, err)
+	require.NotEqual(t, defaultProvider, otel.GetTracerProvider())
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestNewProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := NewProvider(recorder)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "operation")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "operation", spans[0].Name())
+	require.Contains(t, spans[0].Resource().Attributes(), attribute.String("service.name", serviceName))
+}
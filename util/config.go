@@ -1,11 +1,81 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RateLimit allows Requests every Period, in bursts of up to Burst requests.
+// Burst defaults to Requests.
+type RateLimit struct {
+	Requests int `mapstructure:"requests"`
+	Period time.Duration `mapstructure:"period"`
+	Burst int `mapstructure:"burst"`
+}
 
 type Config struct {
 	DBDriver string `mapstructure:"DB_DRIVER"`
 	DBSource string `mapstructure:"DB_SOURCE"`
+	// MigrateOnStart applies pending migrations when the server starts
+	MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	// LogLevel is one of debug, info, warn or error and LogFormat is either json or text
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+	// Traces are exported to OTLPEndpoint (host:port of an OTLP/gRPC collector) if set,
+	// or written to stdout if TracingStdout is true
+	OTLPEndpoint string `mapstructure:"OTLP_ENDPOINT"`
+	TracingStdout bool `mapstructure:"TRACING_STDOUT"`
+	// TrustedProxies are the addresses of the proxies allowed to set the client IP through X-Forwarded-For.
+	// Without them the client IP is the address of the connection.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+	// ShutdownTimeout is how long in-flight requests are given to finish once a shutdown is signaled
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// RateLimits maps HTTP routes ("POST /users") and gRPC methods ("UserService/LoginUser", without the package
+	// as viper splits keys on dots) to the limit applied to every authenticated user, or client IP if anonymous,
+	// calling them. Buckets are kept in RateLimitStore, memory or postgres to share them across replicas.
+	RateLimits map[string]RateLimit `mapstructure:"RATE_LIMITS"`
+	RateLimitStore string `mapstructure:"RATE_LIMIT_STORE"`
+	// OutboxWebhookURL receives the domain events of the outbox, which are not relayed if it is empty.
+	// The outbox is checked for new events every OutboxRelayInterval.
+	OutboxWebhookURL string `mapstructure:"OUTBOX_WEBHOOK_URL"`
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	// The deliveries to the webhooks registered for the accounts are looked for every WebhookDeliveryInterval
+	WebhookDeliveryInterval time.Duration `mapstructure:"WEBHOOK_DELIVERY_INTERVAL"`
+	// Mailer sends the emails to the users: smtp, through SMTPAddress, or file, writing them to MailDir.
+	// No email is sent if it is empty.
+	Mailer string `mapstructure:"MAILER"`
+	MailFrom string `mapstructure:"MAIL_FROM"`
+	SMTPAddress string `mapstructure:"SMTP_ADDRESS"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	MailDir string `mapstructure:"MAIL_DIR"`
+	// VerifyEmailURL is the page the verification emails link to, which calls GET /verify_email with its
+	// query parameters. The emails queued for new users are looked for every VerifyEmailInterval.
+	VerifyEmailURL string `mapstructure:"VERIFY_EMAIL_URL"`
+	VerifyEmailInterval time.Duration `mapstructure:"VERIFY_EMAIL_INTERVAL"`
+	// RequireVerifiedEmail keeps users from making transfers until their email is verified
+	RequireVerifiedEmail bool `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	// PasswordResetURL is the page the password reset emails link to, with the token to send to POST /reset_password
+	PasswordResetURL string `mapstructure:"PASSWORD_RESET_URL"`
+	TokenSymmetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// Users with two-factor authentication log in with a pre-auth token lasting PreAuthTokenDuration, exchanged
+	// for an access token along with a TOTP code. TOTPIssuer names the bank in their authenticator apps.
+	PreAuthTokenDuration time.Duration `mapstructure:"PRE_AUTH_TOKEN_DURATION"`
+	TOTPIssuer string `mapstructure:"TOTP_ISSUER"`
+	// Transfers of at least this amount, in cents, require a TOTP code from users with two-factor authentication
+	// and are refused to the others. Zero disables the step-up authentication.
+	StepUpTransferAmount int64 `mapstructure:"STEP_UP_TRANSFER_AMOUNT"`
+	InterestExpenseOwner string `mapstructure:"INTEREST_EXPENSE_OWNER"`
+	IBANCountryCode string `mapstructure:"IBAN_COUNTRY_CODE"`
+	IBANBankCode string `mapstructure:"IBAN_BANK_CODE"`
+	// Transfers to a beneficiary of at least this amount, in cents, require it to be verified for
+	// BeneficiaryCoolingOff. Zero disables the check.
+	BeneficiaryLargeTransferAmount int64 `mapstructure:"BENEFICIARY_LARGE_TRANSFER_AMOUNT"`
+	BeneficiaryCoolingOff time.Duration `mapstructure:"BENEFICIARY_COOLING_OFF"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
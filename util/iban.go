@@ -0,0 +1,98 @@
+package util
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Number of random digits identifying an account within the bank
+const ibanAccountDigits = 16
+
+var (
+	ErrInvalidIBANFormat = errors.New("invalid IBAN format")
+	ErrInvalidIBANChecksum = errors.New("invalid IBAN check digits")
+)
+
+// GenerateIBAN builds a new IBAN for the given country and bank code with a random,
+// non sequential account number, so account numbers do not reveal the number of accounts.
+func GenerateIBAN(countryCode string, bankCode string) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(ibanAccountDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	countryCode = strings.ToUpper(countryCode)
+	bban := strings.ToUpper(bankCode) + fmt.Sprintf("%0*s", ibanAccountDigits, n.String())
+
+	checkDigits, err := ibanCheckDigits(countryCode, bban)
+	if err != nil {
+		return "", err
+	}
+
+	return countryCode + checkDigits + bban, nil
+}
+
+// NormalizeIBAN removes spaces from an IBAN in its printed format and uppercases it
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+// ValidateIBAN checks the format and the mod-97 check digits (ISO 7064) of a normalized IBAN
+func ValidateIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 {
+		return ErrInvalidIBANFormat
+	}
+
+	for i, c := range iban {
+		switch {
+		case i < 2 && (c < 'A' || c > 'Z'):
+			return ErrInvalidIBANFormat
+		case i >= 2 && i < 4 && (c < '0' || c > '9'):
+			return ErrInvalidIBANFormat
+		case (c < '0' || c > '9') && (c < 'A' || c > 'Z'):
+			return ErrInvalidIBANFormat
+		}
+	}
+
+	remainder, err := mod97(iban[4:] + iban[:4])
+	if err != nil {
+		return err
+	}
+	if remainder != 1 {
+		return ErrInvalidIBANChecksum
+	}
+	return nil
+}
+
+func ibanCheckDigits(countryCode string, bban string) (string, error) {
+	if len(countryCode) != 2 {
+		return "", ErrInvalidIBANFormat
+	}
+
+	remainder, err := mod97(bban + countryCode + "00")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", 98-remainder), nil
+}
+
+// mod97 computes the remainder of the number obtained by replacing every letter by two digits (A=10 ... Z=35),
+// piecewise so it does not overflow
+func mod97(s string) (int, error) {
+	remainder := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return 0, ErrInvalidIBANFormat
+		}
+	}
+	return remainder, nil
+}
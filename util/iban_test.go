@@ -0,0 +1,43 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	valid := []string{
+		"GB82WEST12345698765432",
+		"DE89370400440532013000",
+		"ES9121000418450200051332",
+	}
+	for _, iban := range valid {
+		require.NoError(t, ValidateIBAN(iban), iban)
+	}
+
+	require.ErrorIs(t, ValidateIBAN("GB83WEST12345698765432"), ErrInvalidIBANChecksum)
+	require.ErrorIs(t, ValidateIBAN("GB82WEST1234569876543!"), ErrInvalidIBANFormat)
+	require.ErrorIs(t, ValidateIBAN("82GBWEST12345698765432"), ErrInvalidIBANFormat)
+	require.ErrorIs(t, ValidateIBAN("GB82WEST"), ErrInvalidIBANFormat)
+}
+
+func TestNormalizeIBAN(t *testing.T) {
+	require.Equal(t, "GB82WEST12345698765432", NormalizeIBAN("gb82 west 1234 5698 7654 32"))
+}
+
+func TestGenerateIBAN(t *testing.T) {
+	iban1, err := GenerateIBAN("es", "9999")
+	require.NoError(t, err)
+	require.Len(t, iban1, 24)
+	require.Equal(t, "ES", iban1[:2])
+	require.Equal(t, "9999", iban1[4:8])
+	require.NoError(t, ValidateIBAN(iban1))
+
+	iban2, err := GenerateIBAN("ES", "9999")
+	require.NoError(t, err)
+	require.NotEqual(t, iban1, iban2)
+
+	_, err = GenerateIBAN("ESP", "9999")
+	require.ErrorIs(t, err, ErrInvalidIBANFormat)
+}
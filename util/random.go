@@ -44,4 +44,12 @@ func RandomCurrency() string {
 
 func RandomEmail() string {
 	return fmt.Sprintf("%s@email.com", RandomString(8))
-}
\ No newline at end of file
+}
+
+func RandomAccountNumber() string {
+	iban, err := GenerateIBAN("ES", "9999")
+	if err != nil {
+		panic(err)
+	}
+	return iban
+}
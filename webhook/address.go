@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress is the error of webhook URLs reaching the network the server runs in,
+// which would let the owners of the webhooks make the deliverer probe internal services
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// forbiddenAddress reports whether addr is a loopback, private, link-local, multicast or unspecified address,
+// such as 127.0.0.1, 10.0.0.1 or the 169.254.169.254 of the cloud metadata services
+func forbiddenAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified()
+}
+
+// CheckURL checks the host of a webhook URL is not a forbidden address, nor a name resolving to one.
+// Names which cannot be resolved are let through, they are checked again when the deliveries are posted.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if forbiddenAddress(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if forbiddenAddress(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr)
+		}
+	}
+	return nil
+}
+
+// dialControl refuses the connections to forbidden addresses, once the host of the webhook is resolved,
+// so names resolving to one after the webhook was registered, and redirects to one, are not reached either
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if forbiddenAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
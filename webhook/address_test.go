@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckURL(t *testing.T) {
+	testCases := []struct {
+		url string
+		forbidden bool
+	}{
+		{"https://93.184.215.14/hook", false},
+		{"https://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://localhost/hook", true},
+		{"http://10.0.0.1/hook", true},
+		{"http://172.16.0.1/hook", true},
+		{"http://192.168.1.1/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[fe80::1]/hook", true},
+		{"http://[fd00::1]/hook", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+		{"http://0.0.0.0/hook", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			err := CheckURL(context.Background(), tc.url)
+			if tc.forbidden {
+				require.ErrorIs(t, err, ErrForbiddenAddress)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package webhook delivers the events of the accounts to the webhooks their owners registered.
+// The store queues a delivery for every subscribed webhook within the transaction recording the event,
+// and the Deliverer posts them as signed JSON, retrying the failed ones with an exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+)
+
+const (
+	// batchSize is the most deliveries claimed, and posted concurrently, at once
+	batchSize = 10
+	// DefaultMaxAttempts is how many times a delivery is attempted before it is failed
+	DefaultMaxAttempts = 8
+	// DefaultBackoff is the wait after the first failed attempt, doubling on every attempt after it
+	DefaultBackoff = 30 * time.Second
+	// maxBackoff bounds the wait between two attempts
+	maxBackoff = 6 * time.Hour
+)
+
+// Event is the body of the deliveries. ID is the id of the event, the same for every attempt,
+// so receivers can discard the events they got already.
+type Event struct {
+	ID int64 `json:"id"`
+	Type string `json:"type"`
+	AccountID int64 `json:"account_id"`
+	Data json.RawMessage `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Deliverer posts the pending webhook deliveries of the store
+type Deliverer struct {
+	store db.Store
+	client *http.Client
+	// interval is how long the deliverer waits before looking for due deliveries
+	interval time.Duration
+	// lease is how long a claimed delivery is kept from other deliverers, longer than a request may take
+	lease time.Duration
+	maxAttempts int32
+	backoff time.Duration
+}
+
+// NewDeliverer creates a deliverer giving up on requests taking longer than timeout
+func NewDeliverer(store db.Store, timeout time.Duration, interval time.Duration) *Deliverer {
+	return &Deliverer{
+		store: store,
+		client: newClient(timeout),
+		interval: interval,
+		lease: 2 * timeout,
+		maxAttempts: DefaultMaxAttempts,
+		backoff: DefaultBackoff,
+	}
+}
+
+// newClient creates an HTTP client refusing to connect to forbidden addresses. It uses no proxy,
+// so the address checked is the one of the webhook itself.
+func newClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: dialControl}).DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// DeliverPending posts the deliveries due now, batch after batch, until none is left
+func (deliverer *Deliverer) DeliverPending(ctx context.Context) error {
+	for {
+		deliveries, err := deliverer.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+			LeaseSeconds: deliverer.lease.Seconds(),
+			Limit: batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		errs := make([]error, len(deliveries))
+		var wg sync.WaitGroup
+		for i, delivery := range deliveries {
+			wg.Add(1)
+			go func(i int, delivery db.WebhookDelivery) {
+				defer wg.Done()
+				errs[i] = deliverer.deliver(ctx, delivery)
+			}(i, delivery)
+		}
+		wg.Wait()
+
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
+
+// deliver attempts delivery once, and records the attempt. It only fails if the attempt cannot be recorded,
+// the delivery is attempted again once its lease runs out then.
+func (deliverer *Deliverer) deliver(ctx context.Context, delivery db.WebhookDelivery) error {
+	webhook, err := deliverer.store.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// deleted meanwhile, with its deliveries
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := deliverer.post(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// shutting down, the attempt was not the receiver's fault
+		return nil
+	}
+
+	now := time.Now()
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		ID: delivery.ID,
+		Status: db.WebhookDeliverySucceeded,
+		LastStatusCode: int32(statusCode),
+		NextAttemptAt: delivery.NextAttemptAt,
+		DeliveredAt: now,
+	}
+	if err != nil {
+		arg.LastError = err.Error()
+		if errors.Is(err, ErrForbiddenAddress) {
+			// deliveries are listed to the owner of the webhook, the address stays in the logs below
+			arg.LastError = ErrForbiddenAddress.Error()
+		}
+		arg.DeliveredAt = time.Time{}
+		attempts := delivery.Attempts + 1
+		if attempts >= deliverer.maxAttempts {
+			arg.Status = db.WebhookDeliveryFailed
+		} else {
+			arg.Status = db.WebhookDeliveryPending
+			arg.NextAttemptAt = now.Add(deliverer.backoffAfter(attempts))
+		}
+		slog.WarnContext(ctx, "webhook delivery failed",
+			"delivery_id", delivery.ID,
+			"webhook_id", webhook.ID,
+			"attempts", attempts,
+			"status", arg.Status,
+			"error", err,
+		)
+	}
+
+	_, err = deliverer.store.UpdateWebhookDeliveryAttempt(ctx, arg)
+	return err
+}
+
+// backoffAfter is the wait after the given number of failed attempts
+func (deliverer *Deliverer) backoffAfter(attempts int32) time.Duration {
+	backoff := deliverer.backoff
+	for i := int32(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// post sends delivery to the webhook, it succeeds once the receiver answers with a 2xx status
+func (deliverer *Deliverer) post(ctx context.Context, webhook db.Webhook, delivery db.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(Event{
+		ID: delivery.EventID,
+		Type: delivery.EventType,
+		AccountID: webhook.AccountID,
+		Data: delivery.Payload,
+		CreatedAt: delivery.CreatedAt,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-ID", strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set("Event-Type", delivery.EventType)
+	req.Header.Set("Delivery-ID", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now(), body))
+
+	res, err := deliverer.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// drain the body so the connection is reused
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Run posts the due deliveries every interval until the context is done
+func (deliverer *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(deliverer.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := deliverer.DeliverPending(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "webhook deliverer failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/gorkaio/simplebank/db/sqlc"
+	"github.com/gorkaio/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a webhook endpoint answering with status, which verifies and records the deliveries it gets
+type receiver struct {
+	*httptest.Server
+	status int
+	requests chan *http.Request
+	events chan Event
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	r := &receiver{status: http.StatusNoContent, requests: make(chan *http.Request, 10), events: make(chan Event, 10)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.NoError(t, Verify(secret, req.Header.Get(SignatureHeader), body, time.Minute, time.Now()))
+
+		var event Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		r.requests <- req
+		r.events <- event
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func setUp(t *testing.T, eventTypes ...string) (db.Store, *receiver, db.Account, db.Account) {
+	store := db.NewMemoryStore()
+	secret := util.RandomString(32)
+	receiver := newReceiver(t, secret)
+
+	accounts := []db.Account{}
+	for i := 0; i < 2; i++ {
+		user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username: util.RandomOwner(),
+			HashedPassword: "hashed",
+			FullName: util.RandomOwner(),
+			Email: util.RandomEmail(),
+		})
+		require.NoError(t, err)
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner: user.Username,
+			Balance: 100,
+			Currency: util.EUR,
+			AccountType: db.AccountTypeChecking,
+			AccountNumber: util.RandomAccountNumber(),
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	_, err := store.CreateWebhook(context.Background(), db.CreateWebhookParams{
+		AccountID: accounts[1].ID,
+		Url: receiver.URL,
+		Secret: secret,
+		EventTypes: eventTypes,
+	})
+	require.NoError(t, err)
+	return store, receiver, accounts[0], accounts[1]
+}
+
+func transfer(t *testing.T, store db.Store, from db.Account, to db.Account) db.Transfer {
+	result, err := store.CreateTransferTx(context.Background(), db.CreateTransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID: to.ID,
+		Amount: 10,
+	})
+	require.NoError(t, err)
+	return result.Transfer
+}
+
+func singleDelivery(t *testing.T, store db.Store) db.WebhookDelivery {
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), db.ListWebhookDeliveriesParams{
+		WebhookID: 1,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+// newTestDeliverer creates a deliverer connecting to loopback addresses, which the receivers listen on
+func newTestDeliverer(store db.Store) *Deliverer {
+	deliverer := NewDeliverer(store, time.Second, time.Second)
+	deliverer.client.Transport = http.DefaultTransport
+	return deliverer
+}
+
+func TestDeliverPending(t *testing.T) {
+	store, receiver, account1, account2 := setUp(t, db.WebhookEventTransferReceived)
+	deliverer := newTestDeliverer(store)
+
+	tr := transfer(t, store, account1, account2)
+	// the webhook is not subscribed to the transfers the account sends
+	transfer(t, store, account2, account1)
+
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	require.Len(t, receiver.events, 1)
+
+	req := <-receiver.requests
+	event := <-receiver.events
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.Equal(t, db.WebhookEventTransferReceived, req.Header.Get("Event-Type"))
+	require.Equal(t, strconv.FormatInt(event.ID, 10), req.Header.Get("Event-ID"))
+	require.Equal(t, db.WebhookEventTransferReceived, event.Type)
+	require.Equal(t, account2.ID, event.AccountID)
+
+	var payload db.Transfer
+	require.NoError(t, json.Unmarshal(event.Data, &payload))
+	require.Equal(t, tr, payload)
+
+	delivery := singleDelivery(t, store)
+	require.Equal(t, db.WebhookDeliverySucceeded, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Equal(t, int32(http.StatusNoContent), delivery.LastStatusCode)
+	require.False(t, delivery.DeliveredAt.IsZero())
+
+	// delivered once
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	require.Empty(t, receiver.events)
+}
+
+func TestDeliverPendingRetries(t *testing.T) {
+	store, receiver, account1, account2 := setUp(t, db.WebhookEventTransferReceived)
+	receiver.status = http.StatusInternalServerError
+	deliverer := newTestDeliverer(store)
+	deliverer.maxAttempts = 3
+	// due again right away
+	deliverer.backoff = -time.Hour
+
+	transfer(t, store, account1, account2)
+
+	for attempts := int32(1); attempts <= 2; attempts++ {
+		require.NoError(t, deliverer.DeliverPending(context.Background()))
+		<-receiver.events
+
+		delivery := singleDelivery(t, store)
+		require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+		require.Equal(t, attempts, delivery.Attempts)
+		require.Equal(t, int32(http.StatusInternalServerError), delivery.LastStatusCode)
+		require.Equal(t, "webhook answered 500 Internal Server Error", delivery.LastError)
+	}
+
+	// the last attempt fails the delivery, which is not attempted again
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	<-receiver.events
+	delivery := singleDelivery(t, store)
+	require.Equal(t, db.WebhookDeliveryFailed, delivery.Status)
+	require.Equal(t, int32(3), delivery.Attempts)
+
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	require.Empty(t, receiver.events)
+
+	// until it is replayed
+	receiver.status = http.StatusOK
+	_, err := store.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	<-receiver.events
+
+	delivery = singleDelivery(t, store)
+	require.Equal(t, db.WebhookDeliverySucceeded, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Empty(t, delivery.LastError)
+}
+
+func TestDeliverPendingUnreachable(t *testing.T) {
+	store, receiver, account1, account2 := setUp(t, db.WebhookEventTransferReceived)
+	receiver.Close()
+	deliverer := newTestDeliverer(store)
+
+	transfer(t, store, account1, account2)
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+
+	delivery := singleDelivery(t, store)
+	require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Zero(t, delivery.LastStatusCode)
+	require.NotEmpty(t, delivery.LastError)
+	require.WithinDuration(t, time.Now().Add(DefaultBackoff), delivery.NextAttemptAt, time.Second)
+}
+
+func TestDeliverPendingForbiddenAddress(t *testing.T) {
+	store, receiver, account1, account2 := setUp(t, db.WebhookEventTransferReceived)
+	deliverer := NewDeliverer(store, time.Second, time.Second)
+
+	transfer(t, store, account1, account2)
+	require.NoError(t, deliverer.DeliverPending(context.Background()))
+	require.Empty(t, receiver.events)
+
+	delivery := singleDelivery(t, store)
+	require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, ErrForbiddenAddress.Error(), delivery.LastError)
+}
+
+func TestBackoffAfter(t *testing.T) {
+	deliverer := NewDeliverer(db.NewMemoryStore(), time.Second, time.Second)
+
+	require.Equal(t, DefaultBackoff, deliverer.backoffAfter(1))
+	require.Equal(t, 2*DefaultBackoff, deliverer.backoffAfter(2))
+	require.Equal(t, 8*DefaultBackoff, deliverer.backoffAfter(4))
+	require.Equal(t, maxBackoff, deliverer.backoffAfter(30))
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the signature of every delivery, as "t=<unix timestamp>,v1=<signature>".
+// The signature is the hex HMAC-SHA256, keyed by the webhook secret, of the timestamp, a dot and the body,
+// so receivers can reject bodies which were tampered with and, by the timestamp, deliveries replayed later.
+const SignatureHeader = "Webhook-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredSignature = errors.New("webhook signature has expired")
+)
+
+func signature(secret string, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Sign returns the SignatureHeader value of body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := timestamp.Unix()
+	return "t=" + strconv.FormatInt(t, 10) + ",v1=" + hex.EncodeToString(signature(secret, t, body))
+}
+
+// Verify checks header is a signature of body by secret, made less than tolerance before now
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			s, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			signatures = append(signatures, s)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := signature(secret, timestamp, body)
+	for _, s := range signatures {
+		if hmac.Equal(s, expected) {
+			if now.Sub(time.Unix(timestamp, 0)) > tolerance {
+				return ErrExpiredSignature
+			}
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id": 1}`)
+	now := time.Now()
+	header := Sign("secret", now, body)
+
+	testCases := []struct {
+		name string
+		secret string
+		header string
+		body []byte
+		now time.Time
+		err error
+	}{
+		{"Valid", "secret", header, body, now, nil},
+		{"WithinTolerance", "secret", header, body, now.Add(4 * time.Minute), nil},
+		{"Expired", "secret", header, body, now.Add(6 * time.Minute), ErrExpiredSignature},
+		{"WrongSecret", "other", header, body, now, ErrInvalidSignature},
+		{"TamperedBody", "secret", header, []byte(`{"id": 2}`), now, ErrInvalidSignature},
+		{"ExtraSignature", "secret", "v1=00," + header, body, now, nil},
+		{"NoTimestamp", "secret", header[len("t=1234567890,"):], body, now, ErrInvalidSignature},
+		{"Malformed", "secret", "signature", body, now, ErrInvalidSignature},
+		{"Empty", "secret", "", body, now, ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, 5*time.Minute, tc.now)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}